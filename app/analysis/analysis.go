@@ -0,0 +1,415 @@
+package analysis
+
+import (
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/rulesets/osu"
+	"github.com/wieku/rplpa"
+	"math"
+	"sort"
+)
+
+const (
+	frameBinSize  = 2.0
+	frameBinCount = 20
+
+	keyBinSize  = 10.0
+	keyBinCount = 20
+
+	// Cursor has to travel at least snapDistance osu!pixels in a single frame while being almost still before and after the jump to count as a snap
+	snapDistance  = 60.0
+	stillDistance = 2.0
+
+	maxAutocorrelationLag = 5
+
+	minSamples = 50
+)
+
+type Histogram struct {
+	BinSize float64
+	Counts  []int
+	Total   int
+}
+
+type FrameStats struct {
+	Count      int
+	Median     float64
+	MedianRaw  float64
+	Mean       float64
+	StdDev     float64
+	ZeroFrames int
+	Histogram  *Histogram
+	FPS        float64
+}
+
+type KeyStats struct {
+	Name      string
+	Presses   int
+	Mean      float64
+	StdDev    float64
+	Min       float64
+	Histogram *Histogram
+}
+
+type CursorStats struct {
+	MeanVelocity  float64
+	MaxVelocity   float64
+	MeanJerk      float64
+	JerkP99       float64
+	Snaps         int
+	SnapsPer1000  float64
+	StillFrames   int
+	MovingFrames  int
+	StillFraction float64
+}
+
+type HitStats struct {
+	Count              int
+	Mean               float64
+	UnstableRate       float64
+	UnstableRateScaled float64
+	Autocorrelation    []float64
+}
+
+// Report holds statistics of a single replay. Values are converted to real time, so they can be compared between DT/HT and nomod plays.
+type Report struct {
+	Player string
+	Path   string
+	Mods   difficulty.Modifier
+	Speed  float64
+
+	Frames FrameStats
+	Keys   []KeyStats
+	Cursor CursorStats
+	Hits   HitStats
+
+	Flags []string
+}
+
+// Analyze computes frame, key, cursor and hit error statistics of a replay. hitErrors are expected in hit order as provided by osu.OsuRuleSet.
+func Analyze(replay *rplpa.Replay, path string, hitErrors []osu.HitError) *Report {
+	mods := difficulty.Modifier(replay.Mods)
+
+	diff := difficulty.NewDifficulty(5, 5, 5, 5)
+	diff.SetMods(mods)
+
+	report := &Report{
+		Player: replay.Username,
+		Path:   path,
+		Mods:   mods,
+		Speed:  diff.Speed,
+	}
+
+	frames := cleanFrames(replay.ReplayData)
+
+	report.Frames = analyzeFrameTimes(frames, diff)
+	report.Keys = analyzeKeys(frames, diff)
+	report.Cursor = analyzeCursor(frames, diff)
+	report.Hits = analyzeHitErrors(hitErrors, diff)
+
+	report.checkFlags()
+
+	return report
+}
+
+// cleanFrames removes mania seed frame and incorrect first frame, same as replay controller does
+func cleanFrames(source []*rplpa.ReplayData) []*rplpa.ReplayData {
+	frames := make([]*rplpa.ReplayData, 0, len(source))
+
+	for _, frame := range source {
+		if frame.Time == -12345 {
+			continue
+		}
+
+		frames = append(frames, frame)
+	}
+
+	if len(frames) > 0 && frames[0].Time == 0 {
+		frames = frames[1:]
+	}
+
+	return frames
+}
+
+func analyzeFrameTimes(frames []*rplpa.ReplayData, diff *difficulty.Difficulty) (stats FrameStats) {
+	times := make([]float64, 0, len(frames))
+
+	for _, frame := range frames {
+		if frame.Time < 0 {
+			continue
+		}
+
+		if frame.Time == 0 {
+			stats.ZeroFrames++
+		}
+
+		times = append(times, float64(frame.Time))
+	}
+
+	stats.Count = len(times)
+	stats.Histogram = newHistogram(frameBinSize, frameBinCount)
+
+	if len(times) == 0 {
+		return
+	}
+
+	stats.MedianRaw = median(times)
+	stats.Median = diff.GetModifiedTime(stats.MedianRaw)
+
+	for i := range times {
+		times[i] = diff.GetModifiedTime(times[i])
+
+		stats.Histogram.Add(times[i])
+	}
+
+	stats.Mean, stats.StdDev = meanStdDev(times)
+
+	if stats.Median > 0 {
+		stats.FPS = 1000 / stats.Median
+	}
+
+	return
+}
+
+func analyzeKeys(frames []*rplpa.ReplayData, diff *difficulty.Difficulty) []KeyStats {
+	names := []string{"Left", "Right"}
+
+	durations := make([][]float64, len(names))
+	pressStart := make([]int64, len(names))
+	pressed := make([]bool, len(names))
+
+	time := int64(0)
+
+	for _, frame := range frames {
+		time += frame.Time
+
+		if frame.KeyPressed == nil {
+			continue
+		}
+
+		state := []bool{frame.KeyPressed.LeftClick, frame.KeyPressed.RightClick}
+
+		for i := range names {
+			if state[i] && !pressed[i] {
+				pressStart[i] = time
+			} else if !state[i] && pressed[i] {
+				durations[i] = append(durations[i], diff.GetModifiedTime(float64(time-pressStart[i])))
+			}
+
+			pressed[i] = state[i]
+		}
+	}
+
+	stats := make([]KeyStats, len(names))
+
+	for i, name := range names {
+		stats[i].Name = name
+		stats[i].Presses = len(durations[i])
+		stats[i].Histogram = newHistogram(keyBinSize, keyBinCount)
+
+		if len(durations[i]) == 0 {
+			continue
+		}
+
+		stats[i].Mean, stats[i].StdDev = meanStdDev(durations[i])
+		stats[i].Min = math.Inf(1)
+
+		for _, d := range durations[i] {
+			stats[i].Min = min(stats[i].Min, d)
+			stats[i].Histogram.Add(d)
+		}
+	}
+
+	return stats
+}
+
+func analyzeCursor(frames []*rplpa.ReplayData, diff *difficulty.Difficulty) (stats CursorStats) {
+	var distances, deltas []float64
+
+	for i := 1; i < len(frames); i++ {
+		if frames[i].Time <= 0 {
+			continue
+		}
+
+		dx := float64(frames[i].MouseX - frames[i-1].MouseX)
+		dy := float64(frames[i].MouseY - frames[i-1].MouseY)
+
+		distances = append(distances, math.Sqrt(dx*dx+dy*dy))
+		deltas = append(deltas, diff.GetModifiedTime(float64(frames[i].Time)))
+	}
+
+	if len(distances) == 0 {
+		return
+	}
+
+	velocities := make([]float64, len(distances))
+
+	for i, d := range distances {
+		velocities[i] = d / deltas[i]
+
+		stats.MaxVelocity = max(stats.MaxVelocity, velocities[i])
+
+		if d < stillDistance {
+			stats.StillFrames++
+		} else {
+			stats.MovingFrames++
+		}
+	}
+
+	stats.MeanVelocity, _ = meanStdDev(velocities)
+	stats.StillFraction = float64(stats.StillFrames) / float64(len(distances))
+
+	var jerks []float64
+
+	prevAcceleration := 0.0
+
+	for i := 1; i < len(velocities); i++ {
+		acceleration := (velocities[i] - velocities[i-1]) / deltas[i]
+
+		if i > 1 {
+			jerks = append(jerks, math.Abs(acceleration-prevAcceleration)/deltas[i])
+		}
+
+		prevAcceleration = acceleration
+	}
+
+	if len(jerks) > 0 {
+		stats.MeanJerk, _ = meanStdDev(jerks)
+		stats.JerkP99 = percentile(jerks, 0.99)
+	}
+
+	for i := 1; i < len(distances)-1; i++ {
+		if distances[i] >= snapDistance && distances[i-1] < stillDistance && distances[i+1] < stillDistance {
+			stats.Snaps++
+		}
+	}
+
+	stats.SnapsPer1000 = float64(stats.Snaps) * 1000 / float64(len(distances))
+
+	return
+}
+
+func analyzeHitErrors(hitErrors []osu.HitError, diff *difficulty.Difficulty) (stats HitStats) {
+	stats.Count = len(hitErrors)
+
+	if len(hitErrors) == 0 {
+		return
+	}
+
+	offsets := make([]float64, len(hitErrors))
+	for i, e := range hitErrors {
+		offsets[i] = e.Error
+	}
+
+	var stdDev float64
+
+	stats.Mean, stdDev = meanStdDev(offsets)
+	stats.UnstableRate = stdDev * 10
+	stats.UnstableRateScaled = stats.UnstableRate / diff.Speed
+
+	for lag := 1; lag <= maxAutocorrelationLag && lag < len(offsets); lag++ {
+		stats.Autocorrelation = append(stats.Autocorrelation, autocorrelation(offsets, stats.Mean, lag))
+	}
+
+	return
+}
+
+func (report *Report) checkFlags() {
+	assisted := report.Mods.Active(difficulty.Autoplay | difficulty.Relax | difficulty.Relax2)
+
+	if report.Frames.Count > 0 && report.Frames.Median <= 13 && !assisted {
+		report.Flags = append(report.Flags, "Median frame time is lower than expected for declared mods, replay was probably timewarped")
+	}
+
+	if !assisted {
+		for _, key := range report.Keys {
+			if key.Presses >= minSamples && key.StdDev < 5 {
+				report.Flags = append(report.Flags, "Durations of "+key.Name+" key presses are unusually consistent")
+			}
+		}
+	}
+
+	if report.Cursor.Snaps >= 10 && !report.Mods.Active(difficulty.Relax2) {
+		report.Flags = append(report.Flags, "Cursor snaps between still positions multiple times")
+	}
+
+	if report.Hits.Count >= minSamples && report.Hits.UnstableRateScaled < 50 && !report.Mods.Active(difficulty.Autoplay|difficulty.Relax) {
+		report.Flags = append(report.Flags, "Unstable rate is unusually low")
+	}
+}
+
+func newHistogram(binSize float64, bins int) *Histogram {
+	return &Histogram{
+		BinSize: binSize,
+		Counts:  make([]int, bins+1),
+	}
+}
+
+// Add puts the value in its bin, last bin collects all values exceeding the range
+func (histogram *Histogram) Add(value float64) {
+	bin := min(max(int(value/histogram.BinSize), 0), len(histogram.Counts)-1)
+
+	histogram.Counts[bin]++
+	histogram.Total++
+}
+
+func meanStdDev(values []float64) (mean, stdDev float64) {
+	if len(values) == 0 {
+		return
+	}
+
+	for _, v := range values {
+		mean += v
+	}
+
+	mean /= float64(len(values))
+
+	for _, v := range values {
+		stdDev += (v - mean) * (v - mean)
+	}
+
+	stdDev = math.Sqrt(stdDev / float64(len(values)))
+
+	return
+}
+
+func median(values []float64) float64 {
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+
+	sort.Float64s(sorted)
+
+	l := len(sorted)
+
+	if l%2 == 0 {
+		return (sorted[l/2] + sorted[l/2-1]) / 2
+	}
+
+	return sorted[l/2]
+}
+
+func percentile(values []float64, p float64) float64 {
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+
+	sort.Float64s(sorted)
+
+	return sorted[min(int(p*float64(len(sorted))), len(sorted)-1)]
+}
+
+func autocorrelation(values []float64, mean float64, lag int) float64 {
+	var num, den float64
+
+	for i, v := range values {
+		den += (v - mean) * (v - mean)
+
+		if i+lag < len(values) {
+			num += (v - mean) * (values[i+lag] - mean)
+		}
+	}
+
+	if den == 0 {
+		return 0
+	}
+
+	return num / den
+}
@@ -0,0 +1,151 @@
+package analysis
+
+import (
+	"fmt"
+	"github.com/wieku/danser-go/app/beatmap"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const histogramWidth = 40
+
+// WriteReport saves reports of all analyzed replays of the beatmap to a text file
+func WriteReport(path string, bMap *beatmap.BeatMap, reports []*Report) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	w := &reportWriter{w: file}
+
+	w.line("danser replay analysis")
+	w.line("Generated: %s", time.Now().Format(time.RFC1123))
+	w.line("Beatmap:   %s - %s [%s] (%s)", bMap.Artist, bMap.Name, bMap.Difficulty, bMap.MD5)
+	w.line("")
+	w.line("Flags are heuristics meant to point at replays worth a closer look, they are not a proof of cheating.")
+
+	for _, report := range reports {
+		w.line("")
+		report.write(w)
+	}
+
+	return w.err
+}
+
+func (report *Report) write(w *reportWriter) {
+	title := fmt.Sprintf("Player: %s", report.Player)
+
+	w.line("%s", title)
+	w.line("%s", strings.Repeat("=", len(title)))
+
+	if report.Path != "" {
+		w.line("Replay: %s", report.Path)
+	}
+
+	w.line("Mods:   %s (speed %.2fx)", report.Mods.String(), report.Speed)
+	w.line("")
+
+	if len(report.Flags) == 0 {
+		w.line("Flags: none")
+	} else {
+		w.line("Flags:")
+
+		for _, flag := range report.Flags {
+			w.line("  ! %s", flag)
+		}
+	}
+
+	w.line("")
+	w.line("Frame times (real time):")
+	w.line("  Frames:         %d (%d with zero delta)", report.Frames.Count, report.Frames.ZeroFrames)
+	w.line("  Median:         %.2fms (%.2fms in replay, ~%.0f fps)", report.Frames.Median, report.Frames.MedianRaw, report.Frames.FPS)
+	w.line("  Mean:           %.2fms", report.Frames.Mean)
+	w.line("  Std deviation:  %.2fms", report.Frames.StdDev)
+	w.histogram(report.Frames.Histogram, "ms")
+
+	w.line("")
+	w.line("Key presses (real time):")
+
+	for _, key := range report.Keys {
+		w.line("  %s: %d presses", key.Name, key.Presses)
+
+		if key.Presses == 0 {
+			continue
+		}
+
+		w.line("    Mean:           %.2fms", key.Mean)
+		w.line("    Std deviation:  %.2fms", key.StdDev)
+		w.line("    Shortest:       %.2fms", key.Min)
+		w.histogram(key.Histogram, "ms")
+	}
+
+	w.line("")
+	w.line("Cursor movement (osu!pixels, real time):")
+	w.line("  Mean velocity:  %.3fpx/ms", report.Cursor.MeanVelocity)
+	w.line("  Max velocity:   %.3fpx/ms", report.Cursor.MaxVelocity)
+	w.line("  Mean jerk:      %.5fpx/ms³", report.Cursor.MeanJerk)
+	w.line("  99th pct jerk:  %.5fpx/ms³", report.Cursor.JerkP99)
+	w.line("  Still frames:   %d (%.1f%%)", report.Cursor.StillFrames, report.Cursor.StillFraction*100)
+	w.line("  Snaps:          %d (%.2f per 1000 frames)", report.Cursor.Snaps, report.Cursor.SnapsPer1000)
+
+	w.line("")
+	w.line("Hit errors:")
+	w.line("  Hits:           %d", report.Hits.Count)
+
+	if report.Hits.Count == 0 {
+		return
+	}
+
+	w.line("  Mean error:     %+.2fms", report.Hits.Mean)
+	w.line("  Unstable rate:  %.2f (%.2f converted)", report.Hits.UnstableRate, report.Hits.UnstableRateScaled)
+
+	for i, r := range report.Hits.Autocorrelation {
+		w.line("  Autocorr lag %d: %+.3f", i+1, r)
+	}
+}
+
+type reportWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (w *reportWriter) line(format string, args ...any) {
+	if w.err != nil {
+		return
+	}
+
+	_, w.err = fmt.Fprintf(w.w, format+"\n", args...)
+}
+
+func (w *reportWriter) histogram(histogram *Histogram, unit string) {
+	if histogram == nil || histogram.Total == 0 {
+		return
+	}
+
+	maxCount := 0
+	for _, c := range histogram.Counts {
+		maxCount = max(maxCount, c)
+	}
+
+	last := len(histogram.Counts) - 1
+
+	for i, c := range histogram.Counts {
+		label := fmt.Sprintf("%g-%g%s", float64(i)*histogram.BinSize, float64(i+1)*histogram.BinSize, unit)
+		if i == last {
+			label = fmt.Sprintf("%g%s+", float64(i)*histogram.BinSize, unit)
+		}
+
+		bar := strings.Repeat("#", c*histogramWidth/maxCount)
+
+		w.line("    %10s |%-*s %d (%.1f%%)", label, histogramWidth, bar, c, float64(c)*100/float64(histogram.Total))
+	}
+}
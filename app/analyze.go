@@ -0,0 +1,65 @@
+package app
+
+import (
+	"github.com/wieku/danser-go/app/analysis"
+	"github.com/wieku/danser-go/app/dance"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/app/states"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func mainLoopAnalyze() {
+	p, _ := player.(*states.Player)
+
+	controller, ok := p.GetController().(*dance.ReplayController)
+	if !ok {
+		log.Println("Analysis requires replays, skipping...")
+		return
+	}
+
+	log.Println("Analyzing replays...")
+
+	for !p.Update(1) {
+	}
+
+	ruleset := controller.GetRuleset()
+
+	var reports []*analysis.Report
+
+	for i, rp := range controller.GetReplays() {
+		if rp.Path == "" { // danser's own play
+			continue
+		}
+
+		replay, err := loadReplay(rp.Path)
+		if err != nil {
+			log.Println("Failed to load replay for analysis:", err)
+			continue
+		}
+
+		reports = append(reports, analysis.Analyze(replay, rp.Path, ruleset.GetHitErrors(controller.GetReplayCursor(i))))
+	}
+
+	name := output
+	if strings.TrimSpace(name) == "" {
+		name = "danser_" + time.Now().Format("2006-01-02_15-04-05")
+	}
+
+	path := filepath.Join(settings.Recording.GetOutputDir(), name+".analysis.txt")
+
+	if err := analysis.WriteReport(path, ruleset.GetBeatMap(), reports); err != nil {
+		log.Println("Failed to save the analysis report! Error:", err)
+		return
+	}
+
+	for _, report := range reports {
+		for _, flag := range report.Flags {
+			log.Println(report.Player+":", flag)
+		}
+	}
+
+	log.Println("Analysis report saved to:", path)
+}
@@ -2,7 +2,7 @@ package app
 
 import "C"
 import (
-	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"github.com/faiface/mainthread"
@@ -12,6 +12,7 @@ import (
 	"github.com/wieku/danser-go/app/beatmap"
 	difficulty2 "github.com/wieku/danser-go/app/beatmap/difficulty"
 	camera2 "github.com/wieku/danser-go/app/bmath/camera"
+	"github.com/wieku/danser-go/app/dance"
 	"github.com/wieku/danser-go/app/database"
 	"github.com/wieku/danser-go/app/discord"
 	"github.com/wieku/danser-go/app/ffmpeg"
@@ -40,6 +41,7 @@ import (
 	"log"
 	"math"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"time"
@@ -72,11 +74,15 @@ var output string
 var recordMode bool
 var screenshotMode bool
 var screenshotTime float64
+var thumbnailMode bool
+var analyzeMode bool
 
 var preciseProgress bool
 
 var monitorHz int
 
+var batchMode bool
+
 func run() {
 	defer func() {
 		if err := recover(); err != nil {
@@ -85,6 +91,19 @@ func run() {
 		}
 	}()
 
+	args := os.Args[1:]
+
+	if len(args) > 0 && args[0] == replayCommand {
+		var err error
+		if args, err = runReplayTool(args[1:]); err != nil {
+			panic(fmt.Sprintf("Failed to edit the replay: %s", err))
+		}
+
+		if !recalcMode {
+			return
+		}
+	}
+
 	mainthread.Call(func() {
 		id := flag.Int64("id", -1, "Specify the beatmap id. Overrides other beatmap search flags")
 
@@ -102,12 +121,18 @@ func run() {
 		creator := flag.String("creator", "", creatorDesc)
 		flag.StringVar(creator, "c", "", creatorDesc+shorthand)
 
+		query := flag.String("query", "", "Pick the first beatmap matching a search query, for example \"stars>6 ar>=9.5 length<180 creator=xyz\". Same syntax as in launcher's song select. Overridden by -id and -md5")
+		random := flag.Bool("random", false, "Pick a random beatmap matching -query instead of the first one")
+
 		settingsVersion := flag.String("settings", "", "Specify settings version, -settings=b/abc means that settings/b/abc.json will be loaded. \"Credentials\"")
 		cursors := flag.Int("cursors", 1, "How many repeated cursors should be visible, recommended 2 for mirror, 8 for mandala")
 		tag := flag.Int("tag", 1, "How many cursors should be \"playing\" specific map. 2 means that 1st cursor clicks the 1st object, 2nd clicks 2nd object, 1st clicks 3rd and so on")
 
 		knockout := flag.Bool("knockout", false, "Use (classic) knockout feature. Replays are sourced from \"replays/{a}\" where {a} is an md5 hash of .osu file. Danser automatically organizes replay files put directly in \"replays\", using maps' md5s provided by the replay files.")
-		knockout2 := flag.String("knockout2", "", "Use (new) knockout feature, JSON list of paths to compatible replay files has to be provided. \"Knockout.ExcludeMods\" and \"Knockout.MaxPlayers\" options are ignored, they have to be filtered beforehand.")
+		knockout2 := flag.String("knockout2", "", "Use (new) knockout feature, JSON list of paths to compatible replay files has to be provided. \"Knockout.ExcludeMods\" and \"Knockout.MaxPlayers\" options are ignored, they have to be filtered beforehand. Entries can also be {\"path\": \"a.osr\", \"team\": \"Red\"} objects, which enables Team vs Team mode.")
+		teams := flag.String("teams", "", "Use Team vs Team knockout mode. JSON list of teams or path to a JSON file has to be provided, for example [{\"name\": \"Red\", \"color\": \"#ff4040\", \"players\": [\"player1\", \"replays/b.osr\"]}]. Players are matched by username or replay path. Sets -knockout flag")
+
+		split := flag.Bool("split", false, "Use Split Screen knockout mode. Up to 4 replays are shown on separate playfields, each with its own hit objects, HUD and hit error meter. Sets -knockout flag")
 
 		speed := flag.Float64("speed", 1.0, "Specify music's speed, set to 1.5 to have DoubleTime mod experience")
 		pitch := flag.Float64("pitch", 1.0, "Specify music's pitch, set to 1.5 with -speed=1.5 to have Nightcore mod experience")
@@ -132,6 +157,11 @@ func run() {
 		replay := flag.String("replay", "", replayDesc)
 		flag.StringVar(replay, "r", "", replayDesc+shorthand)
 
+		ghost := flag.String("ghost", "", "Path to a reference replay shown as a translucent ghost cursor next to the replay given by -replay, e.g. player's own PB or the top score. Timing and position differences are shown on every object, see Gameplay.Ghost settings")
+
+		spectate := flag.String("spectate", "", "Spectate a player by judging replay frames streamed to given address in real time, for example tcp://127.0.0.1:7270 or ws://127.0.0.1:7270/spectate. Frames use .osr format: \"delta|x|y|keys\" separated by commas or new lines. Beatmap and mods have to be specified separately")
+		spectatorName := flag.String("spectatorname", "Spectator", "Player's name shown in -spectate mode")
+
 		skin := flag.String("skin", "", "Replace Skin.CurrentSkin setting temporarily")
 
 		noDbCheck := flag.Bool("nodbcheck", false, "Don't validate the database and only import new beatmap sets if there are any. Useful for slow drives.")
@@ -146,12 +176,49 @@ func run() {
 
 		flag.BoolVar(&preciseProgress, "preciseprogress", false, "Show rendering progress in 1% increments")
 
-		flag.Parse()
+		batchFile := flag.String("batch", "", "Render multiple videos described by a JSON or YAML job file. Beatmap, replay, mods, skin and output flags are ignored, -settings is used for jobs without a settings profile. Per-job status is saved to <file>.results.json")
+
+		thumbnail := flag.Bool("thumbnail", false, "Thumbnail mode. Plays the map without recording and saves the results card as PNG in Recording.OutputDir. Specify the name of file by -out, size and layout are managed by Recording.Thumbnail settings")
+
+		stream := flag.String("stream", "", "Stream the video live to given RTMP or SRT url instead of saving it to Recording.OutputDir, for example rtmp://localhost/live/danser. Rendering is paced to real time, encoder settings are taken from Recording settings. Sets -record flag")
+
+		analyze := flag.Bool("analyze", false, "Analysis mode. Simulates replays given by -replay, -knockout or -knockout2 without recording and saves a report with frame time, key press, cursor movement and hit error statistics to Recording.OutputDir. Specify the name of file by -out")
+
+		lintSet := flag.Bool("lint", false, "Lint mode. Checks all difficulties of the selected beatmap's set and its storyboard for unsnapped and overlapping objects, broken sliders, missing files, inconsistent sample sets and abnormal SV without opening a window. Report is printed to the console or saved to Recording.OutputDir if -out is specified. Exits with code 1 if errors were found")
+		lintFormat := flag.String("lintformat", "text", "Format of -lint report: text or json")
+
+		export := flag.Bool("export", false, "Export mode. Saves the selected beatmap's set as .osz in Recording.OutputDir. If -speed or -pitch is given, timing points and objects are rescaled, audio is re-rendered with changed tempo and pitch (requires ffmpeg) and difficulty names get a rate suffix, -ar, -od, -cs and -hp are applied as well. Specify the name of file by -out")
+
+		importPaths := flag.String("import", "", "Import .osz and .olz files into Songs folder and the database, for example -import=\"a.osz|downloads\". Multiple files or directories can be separated by |. Packages already present in the database or duplicated in given files are skipped, packages with an existing set ID but different difficulties are reported as conflicts and skipped")
+		dryRun := flag.Bool("dryrun", false, "Only report what -import would do without extracting anything")
+
+		deterministic := flag.Bool("deterministic", false, "Render with fixed random seeds and timestamps so repeated renders of the same input produce identical frames. Per-frame SHA-1 hashes are saved next to the video as <out>.hashes.txt. Sets -record flag unless -ss or -thumbnail is used")
+
+		_ = flag.CommandLine.Parse(args)
 
 		var knockoutReplays []string
+		var knockoutTeams []*settings.KnockoutTeam
+
+		if *teams != "" {
+			var err error
+			if knockoutTeams, err = parseTeams(*teams); err != nil {
+				panic(fmt.Sprintf("Failed to parse teams: %s", err))
+			}
+
+			*knockout = true
+		}
+
+		if *split {
+			if *teams != "" {
+				panic("Incompatible flags selected: -split, -teams")
+			}
+
+			*knockout = true
+		}
 
 		if *knockout2 != "" {
-			if err := json.Unmarshal([]byte(*knockout2), &knockoutReplays); err != nil {
+			var err error
+			if knockoutReplays, knockoutTeams, err = parseKnockoutReplays(*knockout2, knockoutTeams); err != nil {
 				panic(fmt.Sprintf("Failed to parse replay list: %s", err))
 			}
 
@@ -164,14 +231,50 @@ func run() {
 
 		if *out != "" {
 			output = *out
-			if math.IsNaN(*ss) {
+			if math.IsNaN(*ss) && !*thumbnail && !*analyze && !*lintSet && !*export && *importPaths == "" {
 				*record = true
 			}
 		}
 
+		if *deterministic && math.IsNaN(*ss) && !*thumbnail && !*analyze {
+			*record = true
+		}
+
+		if *stream != "" {
+			if !math.IsNaN(*ss) {
+				panic("Incompatible flags selected: -stream, -ss")
+			} else if *thumbnail {
+				panic("Incompatible flags selected: -stream, -thumbnail")
+			}
+
+			*record = true
+		}
+
 		recordMode = *record
 		screenshotMode = !math.IsNaN(*ss)
 		screenshotTime = *ss
+		thumbnailMode = *thumbnail
+		analyzeMode = *analyze
+
+		if *batchFile != "" {
+			if *play {
+				panic("Incompatible flags selected: -batch, -play")
+			} else if screenshotMode {
+				panic("Incompatible flags selected: -batch, -ss")
+			}
+
+			var err error
+			if batchJobs, err = loadBatchJobs(*batchFile); err != nil {
+				panic(fmt.Sprintf("Failed to load batch file: %s", err))
+			}
+
+			batchPath = *batchFile
+			batchMode = true
+			recordMode = true
+
+			*replay = ""
+			*knockout = false
+		}
 
 		if *record && *play {
 			panic("Incompatible flags selected: -record, -play")
@@ -185,34 +288,72 @@ func run() {
 			panic("Incompatible flags selected: -ss, -play")
 		} else if screenshotMode && recordMode {
 			panic("Incompatible flags selected: -ss, -record")
+		} else if thumbnailMode && *play {
+			panic("Incompatible flags selected: -thumbnail, -play")
+		} else if thumbnailMode && screenshotMode {
+			panic("Incompatible flags selected: -thumbnail, -ss")
+		} else if thumbnailMode && recordMode {
+			panic("Incompatible flags selected: -thumbnail, -record")
+		} else if analyzeMode && *play {
+			panic("Incompatible flags selected: -analyze, -play")
+		} else if analyzeMode && screenshotMode {
+			panic("Incompatible flags selected: -analyze, -ss")
+		} else if analyzeMode && recordMode {
+			panic("Incompatible flags selected: -analyze, -record")
+		} else if analyzeMode && thumbnailMode {
+			panic("Incompatible flags selected: -analyze, -thumbnail")
+		} else if analyzeMode && *replay == "" && !*knockout {
+			panic("-analyze requires -replay, -knockout or -knockout2 flag")
+		} else if *ghost != "" && *replay == "" {
+			panic("-ghost requires -replay flag")
+		} else if *spectate != "" && *play {
+			panic("Incompatible flags selected: -spectate, -play")
+		} else if *spectate != "" && (*replay != "" || *knockout) {
+			panic("Incompatible flags selected: -spectate, -replay/-knockout")
+		} else if *spectate != "" && (screenshotMode || thumbnailMode || analyzeMode || batchMode) {
+			panic("-spectate can't be used with -ss, -thumbnail, -analyze or -batch")
+		} else if *spectate != "" && recordMode && *stream == "" {
+			panic("-spectate can be recorded only with -stream, frames are received in real time")
+		} else if *lintSet && (*play || recordMode || screenshotMode || thumbnailMode || analyzeMode || batchMode || *spectate != "") {
+			panic("-lint can't be used with -play, -record, -ss, -thumbnail, -analyze, -batch or -spectate")
+		} else if *export && (*play || recordMode || screenshotMode || thumbnailMode || analyzeMode || batchMode || *spectate != "" || *lintSet) {
+			panic("-export can't be used with -play, -record, -ss, -thumbnail, -analyze, -batch, -spectate or -lint")
+		} else if *export && *speed == 1 && *pitch == 1 && (!math.IsNaN(*ar) || !math.IsNaN(*od) || !math.IsNaN(*cs) || !math.IsNaN(*hp)) {
+			panic("-export applies -ar, -od, -cs and -hp only with -speed or -pitch flag")
+		} else if *importPaths != "" && (*play || recordMode || screenshotMode || thumbnailMode || analyzeMode || batchMode || *spectate != "" || *lintSet || *export) {
+			panic("-import can't be used with -play, -record, -ss, -thumbnail, -analyze, -batch, -spectate, -lint or -export")
+		} else if *dryRun && *importPaths == "" {
+			panic("-dryrun requires -import flag")
+		} else if *lintSet && *lintFormat != "text" && *lintFormat != "json" {
+			panic(fmt.Sprintf("flag -lintformat: unknown format \"%s\"", *lintFormat))
 		}
 
 		modsParsed := difficulty2.ParseMods(*mods)
 
 		if *replay != "" {
-			bytes, err := ioutil.ReadFile(*replay)
-			if err != nil {
-				panic(err)
-			}
-
-			rp, err := rplpa.ParseReplay(bytes)
+			rp, err := loadReplay(*replay)
 			if err != nil {
 				panic(err)
 			}
 
-			if rp.PlayMode != 0 {
-				panic("Modes other than osu!standard are not supported")
-			}
-
-			if rp.ReplayData == nil || len(rp.ReplayData) < 2 {
-				panic("Replay is missing input data")
-			}
-
 			*md5 = rp.BeatmapMD5
 			*id = -1
 			modsParsed = difficulty2.Modifier(rp.Mods)
 			*knockout = true
 			settings.REPLAY = *replay
+
+			if *ghost != "" {
+				gp, err := loadReplay(*ghost)
+				if err != nil {
+					panic(fmt.Sprintf("Failed to load ghost replay: %s", err))
+				}
+
+				if !strings.EqualFold(gp.BeatmapMD5, rp.BeatmapMD5) {
+					panic("Ghost replay was played on a different beatmap")
+				}
+
+				settings.GHOST = *ghost
+			}
 		}
 
 		if !modsParsed.Compatible() {
@@ -221,7 +362,7 @@ func run() {
 
 		closeAfterSettingsLoad := false
 
-		if (*md5+*artist+*title+*difficulty+*creator) == "" && *id < 0 {
+		if !batchMode && *importPaths == "" && (*md5+*artist+*title+*difficulty+*creator+*query) == "" && *id < 0 {
 			log.Println("No beatmap specified, closing...")
 			closeAfterSettingsLoad = true
 		}
@@ -229,6 +370,7 @@ func run() {
 		settings.DEBUG = *debug
 		settings.KNOCKOUT = *knockout
 		settings.KNOCKOUTREPLAYS = knockoutReplays
+		settings.KNOCKOUTTEAMS = knockoutTeams
 		settings.PLAY = *play
 		settings.DIVIDES = *cursors
 		settings.TAG = *tag
@@ -237,8 +379,14 @@ func run() {
 		settings.SKIP = *skip
 		settings.START = *start
 		settings.END = *end
-		settings.RECORD = recordMode || screenshotMode
+		settings.SPECTATE = *spectate
+		settings.SPECTATORNAME = *spectatorName
+		settings.RECORD = recordMode || screenshotMode || thumbnailMode || analyzeMode || recalcMode
 		settings.LOCALOFFSET = *offset
+		settings.DETERMINISTIC = *deterministic
+		settings.STREAM = strings.TrimSpace(*stream)
+
+		batchSettings = *settingsVersion
 
 		if *settingsVersion == "credentials" || *settingsVersion == "launcher" {
 			panic(fmt.Sprintf("flag -settings: name \"%s\" is forbidden", *settingsVersion))
@@ -260,62 +408,54 @@ func run() {
 			err := database.Init()
 			if err != nil {
 				log.Println("Failed to initialize database:", err)
+			} else if *importPaths != "" {
+				code := runImport(*importPaths, *dryRun, *noDbCheck)
+
+				database.Close()
+				os.Exit(code)
 			} else {
 				beatmaps := database.LoadBeatmaps(*noDbCheck, nil)
 
-				if *id > -1 {
-					for _, b := range beatmaps {
-						if b.ID == *id {
-							beatMap = b
-
-							break
-						}
-					}
-				} else if *md5 != "" {
-					for _, b := range beatmaps {
-						if strings.EqualFold(b.MD5, *md5) {
-							beatMap = b
-
-							break
-						}
-					}
+				if batchMode {
+					batchBeatmaps = beatmaps
+					batchJobs = expandCollectionJobs(batchJobs, beatmaps)
+				} else if *query != "" && *id < 0 && *md5 == "" {
+					beatMap = queryBeatmap(beatmaps, *query, *random)
 				} else {
-					for _, b := range beatmaps {
-						if (*artist == "" || strings.EqualFold(*artist, b.Artist)) &&
-							(*title == "" || strings.EqualFold(*title, b.Name)) &&
-							(*difficulty == "" || strings.EqualFold(*difficulty, b.Difficulty)) &&
-							(*creator == "" || strings.EqualFold(*creator, b.Creator)) {
-							beatMap = b
-
-							break
-						}
-					}
-
-					if beatMap == nil {
-						log.Println("Beatmap with exact parameters not found, searching partially...")
-						for _, b := range beatmaps {
-							if (*artist == "" || strings.Contains(strings.ToLower(b.Artist), strings.ToLower(*artist))) &&
-								(*title == "" || strings.Contains(strings.ToLower(b.Name), strings.ToLower(*title))) &&
-								(*difficulty == "" || strings.Contains(strings.ToLower(b.Difficulty), strings.ToLower(*difficulty))) &&
-								(*creator == "" || strings.Contains(strings.ToLower(b.Creator), strings.ToLower(*creator))) {
-								beatMap = b
-
-								break
-							}
-						}
-					}
+					beatMap = findBeatmap(beatmaps, *id, *md5, *artist, *title, *difficulty, *creator)
 				}
 			}
 
-			if beatMap == nil {
+			if batchMode {
+				if batchBeatmaps == nil {
+					log.Println("Beatmap database is not available, closing...")
+					closeAfterSettingsLoad = true
+				}
+			} else if beatMap == nil {
 				log.Println("Beatmap not found, closing...")
 				closeAfterSettingsLoad = true
 			} else {
 				beatMap.UpdatePlayStats()
 				database.UpdatePlayStats(beatMap)
+
+				if err := dance.LoadLibraryReplays(beatMap.MD5); err != nil {
+					log.Println("Failed to load replays from the library:", err)
+				}
 			}
 
 			database.Close()
+
+			if *lintSet && !closeAfterSettingsLoad {
+				os.Exit(runLint(beatMap, *lintFormat))
+			}
+
+			if *export && !closeAfterSettingsLoad {
+				if *speed == 1 && *pitch == 1 {
+					os.Exit(runSetExport(beatMap))
+				}
+
+				os.Exit(runRateExport(beatMap, *speed, *pitch, *ar, *od, *cs, *hp))
+			}
 		}
 
 		assets.Init(build.Stream == "Dev")
@@ -376,14 +516,15 @@ func run() {
 		}
 
 		if settings.RECORD {
-			//HACK: some in-app variables depend on these settings so we force them here
-			settings.Graphics.VSync = false
-			settings.Graphics.ShowFPS = false
-			settings.DEBUG = false
-			settings.Graphics.Fullscreen = false
-			settings.Graphics.WindowWidth = int64(settings.Recording.FrameWidth)
-			settings.Graphics.WindowHeight = int64(settings.Recording.FrameHeight)
-			settings.Playfield.LeadInTime = 0
+			applyRecordOverrides()
+		}
+
+		if settings.KNOCKOUTTEAMS != nil {
+			settings.Knockout.Mode = settings.TeamVsTeam
+		}
+
+		if *split {
+			settings.Knockout.Mode = settings.SplitScreen
 		}
 
 		if screenshotMode {
@@ -416,7 +557,11 @@ func run() {
 			})
 		}
 
-		win.SetTitle("danser " + build.VERSION + " - " + beatMap.Artist + " - " + beatMap.Name + " [" + beatMap.Difficulty + "]")
+		if batchMode {
+			win.SetTitle("danser " + build.VERSION + " - batch rendering")
+		} else {
+			win.SetTitle("danser " + build.VERSION + " - " + beatMap.Artist + " - " + beatMap.Name + " [" + beatMap.Difficulty + "]")
+		}
 		input.Win = win
 
 		if cTime := time.Now(); cTime.Month() == 12 && cTime.Day() >= 6 {
@@ -467,19 +612,14 @@ func run() {
 		bass.Init(settings.RECORD)
 		audio.LoadSamples()
 
+		if batchMode {
+			batchWidth, batchHeight = settings.Graphics.GetWidth(), settings.Graphics.GetHeight()
+			return
+		}
+
 		speedBefore := settings.SPEED
 
-		if modsParsed.Active(difficulty2.Nightcore) {
-			settings.SPEED *= 1.5
-			settings.PITCH *= 1.5
-		} else if modsParsed.Active(difficulty2.DoubleTime) {
-			settings.SPEED *= 1.5
-		} else if modsParsed.Active(difficulty2.Daycore) {
-			settings.PITCH *= 0.75
-			settings.SPEED *= 0.75
-		} else if modsParsed.Active(difficulty2.HalfTime) {
-			settings.SPEED *= 0.75
-		}
+		applySpeedMods(modsParsed)
 
 		if settings.PLAY || !settings.KNOCKOUT || allowDA {
 			if !math.IsNaN(*ar) {
@@ -501,22 +641,30 @@ func run() {
 			beatMap.Diff.SetCustomSpeed(speedBefore)
 		}
 
-		beatMap.Diff.SetMods(modsParsed)
-		beatmap.ParseTimingPointsAndPauses(beatMap)
-		beatmap.ParseObjects(beatMap, false, true)
-		beatMap.LoadCustomSamples()
-		player = states.NewPlayer(beatMap)
+		loadPlayer(beatMap, modsParsed)
 
 		limiter = frame.NewLimiter(int(settings.Graphics.FPSCap))
 	})
 
-	if recordMode {
+	if batchMode {
+		runBatch(batchJobs, batchPath)
+	} else if recordMode {
 		mainLoopRecord()
 	} else if screenshotMode {
 		mainLoopSS()
+	} else if thumbnailMode {
+		mainLoopThumbnail()
+	} else if analyzeMode {
+		mainLoopAnalyze()
+	} else if recalcMode {
+		mainLoopRecalc()
 	} else {
 		mainLoopNormal()
 	}
+
+	if player != nil {
+		player.Dispose()
+	}
 }
 
 func mainLoopRecord() {
@@ -533,12 +681,27 @@ func mainLoopRecord() {
 
 	var fbo *buffer.Framebuffer
 
-	mainthread.Call(func() {
+	callMain(func() {
 		fbo = buffer.NewFrameMultisampleScreen(w, h, false, 0)
 	})
 
+	// A failed batch job is recovered by runBatchJob, ffmpeg and the framebuffer mustn't outlive it
+	finished := false
+
+	defer func() {
+		if !finished {
+			callMain(fbo.Dispose)
+		}
+	}()
+
 	ffmpeg.StartFFmpeg(int(fps), w, h, audioFPS, output)
 
+	defer func() {
+		if !finished {
+			callMain(ffmpeg.StopFFmpeg)
+		}
+	}()
+
 	updateFPS := max(fps, 1000)
 	updateDelta := 1000 / updateFPS
 	fpsDelta := 1000 / fps
@@ -568,7 +731,7 @@ func mainLoopRecord() {
 
 		deltaSumF += updateDelta
 		if deltaSumF >= fpsDelta {
-			mainthread.Call(func() {
+			callMain(func() {
 				fbo.Bind()
 
 				ffmpeg.PreFrame()
@@ -610,9 +773,172 @@ func mainLoopRecord() {
 		}
 	}
 
-	mainthread.Call(func() {
+	callMain(func() {
 		ffmpeg.StopFFmpeg()
+
+		fbo.Dispose()
 	})
+
+	finished = true
+
+	if settings.Recording.Thumbnail.Enabled {
+		makeThumbnail(filepath.Join(settings.Recording.GetOutputDir(), ffmpeg.GetOutputName()+".png"))
+	}
+
+	if settings.Knockout.ExportTimeline {
+		exportKnockoutTimeline(filepath.Join(settings.Recording.GetOutputDir(), ffmpeg.GetOutputName()))
+	}
+}
+
+func exportKnockoutTimeline(path string) {
+	p, _ := player.(*states.Player)
+
+	timeline := p.GetKnockoutTimeline()
+	if timeline == nil {
+		log.Println("Knockout timeline is available only in knockout renders, skipping...")
+		return
+	}
+
+	paths, err := timeline.Save(path, settings.Knockout.TimelineFormat)
+	if err != nil {
+		log.Println("Failed to save knockout timeline! Error:", err)
+		return
+	}
+
+	for _, path := range paths {
+		log.Println("Knockout timeline saved to:", path)
+	}
+}
+
+func findBeatmap(beatmaps []*beatmap.BeatMap, id int64, md5, artist, title, difficulty, creator string) *beatmap.BeatMap {
+	if id > -1 {
+		for _, b := range beatmaps {
+			if b.ID == id {
+				return b
+			}
+		}
+	} else if md5 != "" {
+		for _, b := range beatmaps {
+			if strings.EqualFold(b.MD5, md5) {
+				return b
+			}
+		}
+	} else {
+		for _, b := range beatmaps {
+			if (artist == "" || strings.EqualFold(artist, b.Artist)) &&
+				(title == "" || strings.EqualFold(title, b.Name)) &&
+				(difficulty == "" || strings.EqualFold(difficulty, b.Difficulty)) &&
+				(creator == "" || strings.EqualFold(creator, b.Creator)) {
+				return b
+			}
+		}
+
+		log.Println("Beatmap with exact parameters not found, searching partially...")
+		for _, b := range beatmaps {
+			if (artist == "" || strings.Contains(strings.ToLower(b.Artist), strings.ToLower(artist))) &&
+				(title == "" || strings.Contains(strings.ToLower(b.Name), strings.ToLower(title))) &&
+				(difficulty == "" || strings.Contains(strings.ToLower(b.Difficulty), strings.ToLower(difficulty))) &&
+				(creator == "" || strings.Contains(strings.ToLower(b.Creator), strings.ToLower(creator))) {
+				return b
+			}
+		}
+	}
+
+	return nil
+}
+
+func queryBeatmap(beatmaps []*beatmap.BeatMap, query string, random bool) *beatmap.BeatMap {
+	q := beatmap.ParseQuery(query)
+
+	var found []*beatmap.BeatMap
+
+	for _, b := range beatmaps {
+		if q.Matches(b) {
+			if !random {
+				return b
+			}
+
+			found = append(found, b)
+		}
+	}
+
+	if len(found) == 0 {
+		return nil
+	}
+
+	log.Println(fmt.Sprintf("Found %d beatmaps matching the query, picking a random one...", len(found)))
+
+	if settings.DETERMINISTIC {
+		util.SetRandomSeed(0)
+	}
+
+	return found[util.RandomIntn(len(found))]
+}
+
+func loadReplay(path string) (*rplpa.Replay, error) {
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	rp, err := rplpa.ParseReplay(bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if rp.PlayMode != 0 {
+		return nil, errors.New("modes other than osu!standard are not supported")
+	}
+
+	if rp.ReplayData == nil || len(rp.ReplayData) < 2 {
+		return nil, errors.New("replay is missing input data")
+	}
+
+	return rp, nil
+}
+
+func applyRecordOverrides() {
+	//HACK: some in-app variables depend on these settings so we force them here
+	settings.Graphics.VSync = false
+	settings.Graphics.ShowFPS = false
+	settings.DEBUG = false
+	settings.Graphics.Fullscreen = false
+	settings.Graphics.WindowWidth = int64(settings.Recording.FrameWidth)
+	settings.Graphics.WindowHeight = int64(settings.Recording.FrameHeight)
+	settings.Playfield.LeadInTime = 0
+}
+
+func applySpeedMods(mods difficulty2.Modifier) {
+	if mods.Active(difficulty2.Nightcore) {
+		settings.SPEED *= 1.5
+		settings.PITCH *= 1.5
+	} else if mods.Active(difficulty2.DoubleTime) {
+		settings.SPEED *= 1.5
+	} else if mods.Active(difficulty2.Daycore) {
+		settings.PITCH *= 0.75
+		settings.SPEED *= 0.75
+	} else if mods.Active(difficulty2.HalfTime) {
+		settings.SPEED *= 0.75
+	}
+}
+
+func loadPlayer(beatMap *beatmap.BeatMap, mods difficulty2.Modifier) {
+	beatMap.Diff.SetMods(mods)
+	beatmap.ParseTimingPointsAndPauses(beatMap)
+	beatmap.ParseObjects(beatMap, false, true)
+
+	// Objects have to be converted before the player resets the beatmap
+	if dance.PrepareCandidates(beatMap) {
+		beatmap.EnableTargetPractice(beatMap, false)
+	}
+
+	beatMap.LoadCustomSamples()
+
+	if settings.DETERMINISTIC {
+		util.SetRandomSeed(0)
+	}
+
+	player = states.NewPlayer(beatMap)
 }
 
 func mainLoopSS() {
@@ -214,6 +214,8 @@ func LoadBeatmapSamples(dir string) {
 		return []string{name}
 	}
 
+	MapSamples = [3][7]map[int]*bass.Sample{}
+
 	fullPath := filepath.Join(settings.General.GetSongsDir(), dir)
 
 	_ = godirwalk.Walk(fullPath, &godirwalk.Options{
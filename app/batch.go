@@ -0,0 +1,414 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/faiface/mainthread"
+	"github.com/wieku/danser-go/app/audio"
+	"github.com/wieku/danser-go/app/beatmap"
+	difficulty2 "github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/database"
+	"github.com/wieku/danser-go/app/ffmpeg"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/app/skin"
+	"github.com/wieku/danser-go/framework/env"
+	"github.com/wieku/danser-go/framework/goroutines"
+	"gopkg.in/yaml.v3"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type batchJob struct {
+	// Name of the job, used as output name if Out is not set
+	Name string `json:"name"`
+
+	// Beatmap selectors, ignored if Replay is set
+	ID         int64  `json:"id"`
+	MD5        string `json:"md5"`
+	Artist     string `json:"artist"`
+	Title      string `json:"title"`
+	Difficulty string `json:"difficulty"`
+	Creator    string `json:"creator"`
+
+	// Search query used when ID and MD5 are not set, see beatmap.ParseQuery. Random picks a random match instead of the first one
+	Query  string `json:"query"`
+	Random bool   `json:"random"`
+
+	// Name of a collection, the job is expanded into one job per beatmap in the collection. Other selectors are ignored
+	Collection string `json:"collection"`
+
+	// Path to a replay file
+	Replay string `json:"replay"`
+
+	// Settings profile, equivalent of -settings flag
+	Settings string `json:"settings"`
+
+	// Skin that replaces Skin.CurrentSkin
+	Skin string `json:"skin"`
+
+	// Mods used in cursordance mode, ignored if Replay is set
+	Mods string `json:"mods"`
+
+	// Start and end time in seconds, End <= 0 means the end of the map
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Skip  bool    `json:"skip"`
+
+	// Output video name, extension is managed by settings
+	Out string `json:"out"`
+}
+
+type batchResult struct {
+	Name     string  `json:"name"`
+	Status   string  `json:"status"`
+	Error    string  `json:"error,omitempty"`
+	Output   string  `json:"output,omitempty"`
+	Duration float64 `json:"duration"`
+}
+
+const (
+	batchPending = "pending"
+	batchSuccess = "success"
+	batchFailed  = "failed"
+)
+
+var batchJobs []*batchJob
+var batchPath string
+var batchBeatmaps []*beatmap.BeatMap
+var batchSettings string
+var batchSkin string
+
+// Recording resolution set when the window was created, jobs can't change it
+var batchWidth, batchHeight int64
+
+func loadBatchJobs(path string) ([]*batchJob, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// YAML is converted to JSON first so json tags of batchJob apply to both formats
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".yaml" || ext == ".yml" {
+		var raw any
+
+		if err = yaml.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+
+		if data, err = json.Marshal(raw); err != nil {
+			return nil, err
+		}
+	}
+
+	var jobs []*batchJob
+
+	if err = json.Unmarshal(data, &jobs); err != nil {
+		return nil, err
+	}
+
+	if len(jobs) == 0 {
+		return nil, errors.New("no jobs specified")
+	}
+
+	return jobs, nil
+}
+
+// expandCollectionJobs replaces jobs with Collection set by copies selecting each beatmap of that collection.
+// Collections are read from the database, so it has to be called before it's closed.
+func expandCollectionJobs(jobs []*batchJob, beatmaps []*beatmap.BeatMap) []*batchJob {
+	byMD5 := make(map[string]*beatmap.BeatMap, len(beatmaps))
+
+	for _, b := range beatmaps {
+		byMD5[strings.ToLower(b.MD5)] = b
+	}
+
+	expanded := make([]*batchJob, 0, len(jobs))
+
+	for _, job := range jobs {
+		if job.Collection == "" || job.Replay != "" {
+			expanded = append(expanded, job)
+			continue
+		}
+
+		collection, err := database.GetCollection(job.Collection)
+		if err != nil {
+			log.Println("Batch: Failed to expand collection job:", err)
+			expanded = append(expanded, job) // Fails with an error when it's run
+			continue
+		}
+
+		name := job.Name
+		if name == "" {
+			name = collection.Name
+		}
+
+		log.Println(fmt.Sprintf("Batch: Collection \"%s\" expanded into %d jobs", collection.Name, len(collection.MD5s)))
+
+		for i, md5 := range collection.MD5s {
+			cJob := *job
+
+			cJob.Collection = ""
+			cJob.ID = 0
+			cJob.MD5 = md5
+			cJob.Artist = ""
+			cJob.Title = ""
+			cJob.Difficulty = ""
+			cJob.Creator = ""
+			cJob.Query = ""
+
+			if b, ok := byMD5[strings.ToLower(md5)]; ok {
+				cJob.Name = fmt.Sprintf("%s - %s - %s [%s]", name, b.Artist, b.Name, b.Difficulty)
+			} else {
+				cJob.Name = fmt.Sprintf("%s - %s", name, md5)
+			}
+
+			if strings.TrimSpace(job.Out) != "" {
+				cJob.Out = fmt.Sprintf("%s_%d", job.Out, i+1)
+			}
+
+			expanded = append(expanded, &cJob)
+		}
+	}
+
+	return expanded
+}
+
+func runBatch(jobs []*batchJob, batchPath string) {
+	resultsPath := strings.TrimSuffix(batchPath, filepath.Ext(batchPath)) + ".results.json"
+
+	results := make([]*batchResult, len(jobs))
+
+	for i, job := range jobs {
+		results[i] = &batchResult{
+			Name:   job.Name,
+			Status: batchPending,
+		}
+	}
+
+	saveBatchResults(resultsPath, results)
+
+	failed := 0
+
+	for i, job := range jobs {
+		log.Println(fmt.Sprintf("Batch: Starting job %d/%d: %s", i+1, len(jobs), job.Name))
+
+		startTime := time.Now()
+
+		err := runBatchJob(job)
+
+		results[i].Duration = time.Since(startTime).Seconds()
+
+		if err != nil {
+			log.Println(fmt.Sprintf("Batch: Job %d/%d failed: %s", i+1, len(jobs), err))
+
+			results[i].Status = batchFailed
+			results[i].Error = err.Error()
+
+			failed++
+		} else {
+			log.Println(fmt.Sprintf("Batch: Job %d/%d finished", i+1, len(jobs)))
+
+			results[i].Status = batchSuccess
+			results[i].Output = ffmpeg.GetOutputPath()
+		}
+
+		saveBatchResults(resultsPath, results)
+	}
+
+	log.Println(fmt.Sprintf("Batch: Finished, %d/%d jobs succeeded. Results saved to: %s", len(jobs)-failed, len(jobs), resultsPath))
+}
+
+func runBatchJob(job *batchJob) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+
+			for _, s := range goroutines.GetStackTrace(4) {
+				log.Println(s)
+			}
+		}
+	}()
+
+	err = mainthread.CallErr(func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("%v", r)
+			}
+		}()
+
+		return prepareBatchJob(job)
+	})
+
+	if err != nil {
+		return
+	}
+
+	mainLoopRecord()
+
+	return
+}
+
+// callMain runs fn on the main thread. A panic is recovered there and raised again in the calling goroutine,
+// otherwise it would crash the main thread instead of failing only the current batch job.
+func callMain(fn func()) {
+	var err any
+	var stackTrace []string
+
+	mainthread.Call(func() {
+		defer func() {
+			if err = recover(); err != nil {
+				stackTrace = goroutines.GetStackTrace(4)
+			}
+		}()
+
+		fn()
+	})
+
+	if err != nil {
+		for _, s := range stackTrace {
+			log.Println(s)
+		}
+
+		panic(err)
+	}
+}
+
+func prepareBatchJob(job *batchJob) error {
+	settingsName := batchSettings
+	if job.Settings != "" {
+		settingsName = job.Settings
+	}
+
+	if settingsName == "credentials" || settingsName == "launcher" {
+		return fmt.Errorf("settings name \"%s\" is forbidden", settingsName)
+	}
+
+	// LoadSettings would silently create a new profile for a misspelled name
+	if settingsName != "" {
+		if _, err := os.Stat(filepath.Join(env.ConfigDir(), settingsName+".json")); err != nil {
+			return fmt.Errorf("settings profile \"%s\" does not exist", settingsName)
+		}
+	}
+
+	// Previous job has finished, free its GPU resources and storyboard thread before the next one is built
+	if player != nil {
+		player.Dispose()
+		player = nil
+	}
+
+	settings.LoadSettings(settingsName)
+
+	if strings.TrimSpace(job.Skin) != "" {
+		settings.Skin.CurrentSkin = job.Skin
+	}
+
+	applyRecordOverrides()
+
+	if settings.Graphics.GetWidth() != batchWidth || settings.Graphics.GetHeight() != batchHeight {
+		return fmt.Errorf("settings \"%s\" use %dx%d resolution, all batch jobs are rendered at %dx%d", settingsName, settings.Graphics.GetWidth(), settings.Graphics.GetHeight(), batchWidth, batchHeight)
+	}
+
+	// Reuse already loaded skin if consecutive jobs share it
+	if skinID := settings.Skin.CurrentSkin + "/" + settings.Skin.FallbackSkin; skinID != batchSkin {
+		skin.Unload()
+
+		batchSkin = skinID
+	}
+
+	audio.LoadSamples()
+
+	settings.PLAY = false
+	settings.KNOCKOUT = false
+	settings.KNOCKOUTREPLAYS = nil
+	settings.KNOCKOUTTEAMS = nil
+	settings.REPLAY = ""
+	settings.GHOST = ""
+	settings.SPEED = 1
+	settings.PITCH = 1
+	settings.SKIP = job.Skip
+	settings.START = job.Start
+	settings.END = math.Inf(1)
+
+	if job.End > 0 {
+		settings.END = job.End
+	}
+
+	md5 := job.MD5
+	id := job.ID
+	if id == 0 {
+		id = -1
+	}
+
+	mods := difficulty2.ParseMods(job.Mods)
+
+	if job.Replay != "" {
+		rp, err := loadReplay(job.Replay)
+		if err != nil {
+			return err
+		}
+
+		md5 = rp.BeatmapMD5
+		id = -1
+		mods = difficulty2.Modifier(rp.Mods)
+
+		settings.KNOCKOUT = true
+		settings.REPLAY = job.Replay
+	}
+
+	if !mods.Compatible() {
+		return errors.New("incompatible mods selected")
+	}
+
+	if job.Collection != "" && job.Replay == "" {
+		return fmt.Errorf("collection \"%s\" not found", job.Collection)
+	}
+
+	if (md5+job.Artist+job.Title+job.Difficulty+job.Creator+job.Query) == "" && id < 0 {
+		return errors.New("no beatmap specified")
+	}
+
+	var found *beatmap.BeatMap
+
+	if job.Query != "" && id < 0 && md5 == "" {
+		found = queryBeatmap(batchBeatmaps, job.Query, job.Random)
+	} else {
+		found = findBeatmap(batchBeatmaps, id, md5, job.Artist, job.Title, job.Difficulty, job.Creator)
+	}
+
+	if found == nil {
+		return errors.New("beatmap not found")
+	}
+
+	if !settings.KNOCKOUT && mods.Active(difficulty2.Autoplay) {
+		settings.KNOCKOUT = true
+		settings.Knockout.MaxPlayers = 0
+	}
+
+	applySpeedMods(mods)
+
+	output = job.Out
+	if strings.TrimSpace(output) == "" {
+		output = job.Name
+	}
+
+	loadPlayer(found.Clone(), mods)
+
+	return nil
+}
+
+func saveBatchResults(path string, results []*batchResult) {
+	data, err := json.MarshalIndent(results, "", "\t")
+	if err != nil {
+		log.Println("Batch: Failed to serialize results:", err)
+		return
+	}
+
+	if err = os.WriteFile(path, data, 0644); err != nil {
+		log.Println("Batch: Failed to save results:", err)
+	}
+}
@@ -35,6 +35,10 @@ type BeatMap struct {
 	Bg    string
 	MD5   string
 
+	AudioLeadIn int64
+	Video       string
+	VideoOffset int64
+
 	SetID int64
 	ID    int64
 
@@ -42,6 +46,7 @@ type BeatMap struct {
 
 	Stars        float64
 	StarsVersion int
+	ModStars     *ModStars
 
 	Length   int
 	Circles  int
@@ -61,6 +66,10 @@ type BeatMap struct {
 	ARSpecified bool
 
 	LocalOffset int
+
+	// Imported from osu!stable's osu!.db, see database.LoadBeatmaps
+	RankedStatus RankedStatus
+	OnlineOffset int
 }
 
 func NewBeatMap() *BeatMap {
@@ -69,6 +78,7 @@ func NewBeatMap() *BeatMap {
 		StackLeniency: 0.7,
 		Diff:          difficulty.NewDifficulty(5, 5, 5, 5),
 		Stars:         -1,
+		ModStars:      NewModStars(),
 		MinBPM:        math.Inf(0),
 		MaxBPM:        0,
 	}
@@ -76,6 +86,87 @@ func NewBeatMap() *BeatMap {
 	return beatMap
 }
 
+// Clone returns a copy of beatmap's metadata with empty timing points and hit objects, so it can be parsed and played again
+func (beatMap *BeatMap) Clone() *BeatMap {
+	bMap := *beatMap
+
+	bMap.Timings = objects.NewTimings()
+	bMap.Timings.SliderMult = beatMap.Timings.SliderMult
+	bMap.Timings.TickRate = beatMap.Timings.TickRate
+	bMap.Timings.BaseSet = beatMap.Timings.BaseSet
+
+	bMap.Diff = difficulty.NewDifficulty(beatMap.Diff.GetBaseHP(), beatMap.Diff.GetBaseCS(), beatMap.Diff.GetBaseOD(), beatMap.Diff.GetBaseAR())
+
+	bMap.HitObjects = nil
+	bMap.Pauses = nil
+	bMap.Queue = nil
+	bMap.processed = nil
+
+	return &bMap
+}
+
+// CloneObjects returns a copy of beatmap with its own timing points and hit objects parsed again from .osu file.
+// Difficulty is shared with the original, so objects are converted and placed the same way. Copied objects don't play any sounds.
+func (beatMap *BeatMap) CloneObjects() *BeatMap {
+	bMap := beatMap.Clone()
+	bMap.Diff = beatMap.Diff
+
+	ParseTimingPointsAndPauses(bMap)
+	ParseObjects(bMap, false, false)
+
+	for _, o := range bMap.HitObjects {
+		o.DisableAudioSubmission(true)
+	}
+
+	bMap.Reset()
+
+	return bMap
+}
+
+// ApplyRate rescales all times saved in .osu file to match audio sped up by rate, it's meant for rate-changed exports.
+// Objects' ticks and paths aren't recalculated, beatmap has to be parsed again to be played.
+func (beatMap *BeatMap) ApplyRate(rate float64) {
+	beatMap.Timings.ApplyRate(rate)
+
+	for _, obj := range beatMap.HitObjects {
+		var hitObject *objects.HitObject
+
+		switch o := obj.(type) {
+		case *objects.Circle:
+			hitObject = o.HitObject
+		case *objects.Slider:
+			hitObject = o.HitObject
+		case *objects.Spinner:
+			hitObject = o.HitObject
+		default:
+			continue
+		}
+
+		hitObject.StartTime /= rate
+		hitObject.EndTime /= rate
+	}
+
+	for _, pause := range beatMap.Pauses {
+		pause.StartTime /= rate
+		pause.EndTime /= rate
+	}
+
+	scaleTime := func(time int64) int64 {
+		return int64(math.Round(float64(time) / rate))
+	}
+
+	if beatMap.PreviewTime > 0 {
+		beatMap.PreviewTime = scaleTime(beatMap.PreviewTime)
+	}
+
+	beatMap.AudioLeadIn = scaleTime(beatMap.AudioLeadIn)
+	beatMap.VideoOffset = scaleTime(beatMap.VideoOffset)
+	beatMap.Length = int(scaleTime(int64(beatMap.Length)))
+
+	beatMap.MinBPM *= rate
+	beatMap.MaxBPM *= rate
+}
+
 func (beatMap *BeatMap) Reset() {
 	beatMap.Queue = beatMap.GetObjectsCopy()
 	beatMap.processed = make([]objects.IHitObject, 0)
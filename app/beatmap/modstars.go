@@ -0,0 +1,48 @@
+package beatmap
+
+import (
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"sync"
+)
+
+// ModStars holds star ratings of a beatmap for mod combinations other than NoMod.
+// It's filled in the background, so access is synchronized. Copies of BeatMap share the same instance.
+type ModStars struct {
+	mutex sync.RWMutex
+	stars map[difficulty.Modifier]float64
+}
+
+func NewModStars() *ModStars {
+	return &ModStars{stars: make(map[difficulty.Modifier]float64)}
+}
+
+// Get returns star rating for given mods, mods are masked to the ones affecting difficulty
+func (s *ModStars) Get(mods difficulty.Modifier) (float64, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	stars, ok := s.stars[difficulty.GetDiffMaskedMods(mods)]
+
+	return stars, ok
+}
+
+func (s *ModStars) Set(mods difficulty.Modifier, stars float64) {
+	s.mutex.Lock()
+	s.stars[difficulty.GetDiffMaskedMods(mods)] = stars
+	s.mutex.Unlock()
+}
+
+// GetStarsFor returns star rating for given mods. If it's not calculated yet, ok is false and NoMod star rating is returned.
+func (beatMap *BeatMap) GetStarsFor(mods difficulty.Modifier) (stars float64, ok bool) {
+	masked := difficulty.GetDiffMaskedMods(mods)
+
+	if masked == difficulty.None {
+		return beatMap.Stars, beatMap.Stars >= 0
+	}
+
+	if stars, ok = beatMap.ModStars.Get(masked); ok {
+		return
+	}
+
+	return beatMap.Stars, false
+}
@@ -48,6 +48,10 @@ type Circle struct {
 
 	// DoubleClick is used in cursordances when 2 nearby circles are merged to one
 	DoubleClick bool
+
+	// Target is set for Target Practice targets, they are judged by the distance from the center
+	Target      bool
+	targetRings []*sprite.Sprite
 }
 
 func NewCircle(data []string) *Circle {
@@ -63,6 +67,25 @@ func NewCircle(data []string) *Circle {
 	return circle
 }
 
+// NewTarget creates a Target Practice target
+func NewTarget(pos vector.Vector2f, time float64, newCombo bool) *Circle {
+	circle := &Circle{
+		HitObject: &HitObject{
+			StartPosRaw: pos,
+			EndPosRaw:   pos,
+			StartTime:   time,
+			EndTime:     time,
+			HitObjectID: -1,
+			NewCombo:    newCombo,
+		},
+		Target: true,
+	}
+
+	circle.textureName = defaultCircleName
+
+	return circle
+}
+
 func DummyCircle(pos vector.Vector2f, time float64) *Circle {
 	return DummyCircleInherit(pos, time, false, false, false)
 }
@@ -176,6 +199,19 @@ func (circle *Circle) SetDifficulty(diff *difficulty.Difficulty) {
 
 	circles := []sprite.ISprite{circle.hitCircle, circle.hitCircleOverlay, circle.comboText}
 
+	if circle.Target {
+		// Rings marking 300 and 100 zones of the target
+		for _, scale := range []float64{2.0 / 3, 1.0 / 3} {
+			ring := sprite.NewSpriteSingle(skin.GetTexture("approachcircle"), 0, vector.NewVec2d(0, 0), vector.Centre)
+			ring.SetScale(scale)
+			ring.SetAlpha(0)
+
+			circle.targetRings = append(circle.targetRings, ring)
+			circle.sprites = append(circle.sprites, ring)
+			circles = append(circles, ring)
+		}
+	}
+
 	for _, t := range circles {
 		if diff.CheckModActive(difficulty.Hidden) {
 			if !circle.SliderPoint || circle.SliderPointStart || circle.firstEndCircle {
@@ -272,6 +308,11 @@ func (circle *Circle) Arm(clicked bool, time float64) {
 		circle.hitCircleOverlay.AddTransform(animation.NewSingleTransform(animation.Fade, easing.OutQuad, startTime, endTime, circle.hitCircleOverlay.GetAlpha(), 0.0))
 		circle.comboText.AddTransform(animation.NewSingleTransform(animation.Fade, easing.Linear, startTime, endTime, circle.comboText.GetAlpha(), 0.0))
 	}
+
+	for _, ring := range circle.targetRings {
+		ring.ClearTransformations()
+		ring.AddTransform(animation.NewSingleTransform(animation.Fade, easing.OutQuad, startTime, startTime+60, ring.GetAlpha(), 0.0))
+	}
 }
 
 func (circle *Circle) Shake(time float64) {
@@ -316,7 +357,12 @@ func (circle *Circle) Draw(time float64, color color2.Color, batch *batch.QuadBa
 			circle.hitCircleOverlay.Draw(time, batch)
 		}
 
-		if !circle.SliderPoint || circle.SliderPointStart {
+		if circle.Target {
+			for _, ring := range circle.targetRings {
+				ring.SetColor(circle.hitCircle.GetColor())
+				ring.Draw(time, batch)
+			}
+		} else if !circle.SliderPoint || circle.SliderPointStart {
 			if settings.DIVIDES < 2 && settings.Objects.DrawComboNumbers {
 				circle.comboText.Draw(0, batch)
 			}
@@ -363,6 +409,10 @@ func (circle *Circle) DrawApproach(time float64, color color2.Color, batch *batc
 	circle.approachCircle.Draw(time, batch)
 }
 
+func (circle *Circle) GetBaseSample() int {
+	return circle.sample
+}
+
 func (circle *Circle) GetType() Type {
 	return CIRCLE
 }
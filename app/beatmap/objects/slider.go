@@ -52,6 +52,7 @@ type Slider struct {
 	*HitObject
 
 	multiCurve  *curves.MultiCurve
+	curveDefs   []curves.CurveDef
 	scorePath   []PathLine
 	Timings     *Timings
 	TPoint      TimingPoint
@@ -249,6 +250,8 @@ func (slider *Slider) parseCurve(curveData string) *curves.MultiCurve {
 		}
 	}
 
+	slider.curveDefs = defs
+
 	return curves.NewMultiCurveT(defs, slider.pixelLength)
 }
 
@@ -267,6 +270,24 @@ func tryGetType(str string) curves.CType {
 	}
 }
 
+// GetCurveDefs returns curve segments as defined in .osu file, the first point of every segment is the end of the previous one
+func (slider *Slider) GetCurveDefs() []curves.CurveDef {
+	return slider.curveDefs
+}
+
+func (slider *Slider) GetPixelLength() float64 {
+	return slider.pixelLength
+}
+
+func (slider *Slider) GetBaseSample() int {
+	return slider.baseSample
+}
+
+// GetEdgeSamples returns hit sounds and sample sets of slider's head, repeats and tail
+func (slider *Slider) GetEdgeSamples() (samples, sampleSets, additionSets []int) {
+	return slider.samples, slider.sampleSets, slider.additionSets
+}
+
 func (slider *Slider) GetLength() float32 {
 	return slider.multiCurve.GetLength()
 }
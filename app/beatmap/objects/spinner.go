@@ -16,8 +16,8 @@ import (
 	"github.com/wieku/danser-go/framework/math/math32"
 	"github.com/wieku/danser-go/framework/math/mutils"
 	"github.com/wieku/danser-go/framework/math/vector"
+	"github.com/wieku/danser-go/framework/util"
 	"math"
-	"math/rand"
 	"strconv"
 )
 
@@ -370,7 +370,7 @@ func (spinner *Spinner) UpdateCompletion(completion float64) {
 	} else if spinner.metre != nil {
 		bars := int(min(0.99, completion) * 10)
 
-		if skin.GetInfo().SpinnerNoBlink || rand.Float64() < math.Mod(completion*10, 1) {
+		if skin.GetInfo().SpinnerNoBlink || util.RandomFloat64() < math.Mod(completion*10, 1) {
 			bars++
 		}
 
@@ -441,6 +441,10 @@ func (spinner *Spinner) Bonus() {
 	spinner.bonus += 1000
 }
 
+func (spinner *Spinner) GetBaseSample() int {
+	return spinner.sample
+}
+
 func (spinner *Spinner) GetType() Type {
 	return SPINNER
 }
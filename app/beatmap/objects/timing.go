@@ -36,6 +36,11 @@ func (t TimingPoint) GetBaseBeatLength() float64 {
 	return t.beatLengthBase
 }
 
+// GetRawBeatLength returns beat length as saved in .osu file, it's negative for inherited points
+func (t TimingPoint) GetRawBeatLength() float64 {
+	return t.beatLength
+}
+
 func (t TimingPoint) GetBeatLength() float64 {
 	return t.beatLengthBase * t.GetRatio()
 }
@@ -106,6 +111,23 @@ func (tim *Timings) FinalizePoints() {
 	}
 }
 
+// ApplyRate rescales timing points to match audio sped up by rate, SV of inherited points stays the same
+func (tim *Timings) ApplyRate(rate float64) {
+	scale := func(points []TimingPoint) {
+		for i := range points {
+			points[i].Time /= rate
+			points[i].beatLengthBase /= rate
+
+			if !points[i].Inherited {
+				points[i].beatLength /= rate
+			}
+		}
+	}
+
+	scale(tim.points)
+	scale(tim.originalPoints)
+}
+
 func (tim *Timings) Update(time float64) {
 	tim.Current = tim.GetPointAt(time)
 }
@@ -124,6 +146,11 @@ func (tim *Timings) GetPointAt(time float64) TimingPoint {
 	return tim.points[max(0, index-1)]
 }
 
+// GetPoints returns all timing points sorted by time, inherited ones included
+func (tim *Timings) GetPoints() []TimingPoint {
+	return tim.points
+}
+
 func (tim *Timings) GetOriginalPointAt(time float64) TimingPoint {
 	tLen := len(tim.originalPoints)
 
@@ -134,6 +161,44 @@ func (tim *Timings) GetOriginalPointAt(time float64) TimingPoint {
 	return tim.originalPoints[max(0, index-1)]
 }
 
+// GetBeats returns times of beats between start and end (inclusive) following uninherited timing points.
+// measureStarts marks beats starting a measure.
+func (tim *Timings) GetBeats(start, end float64) (times []float64, measureStarts []bool) {
+	points := tim.originalPoints
+	if len(points) == 0 {
+		points = []TimingPoint{tim.defaultTimingPoint}
+	}
+
+	for i, point := range points {
+		beatLength := point.GetBaseBeatLength()
+		if beatLength <= 0 || math.IsNaN(beatLength) {
+			continue
+		}
+
+		sectionEnd := end
+		if i < len(points)-1 {
+			sectionEnd = min(end, points[i+1].Time-1)
+		}
+
+		signature := max(point.Signature, 1)
+
+		// First timing point is extended backwards in case objects start before it
+		beat := int(math.Ceil((start-point.Time)/beatLength - 0.001))
+		if i > 0 {
+			beat = max(beat, 0)
+		}
+
+		for time := point.Time + float64(beat)*beatLength; time <= sectionEnd; time = point.Time + float64(beat)*beatLength {
+			times = append(times, math.Floor(time))
+			measureStarts = append(measureStarts, ((beat%signature)+signature)%signature == 0)
+
+			beat++
+		}
+	}
+
+	return
+}
+
 func (tim *Timings) GetScoringDistance() float64 {
 	return (100 * tim.SliderMult) / tim.TickRate
 }
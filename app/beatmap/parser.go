@@ -3,6 +3,7 @@ package beatmap
 import (
 	"cmp"
 	"errors"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
 	"github.com/wieku/danser-go/app/beatmap/objects"
 	"github.com/wieku/danser-go/app/settings"
 	"github.com/wieku/danser-go/app/skin"
@@ -30,6 +31,8 @@ func parseGeneral(line []string, beatMap *BeatMap) bool {
 		}
 	case "AudioFilename":
 		beatMap.Audio += line[1]
+	case "AudioLeadIn":
+		beatMap.AudioLeadIn, _ = strconv.ParseInt(line[1], 10, 64)
 	case "PreviewTime":
 		beatMap.PreviewTime, _ = strconv.ParseInt(line[1], 10, 64)
 	case "SampleSet":
@@ -102,6 +105,11 @@ func parseEvents(line []string, beatMap *BeatMap) {
 	switch line[0] {
 	case "Background", "0":
 		beatMap.Bg = strings.Replace(line[2], "\"", "", -1)
+	case "Video", "1":
+		if len(line) > 2 {
+			beatMap.VideoOffset, _ = strconv.ParseInt(line[1], 10, 64)
+			beatMap.Video = strings.Replace(line[2], "\"", "", -1)
+		}
 	case "Break", "2":
 		beatMap.Pauses = append(beatMap.Pauses, NewPause(line))
 	}
@@ -313,6 +321,10 @@ func ParseObjects(beatMap *BeatMap, diffCalcOnly, parseColors bool) {
 
 	defer bufferPool.Put(buf)
 
+	if parseColors {
+		skin.ResetBeatmapColors()
+	}
+
 	var currentSection string
 
 	for scanner.Scan() {
@@ -351,6 +363,20 @@ func ParseObjects(beatMap *BeatMap, diffCalcOnly, parseColors bool) {
 		skin.FinishBeatmapColors()
 	}
 
+	assignComboNumbers(beatMap)
+
+	for _, obj := range beatMap.HitObjects {
+		obj.SetTiming(beatMap.Timings, beatMap.Version, diffCalcOnly)
+	}
+
+	if beatMap.Diff.CheckModActive(difficulty.Target) {
+		convertToTargets(beatMap, diffCalcOnly)
+	}
+
+	calculateStackLeniency(beatMap, diffCalcOnly)
+}
+
+func assignComboNumbers(beatMap *BeatMap) {
 	num := 0
 	comboNumber := 1
 	comboSet := 0
@@ -377,10 +403,4 @@ func ParseObjects(beatMap *BeatMap, diffCalcOnly, parseColors bool) {
 		comboNumber++
 		num++
 	}
-
-	for _, obj := range beatMap.HitObjects {
-		obj.SetTiming(beatMap.Timings, beatMap.Version, diffCalcOnly)
-	}
-
-	calculateStackLeniency(beatMap, diffCalcOnly)
 }
@@ -0,0 +1,410 @@
+package beatmap
+
+import (
+	"fmt"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type queryOperator int
+
+const (
+	opContains = queryOperator(iota)
+	opEqual
+	opNotEqual
+	opLess
+	opLessEqual
+	opGreater
+	opGreaterEqual
+)
+
+// Longer operators have to be checked first
+var queryOperators = []struct {
+	text string
+	op   queryOperator
+}{
+	{"==", opEqual},
+	{"!=", opNotEqual},
+	{"<=", opLessEqual},
+	{">=", opGreaterEqual},
+	{"<", opLess},
+	{">", opGreater},
+	{"=", opContains},
+	{":", opContains},
+}
+
+type numberField struct {
+	get       func(bMap *BeatMap) float64
+	precision float64 // values closer than this are considered equal
+	parse     func(value string) (float64, error)
+}
+
+type textField func(bMap *BeatMap) string
+
+var numberFields = map[string]numberField{
+	"stars":    {get: getStars, precision: 0.005},
+	"ar":       {get: func(b *BeatMap) float64 { return b.Diff.GetBaseAR() }, precision: 0.005},
+	"cs":       {get: func(b *BeatMap) float64 { return b.Diff.GetBaseCS() }, precision: 0.005},
+	"od":       {get: func(b *BeatMap) float64 { return b.Diff.GetBaseOD() }, precision: 0.005},
+	"hp":       {get: func(b *BeatMap) float64 { return b.Diff.GetBaseHP() }, precision: 0.005},
+	"bpm":      {get: func(b *BeatMap) float64 { return b.MaxBPM }, precision: 0.5},
+	"length":   {get: func(b *BeatMap) float64 { return float64(b.Length) / 1000 }, precision: 0.5, parse: parseDuration},
+	"objects":  {get: func(b *BeatMap) float64 { return float64(b.Circles + b.Sliders + b.Spinners) }},
+	"circles":  {get: func(b *BeatMap) float64 { return float64(b.Circles) }},
+	"sliders":  {get: func(b *BeatMap) float64 { return float64(b.Sliders) }},
+	"spinners": {get: func(b *BeatMap) float64 { return float64(b.Spinners) }},
+	"mode":     {get: func(b *BeatMap) float64 { return float64(b.Mode) }, parse: parseMode},
+	"id":       {get: func(b *BeatMap) float64 { return float64(b.ID) }},
+	"setid":    {get: func(b *BeatMap) float64 { return float64(b.SetID) }},
+	"plays":    {get: func(b *BeatMap) float64 { return float64(b.PlayCount) }},
+}
+
+var textFields = map[string]textField{
+	"artist":     func(b *BeatMap) string { return b.Artist + " " + b.ArtistUnicode },
+	"title":      func(b *BeatMap) string { return b.Name + " " + b.NameUnicode },
+	"difficulty": func(b *BeatMap) string { return b.Difficulty },
+	"creator":    func(b *BeatMap) string { return b.Creator },
+	"source":     func(b *BeatMap) string { return b.Source },
+	"tags":       func(b *BeatMap) string { return b.Tags },
+	"md5":        func(b *BeatMap) string { return b.MD5 },
+}
+
+var fieldAliases = map[string]string{
+	"star":    "stars",
+	"sr":      "stars",
+	"dr":      "hp",
+	"mapper":  "creator",
+	"diff":    "difficulty",
+	"version": "difficulty",
+	"tag":     "tags",
+	"len":     "length",
+	"set":     "setid",
+	"played":  "plays",
+}
+
+type queryFilter func(bMap *BeatMap) bool
+
+// Query is a parsed beatmap search, similar to osu!stable's song select search.
+// Filters like "stars>6 ar>=9.5 creator=xyz" are combined with free text terms, all of them have to match.
+type Query struct {
+	filters []queryFilter
+	terms   []string
+}
+
+// ParseQuery parses given search string. Tokens that are not valid filters are treated as free text, same as in osu!stable.
+//
+// Numeric fields: stars, ar, cs, od, hp, bpm (highest), length (seconds, "3m20s" format is also accepted), objects,
+// circles, sliders, spinners, mode (osu/taiko/catch/mania), id, setid, plays and status (played/unplayed or ranked status
+// imported from osu!.db: ranked/approved/qualified/loved/pending/unsubmitted).
+// Star rating with mods can be filtered with mods prefix, e.g. "dtstars>7", if it's already calculated.
+// Text fields: artist, title, difficulty, creator, source, tags and md5.
+// Operators: "=" or ":" (numbers: equal, text: contains), "==" (exact), "!=", "<", "<=", ">", ">=".
+// Values with spaces and free text phrases can be quoted.
+func ParseQuery(query string) *Query {
+	q := new(Query)
+
+	for _, token := range tokenizeQuery(query) {
+		if filter := parseFilter(token); filter != nil {
+			q.filters = append(q.filters, filter)
+			continue
+		}
+
+		if term := strings.ToLower(strings.ReplaceAll(token, "\"", "")); term != "" {
+			q.terms = append(q.terms, term)
+		}
+	}
+
+	return q
+}
+
+// IsEmpty checks whether query matches all beatmaps
+func (q *Query) IsEmpty() bool {
+	return len(q.filters) == 0 && len(q.terms) == 0
+}
+
+// Matches checks beatmap against the query. Free text is searched in SearchString.
+func (q *Query) Matches(bMap *BeatMap) bool {
+	return q.MatchesWith(bMap, SearchString(bMap))
+}
+
+// MatchesWith checks beatmap against the query with precomputed (lowercase) SearchString
+func (q *Query) MatchesWith(bMap *BeatMap, searchString string) bool {
+	for _, term := range q.terms {
+		if !strings.Contains(searchString, term) {
+			return false
+		}
+	}
+
+	for _, filter := range q.filters {
+		if !filter(bMap) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// SearchString returns lowercase text used for free text search
+func SearchString(bMap *BeatMap) string {
+	return strings.ToLower(fmt.Sprintf("%s - %s [%s] by %s %d %d", bMap.Artist, bMap.Name, bMap.Difficulty, bMap.Creator, bMap.SetID, bMap.ID))
+}
+
+func tokenizeQuery(query string) (tokens []string) {
+	var builder strings.Builder
+
+	quoted := false
+
+	for _, r := range query {
+		if r == '"' {
+			quoted = !quoted
+		}
+
+		if unicode.IsSpace(r) && !quoted {
+			if builder.Len() > 0 {
+				tokens = append(tokens, builder.String())
+				builder.Reset()
+			}
+
+			continue
+		}
+
+		builder.WriteRune(r)
+	}
+
+	if builder.Len() > 0 {
+		tokens = append(tokens, builder.String())
+	}
+
+	return
+}
+
+func parseFilter(token string) queryFilter {
+	idx, length := -1, 0
+	op := opContains
+
+	// Operator that appears first wins, for the same position longer one is preferred
+	for _, o := range queryOperators {
+		if i := strings.Index(token, o.text); i > 0 && (idx == -1 || i < idx) {
+			idx, length, op = i, len(o.text), o.op
+		}
+	}
+
+	if idx == -1 {
+		return nil
+	}
+
+	key := strings.ToLower(token[:idx])
+	value := strings.Trim(token[idx+length:], "\"")
+
+	if value == "" {
+		return nil
+	}
+
+	if alias, ok := fieldAliases[key]; ok {
+		key = alias
+	}
+
+	if field, ok := numberFields[key]; ok {
+		return numberFilter(field, op, value)
+	}
+
+	if key == "status" {
+		return numberFilter(statusField(value), op, value)
+	}
+
+	if field, ok := textFields[key]; ok {
+		return textFilter(field, op, value)
+	}
+
+	if mods, ok := parseStarsMods(key); ok {
+		return numberFilter(numberField{get: func(b *BeatMap) float64 { return getModStars(b, mods) }, precision: 0.005}, op, value)
+	}
+
+	return nil
+}
+
+// parseStarsMods parses keys like "dtstars" or "hdflstars"
+func parseStarsMods(key string) (difficulty.Modifier, bool) {
+	prefix, found := strings.CutSuffix(key, "stars")
+	if !found || prefix == "" || len(prefix)%2 != 0 {
+		return difficulty.None, false
+	}
+
+	prefix = strings.ToUpper(prefix)
+
+	for i := 0; i < len(prefix); i += 2 {
+		if difficulty.ParseMods(prefix[i:i+2]) == difficulty.None {
+			return difficulty.None, false
+		}
+	}
+
+	return difficulty.ParseMods(prefix), true
+}
+
+func numberFilter(field numberField, op queryOperator, value string) queryFilter {
+	parse := field.parse
+	if parse == nil {
+		parse = func(value string) (float64, error) {
+			return strconv.ParseFloat(value, 64)
+		}
+	}
+
+	target, err := parse(value)
+	if err != nil {
+		return nil
+	}
+
+	precision := max(field.precision, 1e-9)
+
+	return func(bMap *BeatMap) bool {
+		v := field.get(bMap)
+
+		switch op {
+		case opContains, opEqual:
+			return math.Abs(v-target) < precision
+		case opNotEqual:
+			return math.Abs(v-target) >= precision
+		case opLess:
+			return v < target && math.Abs(v-target) >= precision
+		case opLessEqual:
+			return v < target+precision
+		case opGreater:
+			return v > target && math.Abs(v-target) >= precision
+		case opGreaterEqual:
+			return v > target-precision
+		}
+
+		return false
+	}
+}
+
+func textFilter(field textField, op queryOperator, value string) queryFilter {
+	value = strings.ToLower(value)
+
+	return func(bMap *BeatMap) bool {
+		v := strings.ToLower(field(bMap))
+
+		switch op {
+		case opContains:
+			return strings.Contains(v, value)
+		case opEqual:
+			return v == value
+		case opNotEqual:
+			return !strings.Contains(v, value)
+		case opLess:
+			return v < value
+		case opLessEqual:
+			return v <= value
+		case opGreater:
+			return v > value
+		case opGreaterEqual:
+			return v >= value
+		}
+
+		return false
+	}
+}
+
+// getStars returns NaN for maps without calculated star rating, so they never match
+func getStars(bMap *BeatMap) float64 {
+	if bMap.Stars < 0 {
+		return math.NaN()
+	}
+
+	return bMap.Stars
+}
+
+// getModStars returns NaN if star rating for given mods is not calculated yet
+func getModStars(bMap *BeatMap, mods difficulty.Modifier) float64 {
+	if stars, ok := bMap.GetStarsFor(mods); ok {
+		return stars
+	}
+
+	return math.NaN()
+}
+
+// parseDuration parses seconds, optionally in "1h2m3s" format
+func parseDuration(value string) (float64, error) {
+	if v, err := strconv.ParseFloat(value, 64); err == nil {
+		return v, nil
+	}
+
+	total := 0.0
+	number := ""
+
+	for _, r := range strings.ToLower(value) {
+		if unicode.IsDigit(r) || r == '.' {
+			number += string(r)
+			continue
+		}
+
+		v, err := strconv.ParseFloat(number, 64)
+		if err != nil {
+			return 0, err
+		}
+
+		switch r {
+		case 'h':
+			total += v * 3600
+		case 'm':
+			total += v * 60
+		case 's':
+			total += v
+		default:
+			return 0, fmt.Errorf("unknown time unit: %c", r)
+		}
+
+		number = ""
+	}
+
+	if number != "" {
+		return 0, fmt.Errorf("missing time unit: %s", value)
+	}
+
+	return total, nil
+}
+
+func parseMode(value string) (float64, error) {
+	switch strings.ToLower(value) {
+	case "osu", "std", "standard":
+		return 0, nil
+	case "taiko":
+		return 1, nil
+	case "catch", "fruits", "ctb":
+		return 2, nil
+	case "mania":
+		return 3, nil
+	}
+
+	return strconv.ParseFloat(value, 64)
+}
+
+// statusField compares local play status for played/unplayed values and ranked status otherwise
+func statusField(value string) numberField {
+	if _, err := parsePlayStatus(value); err == nil {
+		return numberField{get: func(b *BeatMap) float64 { return float64(min(b.PlayCount, 1)) }, parse: parsePlayStatus}
+	}
+
+	return numberField{get: func(b *BeatMap) float64 { return float64(b.RankedStatus) }, parse: parseRankedStatus}
+}
+
+func parseRankedStatus(value string) (float64, error) {
+	status, err := ParseRankedStatus(value)
+
+	return float64(status), err
+}
+
+// parsePlayStatus parses local play status
+func parsePlayStatus(value string) (float64, error) {
+	switch strings.ToLower(value) {
+	case "unplayed":
+		return 0, nil
+	case "played":
+		return 1, nil
+	}
+
+	return 0, fmt.Errorf("unknown status: %s", value)
+}
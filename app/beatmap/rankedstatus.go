@@ -0,0 +1,56 @@
+package beatmap
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RankedStatus is beatmap's online status, values match the ones in osu!stable's osu!.db
+type RankedStatus int
+
+const (
+	StatusUnknown     = RankedStatus(0)
+	StatusUnsubmitted = RankedStatus(1)
+	StatusPending     = RankedStatus(2) // Also WIP and graveyard
+	StatusRanked      = RankedStatus(4)
+	StatusApproved    = RankedStatus(5)
+	StatusQualified   = RankedStatus(6)
+	StatusLoved       = RankedStatus(7)
+)
+
+func (s RankedStatus) String() string {
+	switch s {
+	case StatusUnsubmitted:
+		return "Unsubmitted"
+	case StatusPending:
+		return "Pending"
+	case StatusRanked:
+		return "Ranked"
+	case StatusApproved:
+		return "Approved"
+	case StatusQualified:
+		return "Qualified"
+	case StatusLoved:
+		return "Loved"
+	}
+
+	return "Unknown"
+}
+
+// ParseRankedStatus parses status name, first letters like in osu!stable's search ("r" for ranked) are accepted too
+func ParseRankedStatus(value string) (RankedStatus, error) {
+	value = strings.ToLower(value)
+
+	switch value {
+	case "graveyard", "wip":
+		return StatusPending, nil
+	}
+
+	for _, s := range []RankedStatus{StatusUnknown, StatusUnsubmitted, StatusPending, StatusRanked, StatusApproved, StatusQualified, StatusLoved} {
+		if name := strings.ToLower(s.String()); value == name || (len(value) == 1 && s != StatusUnknown && value[0] == name[0]) {
+			return s, nil
+		}
+	}
+
+	return StatusUnknown, fmt.Errorf("unknown ranked status: %s", value)
+}
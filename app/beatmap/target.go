@@ -0,0 +1,100 @@
+package beatmap
+
+import (
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/beatmap/objects"
+	"github.com/wieku/danser-go/framework/math/vector"
+	"sort"
+)
+
+// EnableTargetPractice activates Target Practice mod on a beatmap with already parsed objects and converts them to targets
+func EnableTargetPractice(beatMap *BeatMap, diffCalcOnly bool) {
+	if beatMap.Diff.CheckModActive(difficulty.Target) {
+		return
+	}
+
+	beatMap.Diff.SetMods(beatMap.Diff.Mods | difficulty.Target)
+
+	convertToTargets(beatMap, diffCalcOnly)
+	calculateStackLeniency(beatMap, diffCalcOnly)
+}
+
+// convertToTargets replaces beatmap's objects with Target Practice targets placed on every beat.
+// Targets follow the path of original objects, beats during breaks and spinners are skipped.
+func convertToTargets(beatMap *BeatMap, diffCalcOnly bool) {
+	objs := beatMap.HitObjects
+	if len(objs) == 0 {
+		return
+	}
+
+	start := objs[0].GetStartTime()
+	end := objs[len(objs)-1].GetEndTime()
+
+	beats, measureStarts := beatMap.Timings.GetBeats(start, end)
+
+	targets := make([]objects.IHitObject, 0, len(beats))
+
+	forceNewCombo := true
+
+	for i, time := range beats {
+		if isInBreak(beatMap, objs, time) {
+			forceNewCombo = true
+			continue
+		}
+
+		targets = append(targets, objects.NewTarget(getPathPosition(objs, time), time, forceNewCombo || measureStarts[i]))
+
+		forceNewCombo = false
+	}
+
+	beatMap.HitObjects = targets
+
+	assignComboNumbers(beatMap)
+
+	for _, obj := range beatMap.HitObjects {
+		obj.SetTiming(beatMap.Timings, beatMap.Version, diffCalcOnly)
+	}
+}
+
+func isInBreak(beatMap *BeatMap, objs []objects.IHitObject, time float64) bool {
+	for _, pause := range beatMap.Pauses {
+		if time >= pause.GetStartTime() && time <= pause.GetEndTime() {
+			return true
+		}
+	}
+
+	for _, o := range objs {
+		if o.GetType() == objects.SPINNER && time >= o.GetStartTime() && time <= o.GetEndTime() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getPathPosition returns the position of the object active at given time or interpolates between neighbouring objects
+func getPathPosition(objs []objects.IHitObject, time float64) vector.Vector2f {
+	index := sort.Search(len(objs), func(i int) bool {
+		return objs[i].GetStartTime() > time
+	}) - 1
+
+	if index < 0 {
+		return objs[0].GetStartPosition()
+	}
+
+	current := objs[index]
+
+	if time <= current.GetEndTime() {
+		return current.GetPositionAt(time)
+	}
+
+	if index == len(objs)-1 {
+		return current.GetEndPosition()
+	}
+
+	next := objs[index+1]
+
+	t := float32((time - current.GetEndTime()) / (next.GetStartTime() - current.GetEndTime()))
+
+	return current.GetEndPosition().Lerp(next.GetStartPosition(), t)
+}
@@ -0,0 +1,393 @@
+package beatmap
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/wieku/danser-go/app/audio"
+	"github.com/wieku/danser-go/app/beatmap/objects"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/framework/files"
+	"github.com/wieku/danser-go/framework/math/curves"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// General keys that are stored in BeatMap, other keys are copied from the original file
+var writtenGeneralKeys = map[string]bool{
+	"AudioFilename": true,
+	"AudioLeadIn":   true,
+	"PreviewTime":   true,
+	"SampleSet":     true,
+	"StackLeniency": true,
+	"Mode":          true,
+}
+
+var sampleSetNames = map[int]string{
+	1: "Normal",
+	2: "Soft",
+	3: "Drum",
+}
+
+// SaveBeatMap writes the beatmap to a .osu file, see WriteBeatMap
+func SaveBeatMap(beatMap *BeatMap, path string, storyboard bool) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(file)
+
+	if err = WriteBeatMap(beatMap, bw, storyboard); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err = bw.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// WriteBeatMap serializes the beatmap as osu file format v14. Timing points and hit objects have to be parsed.
+// Difficulty settings are written with custom values (-ar, -cs etc.) applied but without mods.
+// Settings that BeatMap doesn't store (countdown, editor settings except bookmarks, combo colours) are copied from the original file if it still exists.
+// If storyboard is true, storyboard events and variables of the original file are copied as they are, their times are not
+// changed with the rest of the map. Background, video and breaks are always written from BeatMap. .osb file is not affected.
+func WriteBeatMap(beatMap *BeatMap, w io.Writer, storyboard bool) error {
+	extra := readExtraSections(beatMap)
+
+	ow := &osuWriter{w: w}
+
+	ow.line("osu file format v14")
+	ow.line("")
+
+	ow.line("[General]")
+	ow.line("AudioFilename: %s", beatMap.Audio)
+	ow.line("AudioLeadIn: %d", beatMap.AudioLeadIn)
+	ow.line("PreviewTime: %d", beatMap.PreviewTime)
+
+	for _, l := range extra["General"] {
+		ow.line("%s", l)
+	}
+
+	sampleSet, ok := sampleSetNames[beatMap.Timings.BaseSet]
+	if !ok {
+		sampleSet = "Normal"
+	}
+
+	ow.line("SampleSet: %s", sampleSet)
+	ow.line("StackLeniency: %s", formatFloat(beatMap.StackLeniency))
+	ow.line("Mode: %d", beatMap.Mode)
+	ow.line("")
+
+	if editor := extra["Editor"]; len(editor) > 0 {
+		ow.line("[Editor]")
+
+		for _, l := range editor {
+			ow.line("%s", l)
+		}
+
+		ow.line("")
+	}
+
+	ow.line("[Metadata]")
+	ow.line("Title:%s", beatMap.Name)
+	ow.line("TitleUnicode:%s", beatMap.NameUnicode)
+	ow.line("Artist:%s", beatMap.Artist)
+	ow.line("ArtistUnicode:%s", beatMap.ArtistUnicode)
+	ow.line("Creator:%s", beatMap.Creator)
+	ow.line("Version:%s", beatMap.Difficulty)
+	ow.line("Source:%s", beatMap.Source)
+	ow.line("Tags:%s", beatMap.Tags)
+	ow.line("BeatmapID:%d", beatMap.ID)
+	ow.line("BeatmapSetID:%d", beatMap.SetID)
+	ow.line("")
+
+	ow.line("[Difficulty]")
+	ow.line("HPDrainRate:%s", formatFloat(beatMap.Diff.GetHP()))
+	ow.line("CircleSize:%s", formatFloat(beatMap.Diff.GetCS()))
+	ow.line("OverallDifficulty:%s", formatFloat(beatMap.Diff.GetOD()))
+	ow.line("ApproachRate:%s", formatFloat(beatMap.Diff.GetAR()))
+	ow.line("SliderMultiplier:%s", formatFloat(beatMap.SliderMultiplier))
+	ow.line("SliderTickRate:%s", formatFloat(beatMap.Timings.TickRate))
+	ow.line("")
+
+	if variables := extra["Variables"]; storyboard && len(variables) > 0 {
+		ow.line("[Variables]")
+
+		for _, l := range variables {
+			ow.line("%s", l)
+		}
+
+		ow.line("")
+	}
+
+	ow.line("[Events]")
+	ow.line("//Background and Video events")
+
+	if beatMap.Bg != "" {
+		ow.line("0,0,\"%s\",0,0", beatMap.Bg)
+	}
+
+	if beatMap.Video != "" {
+		ow.line("Video,%d,\"%s\"", beatMap.VideoOffset, beatMap.Video)
+	}
+
+	ow.line("//Break Periods")
+
+	for _, pause := range beatMap.Pauses {
+		ow.line("2,%s,%s", formatTime(pause.StartTime), formatTime(pause.EndTime))
+	}
+
+	if events := extra["Events"]; storyboard && len(events) > 0 {
+		for _, l := range events {
+			ow.line("%s", l)
+		}
+	} else {
+		ow.line("//Storyboard Layer 0 (Background)")
+		ow.line("//Storyboard Layer 1 (Fail)")
+		ow.line("//Storyboard Layer 2 (Pass)")
+		ow.line("//Storyboard Layer 3 (Foreground)")
+		ow.line("//Storyboard Layer 4 (Overlay)")
+		ow.line("//Storyboard Sound Samples")
+	}
+
+	ow.line("")
+
+	ow.line("[TimingPoints]")
+
+	for _, point := range beatMap.Timings.GetPoints() {
+		uninherited := 1
+		if point.Inherited {
+			uninherited = 0
+		}
+
+		effects := 0
+		if point.Kiai {
+			effects |= 1
+		}
+
+		if point.OmitFirstBarLine {
+			effects |= 8
+		}
+
+		ow.line("%s,%s,%d,%d,%d,%d,%d,%d", formatFloat(point.Time), formatFloat(point.GetRawBeatLength()), point.Signature, point.SampleSet, point.SampleIndex, int(math.Round(point.SampleVolume*100)), uninherited, effects)
+	}
+
+	ow.line("")
+
+	if colours := extra["Colours"]; len(colours) > 0 { //nolint:misspell
+		ow.line("[Colours]") //nolint:misspell
+
+		for _, l := range colours {
+			ow.line("%s", l)
+		}
+
+		ow.line("")
+	}
+
+	ow.line("[HitObjects]")
+
+	for _, obj := range beatMap.HitObjects {
+		ow.hitObject(obj)
+	}
+
+	return ow.err
+}
+
+// readExtraSections reads lines of the original file that can't be recreated from BeatMap
+func readExtraSections(beatMap *BeatMap) map[string][]string {
+	extra := make(map[string][]string)
+
+	file, err := os.Open(filepath.Join(settings.General.GetSongsDir(), beatMap.Dir, beatMap.File))
+	if err != nil {
+		return extra
+	}
+
+	defer file.Close()
+
+	scanner := files.NewScanner(file)
+
+	buf := bufferPool.Get().(*[]byte)
+	scanner.Buffer(*buf, cap(*buf))
+
+	defer bufferPool.Put(buf)
+
+	var currentSection string
+
+	for scanner.Scan() {
+		raw := scanner.Text()
+		line := strings.TrimSpace(raw)
+
+		section := getSection(line)
+		if section != "" {
+			currentSection = section
+			continue
+		}
+
+		if line == "" {
+			continue
+		}
+
+		// Indentation of storyboard commands is significant, so events are kept as they are
+		if currentSection == "Events" {
+			if isStoryboardEvent(raw) {
+				extra[currentSection] = append(extra[currentSection], strings.TrimRight(raw, " \t"))
+			}
+
+			continue
+		}
+
+		if strings.HasPrefix(line, "//") {
+			continue
+		}
+
+		switch currentSection {
+		case "General":
+			if arr := tokenizeN(line, ":", 2); len(arr) > 1 && !writtenGeneralKeys[arr[0]] {
+				extra[currentSection] = append(extra[currentSection], line)
+			}
+		case "Editor":
+			if arr := tokenizeN(line, ":", 2); len(arr) > 1 && arr[0] != "Bookmarks" { // bookmarks may not match modified timing
+				extra[currentSection] = append(extra[currentSection], line)
+			}
+		case "Colours", "Variables": //nolint:misspell
+			extra[currentSection] = append(extra[currentSection], line)
+		}
+	}
+
+	return extra
+}
+
+// isStoryboardEvent checks whether a line of [Events] section belongs to the storyboard.
+// Background, video and breaks are not included as they are written from BeatMap.
+func isStoryboardEvent(raw string) bool {
+	if strings.HasPrefix(raw, " ") || strings.HasPrefix(raw, "_") { // commands of the previous object
+		return true
+	}
+
+	line := strings.TrimSpace(raw)
+
+	if line == "//Background and Video events" || line == "//Break Periods" {
+		return false
+	}
+
+	if strings.HasPrefix(line, "//") { // storyboard layer headers
+		return true
+	}
+
+	switch strings.TrimSpace(strings.Split(line, ",")[0]) {
+	case "0", "1", "2", "Background", "Video", "Break":
+		return false
+	}
+
+	return true
+}
+
+type osuWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (w *osuWriter) line(format string, args ...any) {
+	if w.err != nil {
+		return
+	}
+
+	_, w.err = fmt.Fprintf(w.w, format+"\r\n", args...)
+}
+
+func (w *osuWriter) hitObject(obj objects.IHitObject) {
+	objType := int(obj.GetType())
+
+	if obj.IsNewCombo() {
+		objType |= int(objects.NEWCOMBO)
+	}
+
+	objType |= int(obj.GetColorOffset()&7) << 4
+
+	pos := obj.GetStartPosition()
+
+	switch o := obj.(type) {
+	case *objects.Circle:
+		w.line("%s,%s,%s,%d,%d,%s", formatFloat32(pos.X), formatFloat32(pos.Y), formatTime(o.StartTime), objType, o.GetBaseSample(), formatHitSample(o.BasicHitSound))
+	case *objects.Slider:
+		samples, sampleSets, additionSets := o.GetEdgeSamples()
+
+		edgeSounds := make([]string, len(samples))
+		edgeSets := make([]string, len(samples))
+
+		for i := range samples {
+			edgeSounds[i] = strconv.Itoa(samples[i])
+			edgeSets[i] = fmt.Sprintf("%d:%d", sampleSets[i], additionSets[i])
+		}
+
+		w.line("%s,%s,%s,%d,%d,%s,%d,%s,%s,%s,%s", formatFloat32(pos.X), formatFloat32(pos.Y), formatTime(o.StartTime), objType, o.GetBaseSample(),
+			formatCurve(o.GetCurveDefs()), o.RepeatCount, formatFloat(o.GetPixelLength()), strings.Join(edgeSounds, "|"), strings.Join(edgeSets, "|"), formatHitSample(o.BasicHitSound))
+	case *objects.Spinner:
+		w.line("256,192,%s,%d,%d,%s,%s", formatTime(o.StartTime), objType, o.GetBaseSample(), formatTime(o.EndTime), formatHitSample(o.BasicHitSound))
+	}
+}
+
+func formatHitSample(info audio.HitSoundInfo) string {
+	return fmt.Sprintf("%d:%d:%d:%d:%s", info.SampleSet, info.AdditionSet, info.CustomIndex, int(math.Round(info.CustomVolume*100)), info.Filename)
+}
+
+// formatCurve writes curve segments the way objects.Slider parses them, a type change is placed before the last point of the previous segment
+func formatCurve(defs []curves.CurveDef) string {
+	var sb strings.Builder
+
+	for i, def := range defs {
+		if i == 0 {
+			sb.WriteString(curveTypeName(def.CurveType))
+		}
+
+		points := def.Points[1:] // the first point is slider's start or the end of the previous segment
+
+		for j, p := range points {
+			if j == len(points)-1 && i < len(defs)-1 {
+				sb.WriteString("|" + curveTypeName(defs[i+1].CurveType))
+			}
+
+			sb.WriteString("|" + formatFloat32(p.X) + ":" + formatFloat32(p.Y))
+		}
+	}
+
+	return sb.String()
+}
+
+func curveTypeName(cType curves.CType) string {
+	switch cType {
+	case curves.CCirArc:
+		return "P"
+	case curves.CLine:
+		return "L"
+	case curves.CCatmull:
+		return "C"
+	default:
+		return "B"
+	}
+}
+
+// formatTime rounds the time to whole milliseconds, osu!stable reads object times as integers
+func formatTime(time float64) string {
+	return strconv.FormatInt(int64(math.Round(time)), 10)
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func formatFloat32(value float32) string {
+	return strconv.FormatFloat(float64(value), 'f', -1, 32)
+}
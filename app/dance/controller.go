@@ -2,14 +2,19 @@ package dance
 
 import (
 	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
 	"github.com/wieku/danser-go/app/beatmap/objects"
 	"github.com/wieku/danser-go/app/dance/movers"
 	"github.com/wieku/danser-go/app/dance/schedulers"
 	"github.com/wieku/danser-go/app/dance/spinners"
+	"github.com/wieku/danser-go/app/database"
 	"github.com/wieku/danser-go/app/graphics"
+	"github.com/wieku/danser-go/app/rulesets/osu"
+	"github.com/wieku/danser-go/app/rulesets/osu/performance/pp220930"
 	"github.com/wieku/danser-go/app/settings"
 	"sort"
 	"strings"
+	"time"
 )
 
 type Controller interface {
@@ -179,3 +184,42 @@ func (controller *GenericController) Update(time float64, delta float64) {
 func (controller *GenericController) GetCursors() []*graphics.Cursor {
 	return controller.cursors
 }
+
+// getScoreTime returns time shown on results screen for non-replay cursors, fixed in deterministic mode
+func getScoreTime() time.Time {
+	if settings.DETERMINISTIC {
+		return time.Unix(0, 0).UTC()
+	}
+
+	return time.Now()
+}
+
+// newRuleset creates osu! ruleset with difficulty attributes loaded from the database cache, attributes the ruleset had to
+// calculate are cached for next runs. Custom difficulty values and speed aren't cached.
+func newRuleset(bMap *beatmap.BeatMap, cursors []*graphics.Cursor, mods []difficulty.Modifier) *osu.OsuRuleSet {
+	cacheable := bMap.MD5 != "" && bMap.Diff.CustomSpeed == 1 &&
+		bMap.Diff.GetAR() == bMap.Diff.GetBaseAR() && bMap.Diff.GetOD() == bMap.Diff.GetBaseOD() &&
+		bMap.Diff.GetCS() == bMap.Diff.GetBaseCS() && bMap.Diff.GetHP() == bMap.Diff.GetBaseHP()
+
+	if !cacheable {
+		return osu.NewOsuRuleset(bMap, cursors, mods, nil)
+	}
+
+	cached := make(map[difficulty.Modifier][]pp220930.Attributes)
+
+	for _, m := range mods {
+		if maskedMods := difficulty.GetDiffMaskedMods(m); cached[maskedMods] == nil {
+			cached[maskedMods] = database.GetDifficultySteps(bMap.MD5, m)
+		}
+	}
+
+	ruleset := osu.NewOsuRuleset(bMap, cursors, mods, cached)
+
+	for maskedMods, steps := range ruleset.GetDifficultySteps() {
+		if len(cached[maskedMods]) != len(steps) {
+			database.StoreDifficultySteps(bMap.MD5, maskedMods, steps)
+		}
+	}
+
+	return ruleset
+}
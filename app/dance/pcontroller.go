@@ -18,7 +18,6 @@ import (
 	"github.com/wieku/danser-go/framework/platform"
 	"log"
 	"strings"
-	"time"
 )
 
 type PlayerController struct {
@@ -54,9 +53,9 @@ func (controller *PlayerController) InitCursors() {
 	controller.cursors = []*graphics.Cursor{graphics.NewCursor()}
 	controller.cursors[0].IsPlayer = true
 	controller.cursors[0].Name = settings.Gameplay.PlayUsername
-	controller.cursors[0].ScoreTime = time.Now()
+	controller.cursors[0].ScoreTime = getScoreTime()
 	controller.window = glfw.GetCurrentContext()
-	controller.ruleset = osu.NewOsuRuleset(controller.bMap, controller.cursors, []difficulty.Modifier{controller.bMap.Diff.Mods})
+	controller.ruleset = newRuleset(controller.bMap, controller.cursors, []difficulty.Modifier{controller.bMap.Diff.Mods})
 
 	if !controller.bMap.Diff.CheckModActive(difficulty.Relax) {
 		input2.RegisterListener(controller.KeyEvent)
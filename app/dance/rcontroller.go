@@ -7,6 +7,8 @@ import (
 	"github.com/wieku/danser-go/app/dance/movers"
 	"github.com/wieku/danser-go/app/dance/schedulers"
 	"github.com/wieku/danser-go/app/dance/spinners"
+	"github.com/wieku/danser-go/app/database"
+	"github.com/wieku/danser-go/app/knockout"
 	"github.com/wieku/danser-go/framework/env"
 	"github.com/wieku/danser-go/framework/math/mutils"
 	"github.com/wieku/rplpa"
@@ -34,6 +36,17 @@ import (
 
 const replaysMaster = "replays"
 
+// Replays found by LoadLibraryReplays for the beatmap with libraryMD5
+var libraryReplays []string
+var libraryMD5 string
+
+// Candidates loaded by PrepareCandidates for the beatmap with candidatesMD5
+var knockoutCandidates []*knockout.Candidate
+var candidatesMD5 string
+
+// SplitScreenViews is the maximum number of playfields shown in split screen knockout mode
+const SplitScreenViews = 4
+
 type RpData struct {
 	Name      string
 	Mods      string
@@ -44,6 +57,8 @@ type RpData struct {
 	Grade     osu.Grade
 	scoreID   int64
 	ScoreTime time.Time
+	Team      int
+	Path      string
 }
 
 type subControl struct {
@@ -69,14 +84,19 @@ type ReplayController struct {
 	replays     []RpData
 	cursors     []*graphics.Cursor
 	controllers []*subControl
+
+	// First cursor of every replay, danser's play can have more cursors than one
+	replayCursors []*graphics.Cursor
+
 	ruleset     *osu.OsuRuleSet
 	lastTime    float64
+	replayPaths map[*rplpa.Replay]string
 }
 
 func NewReplayController() Controller {
 	_ = os.MkdirAll(filepath.Join(env.DataDir(), replaysMaster), 0755)
 
-	return &ReplayController{lastTime: -200}
+	return &ReplayController{lastTime: -200, replayPaths: make(map[*rplpa.Replay]string)}
 }
 
 func (controller *ReplayController) SetBeatMap(beatMap *beatmap.BeatMap) {
@@ -101,23 +121,63 @@ func (controller *ReplayController) SetBeatMap(beatMap *beatmap.BeatMap) {
 			log.Println("Excluding for missing input data:", replayD.Username)
 		} else {
 			candidates = append(candidates, replayD)
+			controller.replayPaths[replayD] = settings.REPLAY
 
 			localReplay = true
 		}
+
+		if settings.GHOST != "" && localReplay {
+			log.Println("Loading ghost: ", settings.GHOST)
+
+			data, err = ioutil.ReadFile(settings.GHOST)
+			if err != nil {
+				panic(err)
+			}
+
+			ghostD, _ := rplpa.ParseReplay(data)
+
+			if ghostD.ReplayData == nil || len(ghostD.ReplayData) == 0 {
+				log.Println("Excluding ghost for missing input data:", ghostD.Username)
+			} else {
+				candidates = append(candidates, ghostD)
+				controller.replayPaths[ghostD] = settings.GHOST
+			}
+		}
 	} else if settings.Knockout.MaxPlayers > 0 || (settings.KNOCKOUTREPLAYS != nil && len(settings.KNOCKOUTREPLAYS) > 0) { // ignore max player limit with new knockout
 		candidates = controller.getCandidates()
 	}
 
 	if !localReplay {
-		sort.Slice(candidates, func(i, j int) bool {
-			return candidates[i].Score > candidates[j].Score
-		})
-
-		if settings.KNOCKOUTREPLAYS == nil || len(settings.KNOCKOUTREPLAYS) == 0 { // limit only with classic knockout
+		if settings.KNOCKOUTREPLAYS == nil || len(settings.KNOCKOUTREPLAYS) == 0 { // limit only with classic knockout, candidates are already ranked by selection rules
 			candidates = candidates[:min(len(candidates), settings.Knockout.MaxPlayers)]
+		} else {
+			sort.Slice(candidates, func(i, j int) bool {
+				return candidates[i].Score > candidates[j].Score
+			})
 		}
 	}
 
+	if settings.Knockout.Mode == settings.SplitScreen && !localReplay {
+		maxViews := SplitScreenViews
+		if settings.Knockout.AddDanser {
+			maxViews--
+		}
+
+		if len(candidates) > maxViews {
+			log.Println(fmt.Sprintf("Split screen mode supports up to %d playfields, excluding %d replays", SplitScreenViews, len(candidates)-maxViews))
+			candidates = candidates[:maxViews]
+		}
+	}
+
+	if settings.Knockout.Mode == settings.TeamVsTeam && len(settings.KNOCKOUTTEAMS) == 0 && !localReplay {
+		log.Println("Team vs Team mode needs teams defined with -teams or -knockout2, falling back to Combo Break mode")
+		settings.Knockout.Mode = settings.ComboBreak
+	}
+
+	if settings.Knockout.Mode == settings.TeamVsTeam && settings.Knockout.TeamScoring == "ScoreV2" {
+		controller.bMap.Diff.SetMods(controller.bMap.Diff.Mods | difficulty.ScoreV2)
+	}
+
 	displayedMods := ^difficulty.ParseMods(settings.Knockout.HideMods)
 
 	for i, replay := range candidates {
@@ -135,7 +195,18 @@ func (controller *ReplayController) SetBeatMap(beatMap *beatmap.BeatMap) {
 		control.newHandling = replay.OsuVersion >= 20190506 // This was when slider scoring was changed, so *I think* replay handling as well: https://osu.ppy.sh/home/changelog/cuttingedge/20190506
 		control.oldSpinners = replay.OsuVersion < 20190510  // This was when spinner scoring was changed: https://osu.ppy.sh/home/changelog/cuttingedge/20190510.2
 
-		controller.replays = append(controller.replays, RpData{replay.Username + string(rune(unicode.MaxRune-i)), (control.mods & displayedMods).String(), control.mods, 100, 0, int64(mxCombo), osu.NONE, replay.ScoreID, replay.Timestamp})
+		controller.replays = append(controller.replays, RpData{replay.Username + string(rune(unicode.MaxRune-i)), (control.mods & displayedMods).String(), control.mods, 100, 0, int64(mxCombo), osu.NONE, replay.ScoreID, replay.Timestamp, -1, controller.replayPaths[replay]})
+
+		if settings.Knockout.Mode == settings.TeamVsTeam {
+			team := findTeam(controller.replayPaths[replay], replay.Username)
+			controller.replays[len(controller.replays)-1].Team = team
+
+			if team >= 0 {
+				log.Println("\tTeam:", settings.KNOCKOUTTEAMS[team].Name)
+			} else {
+				log.Println("\tWARNING: Player is not assigned to any team")
+			}
+		}
 		controller.controllers = append(controller.controllers, control)
 
 		log.Println("\tExpected score:", replay.Score)
@@ -149,7 +220,7 @@ func (controller *ReplayController) SetBeatMap(beatMap *beatmap.BeatMap) {
 		control.danceController = NewGenericController()
 		control.danceController.SetBeatMap(beatMap)
 
-		controller.replays = append([]RpData{{settings.Knockout.DanserName, control.mods.String(), control.mods, 100, 0, 0, osu.NONE, -1, time.Now()}}, controller.replays...)
+		controller.replays = append([]RpData{{settings.Knockout.DanserName, control.mods.String(), control.mods, 100, 0, 0, osu.NONE, -1, getScoreTime(), -1, ""}}, controller.replays...)
 		controller.controllers = append([]*subControl{control}, controller.controllers...)
 
 		if len(candidates) == 0 {
@@ -207,10 +278,46 @@ func organizeReplays() {
 	})
 }
 
-func (controller *ReplayController) getCandidates() (candidates []*rplpa.Replay) {
-	excludedMods := difficulty.ParseMods(settings.Knockout.ExcludeMods)
+// PrepareCandidates loads replays used by the next ReplayController. Target Practice replays need a converted beatmap,
+// so it returns true if most of the replays use it and the beatmap doesn't. Has to be called before the player is created.
+func PrepareCandidates(beatMap *beatmap.BeatMap) (targetPractice bool) {
+	knockoutCandidates, candidatesMD5 = nil, ""
+
+	if !usesReplays() || (settings.Knockout.MaxPlayers <= 0 && len(settings.KNOCKOUTREPLAYS) == 0) {
+		return false
+	}
 
-	tryAddReplay := func(path string, modExclude bool) {
+	knockoutCandidates = loadCandidates(beatMap.MD5)
+	candidatesMD5 = beatMap.MD5
+
+	if beatMap.Diff.CheckModActive(difficulty.Target) {
+		return false
+	}
+
+	tpCount := 0
+
+	for _, c := range knockoutCandidates {
+		if difficulty.Modifier(c.Replay.Mods).Active(difficulty.Target) {
+			tpCount++
+		}
+	}
+
+	if tpCount > len(knockoutCandidates)-tpCount {
+		log.Println("Most replays use Target Practice, converting the beatmap...")
+		return true
+	}
+
+	return false
+}
+
+// usesReplays checks whether ReplayController will load replays other than the one given by -replay
+func usesReplays() bool {
+	return settings.KNOCKOUT && !settings.PLAY && settings.SPECTATE == "" && settings.REPLAY == ""
+}
+
+// loadCandidates loads replays given by -knockout2 or replays of the beatmap from the replay library for classic knockout
+func loadCandidates(beatmapMD5 string) (loaded []*knockout.Candidate) {
+	tryAddReplay := func(path string) {
 		log.Println("Loading: ", path)
 
 		data, err := ioutil.ReadFile(path)
@@ -225,35 +332,38 @@ func (controller *ReplayController) getCandidates() (candidates []*rplpa.Replay)
 			return
 		}
 
-		if !strings.EqualFold(replayD.BeatmapMD5, controller.bMap.MD5) {
+		if !strings.EqualFold(replayD.BeatmapMD5, beatmapMD5) {
 			log.Println("Incompatible maps, skipping", replayD.Username)
 			return
 		}
 
-		if !difficulty.Modifier(replayD.Mods).Compatible() || difficulty.Modifier(replayD.Mods).Active(difficulty.Target) {
+		if !difficulty.Modifier(replayD.Mods).Compatible() {
 			log.Println("Excluding for incompatible mods:", replayD.Username)
 			return
 		}
 
-		if (replayD.Mods&uint32(excludedMods)) > 0 && modExclude {
-			log.Println("Excluding for mods:", replayD.Username)
-			return
-		}
-
 		if replayD.ReplayData == nil || len(replayD.ReplayData) == 0 {
 			log.Println("Excluding for missing input data:", replayD.Username)
 			return
 		}
 
-		candidates = append(candidates, replayD)
+		loaded = append(loaded, knockout.NewCandidate(path, replayD))
 	}
 
-	if settings.KNOCKOUTREPLAYS != nil && len(settings.KNOCKOUTREPLAYS) > 0 {
+	if len(settings.KNOCKOUTREPLAYS) > 0 {
 		for _, r := range settings.KNOCKOUTREPLAYS {
-			tryAddReplay(r, false)
+			tryAddReplay(r)
+		}
+	} else if libraryMD5 != "" && strings.EqualFold(libraryMD5, beatmapMD5) {
+		for _, path := range libraryReplays {
+			tryAddReplay(path)
 		}
 	} else {
-		replayDir := filepath.Join(env.DataDir(), replaysMaster, controller.bMap.MD5)
+		log.Println("Replay library is not available, scanning replay directory instead...")
+
+		organizeReplays()
+
+		replayDir := filepath.Join(env.DataDir(), replaysMaster, beatmapMD5)
 
 		_ = godirwalk.Walk(replayDir, &godirwalk.Options{
 			Callback: func(osPathname string, de *godirwalk.Dirent) error {
@@ -262,7 +372,7 @@ func (controller *ReplayController) getCandidates() (candidates []*rplpa.Replay)
 				}
 
 				if strings.HasSuffix(de.Name(), ".osr") {
-					tryAddReplay(osPathname, true)
+					tryAddReplay(osPathname)
 				}
 
 				return nil
@@ -275,6 +385,175 @@ func (controller *ReplayController) getCandidates() (candidates []*rplpa.Replay)
 	return
 }
 
+func (controller *ReplayController) getCandidates() (candidates []*rplpa.Replay) {
+	classic := settings.KNOCKOUTREPLAYS == nil || len(settings.KNOCKOUTREPLAYS) == 0
+
+	var rules knockout.Rules
+
+	if classic {
+		var err error
+		if rules, err = knockout.RulesFromSettings(); err != nil {
+			panic(fmt.Sprintf("Invalid knockout selection rules: %s", err))
+		}
+	}
+
+	loaded := knockoutCandidates
+	if candidatesMD5 == "" || !strings.EqualFold(candidatesMD5, controller.bMap.MD5) {
+		loaded = loadCandidates(controller.bMap.MD5)
+	}
+
+	knockoutCandidates, candidatesMD5 = nil, ""
+
+	loaded = controller.matchTargetPractice(loaded)
+
+	if classic {
+		if rules.SortBy == "PP" {
+			log.Println("Calculating pp of replays...")
+			knockout.CalculatePP(controller.bMap, loaded)
+		}
+
+		selected := knockout.Select(loaded, rules)
+
+		selectedSet := make(map[*knockout.Candidate]bool, len(selected))
+		for _, c := range selected {
+			selectedSet[c] = true
+		}
+
+		for _, c := range loaded {
+			if !selectedSet[c] {
+				log.Println("Excluding by selection rules:", c.Replay.Username)
+			}
+		}
+
+		loaded = selected
+	}
+
+	for _, c := range loaded {
+		candidates = append(candidates, c.Replay)
+		controller.replayPaths[c.Replay] = c.Path
+	}
+
+	return
+}
+
+// matchTargetPractice excludes replays that don't match beatmap's Target Practice mod, see PrepareCandidates
+func (controller *ReplayController) matchTargetPractice(loaded []*knockout.Candidate) []*knockout.Candidate {
+	useTP := controller.bMap.Diff.CheckModActive(difficulty.Target)
+
+	matching := make([]*knockout.Candidate, 0, len(loaded))
+
+	for _, c := range loaded {
+		if difficulty.Modifier(c.Replay.Mods).Active(difficulty.Target) != useTP {
+			log.Println("Excluding for Target Practice mismatch:", c.Replay.Username)
+			continue
+		}
+
+		matching = append(matching, c)
+	}
+
+	return matching
+}
+
+// LoadLibraryReplays updates the replay index and finds replays of given beatmap used by classic knockout, mod and date
+// selection rules are applied by the query. Database has to be initialized, found replays are used by the next ReplayController.
+// Does nothing if replays from the library won't be used.
+func LoadLibraryReplays(beatmapMD5 string) error {
+	libraryReplays, libraryMD5 = nil, ""
+
+	if !usesReplays() || len(settings.KNOCKOUTREPLAYS) > 0 || settings.Knockout.MaxPlayers <= 0 {
+		return nil
+	}
+
+	rules, err := knockout.RulesFromSettings()
+	if err != nil {
+		return err
+	}
+
+	// Replays have to be in their final place before they are indexed
+	organizeReplays()
+
+	paths, err := getLibraryReplays(beatmapMD5, rules)
+	if err != nil {
+		return err
+	}
+
+	libraryReplays, libraryMD5 = paths, beatmapMD5
+
+	return nil
+}
+
+// getLibraryReplays updates the replay index and returns paths of replays of given beatmap, mod and date rules are already applied by the query.
+// Replays of osu!'s local scores are added if Knockout.Selection.StableReplays is enabled.
+func getLibraryReplays(beatmapMD5 string, rules knockout.Rules) ([]string, error) {
+	if err := database.IndexReplays(filepath.Join(env.DataDir(), replaysMaster)); err != nil {
+		return nil, err
+	}
+
+	replays, err := database.GetReplays(database.ReplayFilter{
+		BeatmapMD5:  beatmapMD5,
+		IncludeMods: rules.IncludeMods,
+		ExcludeMods: rules.ExcludeMods,
+		From:        rules.From,
+		To:          rules.To,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(replays))
+	for _, r := range replays {
+		paths = append(paths, r.Path)
+	}
+
+	if settings.Knockout.Selection.StableReplays {
+		scores, err := database.GetLocalScores(database.ReplayFilter{
+			BeatmapMD5:  beatmapMD5,
+			IncludeMods: rules.IncludeMods,
+			ExcludeMods: rules.ExcludeMods,
+			From:        rules.From,
+			To:          rules.To,
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, s := range scores {
+			// Scores of converted maps in other modes can't be replayed
+			if s.ReplayPath != "" && s.Mode == 0 {
+				paths = append(paths, s.ReplayPath)
+			}
+		}
+	}
+
+	return paths, nil
+}
+
+// findTeam returns index of the team in settings.KNOCKOUTTEAMS the replay belongs to, matched by username or replay path. Returns -1 if not found.
+func findTeam(path, username string) int {
+	absPath := ""
+	if path != "" {
+		absPath, _ = filepath.Abs(path)
+	}
+
+	for i, team := range settings.KNOCKOUTTEAMS {
+		for _, player := range team.Players {
+			if strings.EqualFold(player, username) {
+				return i
+			}
+
+			if absPath != "" {
+				if pPath, err := filepath.Abs(player); err == nil && pPath == absPath {
+					return i
+				}
+			}
+		}
+	}
+
+	return -1
+}
+
 func loadFrames(subController *subControl, frames []*rplpa.ReplayData) {
 	// Remove mania seed frame if its present
 	for i, frame := range frames {
@@ -341,11 +620,12 @@ func (controller *ReplayController) InitCursors() {
 
 			for _, cursor := range cursors {
 				cursor.Name = controller.replays[i].Name
-				cursor.ScoreTime = time.Now()
+				cursor.ScoreTime = getScoreTime()
 				cursor.ScoreID = -1
 			}
 
 			controller.cursors = append(controller.cursors, cursors...)
+			controller.replayCursors = append(controller.replayCursors, cursors[0])
 		} else {
 			cursor := graphics.NewCursor()
 			cursor.Name = controller.replays[i].Name
@@ -361,6 +641,7 @@ func (controller *ReplayController) InitCursors() {
 			c.frames = c.frames[1:]
 
 			controller.cursors = append(controller.cursors, cursor)
+			controller.replayCursors = append(controller.replayCursors, cursor)
 		}
 
 		if controller.bMap.Diff.Mods.Active(difficulty.HardRock) != controller.replays[i].ModsV.Active(difficulty.HardRock) {
@@ -370,7 +651,7 @@ func (controller *ReplayController) InitCursors() {
 		modifiers = append(modifiers, controller.replays[i].ModsV)
 	}
 
-	controller.ruleset = osu.NewOsuRuleset(controller.bMap, controller.cursors, modifiers)
+	controller.ruleset = newRuleset(controller.bMap, controller.cursors, modifiers)
 
 	for i := range controller.controllers {
 		if controller.replays[i].ModsV.Active(difficulty.Relax) {
@@ -549,6 +830,11 @@ func (controller *ReplayController) GetReplays() []RpData {
 	return controller.replays
 }
 
+// GetReplayCursor returns the cursor playing the replay at given index of GetReplays
+func (controller *ReplayController) GetReplayCursor(replay int) *graphics.Cursor {
+	return controller.replayCursors[replay]
+}
+
 func (controller *ReplayController) GetRuleset() *osu.OsuRuleSet {
 	return controller.ruleset
 }
@@ -9,7 +9,7 @@ import (
 	"github.com/wieku/danser-go/app/graphics"
 	"github.com/wieku/danser-go/app/settings"
 	"github.com/wieku/danser-go/framework/math/vector"
-	"math/rand"
+	"github.com/wieku/danser-go/framework/util"
 )
 
 type GenericScheduler struct {
@@ -38,7 +38,7 @@ func (scheduler *GenericScheduler) Init(objs []objects.IHitObject, diff *difficu
 
 	// Slider dance / random slider dance resolving
 	for i := 0; i < len(scheduler.queue); i++ {
-		scheduler.queue = PreprocessQueue(i, scheduler.queue, (config.SliderDance && !config.RandomSliderDance) || (config.RandomSliderDance && rand.Intn(2) == 0))
+		scheduler.queue = PreprocessQueue(i, scheduler.queue, (config.SliderDance && !config.RandomSliderDance) || (config.RandomSliderDance && util.RandomIntn(2) == 0))
 	}
 
 	// Convert spinners to pseudo spinners that have beginning and ending angles, simplifies mover codes as well
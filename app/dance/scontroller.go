@@ -0,0 +1,214 @@
+package dance
+
+import (
+	"fmt"
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/dance/input"
+	"github.com/wieku/danser-go/app/dance/movers"
+	"github.com/wieku/danser-go/app/dance/schedulers"
+	"github.com/wieku/danser-go/app/dance/spinners"
+	"github.com/wieku/danser-go/app/graphics"
+	"github.com/wieku/danser-go/app/rulesets/osu"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/app/spectator"
+	"github.com/wieku/danser-go/framework/math/vector"
+	"github.com/wieku/rplpa"
+	"math"
+)
+
+// SpectatorController drives a single cursor with replay frames streamed over a local socket.
+// Judgements never go past the latest received frame, so objects of a feeder lagging behind the music wait for its frames
+// instead of being missed. Once the feeder disconnects, the rest of the map is judged along with the music.
+type SpectatorController struct {
+	bMap    *beatmap.BeatMap
+	cursors []*graphics.Cursor
+	ruleset *osu.OsuRuleSet
+
+	server *spectator.Server
+
+	frames     []*rplpa.ReplayData
+	frameIndex int
+	frameTime  int64
+	received   bool
+
+	// receivedTime is the time of the latest received frame
+	receivedTime int64
+	rulesetTime  int64
+
+	relaxController *input.RelaxInputProcessor
+	mouseController schedulers.Scheduler
+
+	lastTime float64
+}
+
+func NewSpectatorController() Controller {
+	return new(SpectatorController)
+}
+
+func (controller *SpectatorController) SetBeatMap(beatMap *beatmap.BeatMap) {
+	controller.bMap = beatMap
+}
+
+func (controller *SpectatorController) InitCursors() {
+	server, err := spectator.Listen(settings.SPECTATE)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to start spectator server on %s: %s", settings.SPECTATE, err))
+	}
+
+	controller.server = server
+	controller.rulesetTime = math.MinInt64
+
+	cursor := graphics.NewCursor()
+	cursor.Name = settings.SPECTATORNAME
+	cursor.ScoreTime = getScoreTime()
+	cursor.IsReplay = true
+
+	controller.cursors = []*graphics.Cursor{cursor}
+	controller.ruleset = newRuleset(controller.bMap, controller.cursors, []difficulty.Modifier{controller.bMap.Diff.Mods})
+
+	if controller.bMap.Diff.CheckModActive(difficulty.Relax) {
+		controller.relaxController = input.NewRelaxInputProcessor(controller.ruleset, cursor)
+	}
+
+	if controller.bMap.Diff.CheckModActive(difficulty.Relax2) {
+		controller.mouseController = schedulers.NewGenericScheduler(movers.NewLinearMoverSimple, 0, 0)
+		controller.mouseController.Init(controller.bMap.GetObjectsCopy(), controller.bMap.Diff, cursor, spinners.GetMoverCtorByName("circle"), false)
+	}
+}
+
+func (controller *SpectatorController) Update(time float64, delta float64) {
+	controller.receive()
+
+	numSkipped := int(time-controller.lastTime) - 1
+
+	if numSkipped >= 1 {
+		for nTime := numSkipped; nTime >= 1; nTime-- {
+			controller.updateMain(time - float64(nTime))
+		}
+	}
+
+	controller.updateMain(time)
+
+	controller.cursors[0].Update(delta)
+}
+
+func (controller *SpectatorController) receive() {
+	for _, frame := range controller.server.Poll() {
+		if frame.Time == -12345 {
+			continue
+		}
+
+		if !controller.received {
+			controller.received = true
+
+			// Same as in .osr files, first frame with 0 delta is ignored
+			if frame.Time == 0 {
+				continue
+			}
+		}
+
+		controller.frames = append(controller.frames, frame)
+		controller.receivedTime += frame.Time
+	}
+}
+
+// getJudgeTime returns the time up to which objects can be judged
+func (controller *SpectatorController) getJudgeTime(nTime int64) int64 {
+	if controller.received && !controller.server.IsConnected() {
+		return nTime
+	}
+
+	return min(nTime, controller.receivedTime)
+}
+
+func (controller *SpectatorController) updateMain(nTime float64) {
+	controller.bMap.Update(nTime)
+
+	cursor := controller.cursors[0]
+
+	isRelax := controller.relaxController != nil
+	isAutopilot := controller.mouseController != nil
+
+	if isAutopilot {
+		controller.mouseController.Update(nTime)
+	}
+
+	wasUpdated := false
+
+	for controller.frameIndex < len(controller.frames) && controller.frameTime+controller.frames[controller.frameIndex].Time <= int64(nTime) {
+		frame := controller.frames[controller.frameIndex]
+		controller.frameTime += frame.Time
+
+		if !isAutopilot {
+			cursor.SetPos(vector.NewVec2f(frame.MouseX, frame.MouseY))
+		}
+
+		cursor.LastFrameTime = cursor.CurrentFrameTime
+		cursor.CurrentFrameTime = controller.frameTime
+		cursor.IsReplayFrame = true
+
+		if !isRelax {
+			cursor.LeftKey = frame.KeyPressed.LeftClick && frame.KeyPressed.Key1
+			cursor.RightKey = frame.KeyPressed.RightClick && frame.KeyPressed.Key2
+
+			cursor.LeftMouse = frame.KeyPressed.LeftClick && !frame.KeyPressed.Key1
+			cursor.RightMouse = frame.KeyPressed.RightClick && !frame.KeyPressed.Key2
+
+			cursor.LeftButton = frame.KeyPressed.LeftClick
+			cursor.RightButton = frame.KeyPressed.RightClick
+		} else {
+			controller.relaxController.Update(float64(controller.frameTime))
+		}
+
+		cursor.SmokeKey = frame.KeyPressed.Smoke
+
+		// Next frame may not have arrived yet, so slider ends are always processed ahead like in new replays
+		controller.ruleset.UpdateClickFor(cursor, controller.frameTime)
+		controller.ruleset.UpdateNormalFor(cursor, controller.frameTime, true)
+		controller.ruleset.UpdatePostFor(cursor, controller.frameTime, true)
+
+		wasUpdated = true
+
+		controller.frameIndex++
+	}
+
+	if !wasUpdated {
+		if !isAutopilot && controller.frameIndex < len(controller.frames) && controller.frameIndex > 0 {
+			next := controller.frames[controller.frameIndex]
+			prev := controller.frames[controller.frameIndex-1]
+
+			progress := min(float32(nTime-float64(controller.frameTime)), float32(next.Time)) / float32(max(next.Time, 1))
+
+			mX := (next.MouseX-prev.MouseX)*progress + prev.MouseX
+			mY := (next.MouseY-prev.MouseY)*progress + prev.MouseY
+
+			cursor.SetPos(vector.NewVec2f(mX, mY))
+		}
+
+		cursor.IsReplayFrame = false
+	}
+
+	if judgeTime := controller.getJudgeTime(int64(nTime)); judgeTime > controller.rulesetTime {
+		controller.ruleset.Update(judgeTime)
+		controller.rulesetTime = judgeTime
+	}
+
+	controller.lastTime = nTime
+}
+
+func (controller *SpectatorController) GetCursors() []*graphics.Cursor {
+	return controller.cursors
+}
+
+func (controller *SpectatorController) GetRuleset() *osu.OsuRuleSet {
+	return controller.ruleset
+}
+
+// Dispose stops the spectator server
+func (controller *SpectatorController) Dispose() {
+	if controller.server != nil {
+		controller.server.Close()
+		controller.server = nil
+	}
+}
@@ -0,0 +1,273 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"github.com/wieku/danser-go/app/stabledb"
+	"log"
+	"strings"
+)
+
+const collectionsTableStmt = `
+		CREATE TABLE IF NOT EXISTS collections (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL, stable INTEGER DEFAULT 0, UNIQUE (name, stable));
+		CREATE TABLE IF NOT EXISTS collectionMaps (collectionID INTEGER NOT NULL, md5 TEXT NOT NULL, UNIQUE (collectionID, md5));`
+
+// Collection is a named group of beatmaps. Collections imported from osu!stable's collection.db are read-only,
+// they are replaced on every import.
+type Collection struct {
+	ID     int64
+	Name   string
+	Stable bool
+	MD5s   []string
+}
+
+// Contains checks whether beatmap with given md5 belongs to the collection
+func (c *Collection) Contains(md5 string) bool {
+	for _, m := range c.MD5s {
+		if strings.EqualFold(m, md5) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetCollections returns all collections, danser's collections go first
+func GetCollections() ([]*Collection, error) {
+	if dbFile == nil {
+		return nil, errors.New("database is not initialized")
+	}
+
+	res, err := dbFile.Query("SELECT c.id, c.name, c.stable, m.md5 FROM collections c LEFT JOIN collectionMaps m ON m.collectionID = c.id ORDER BY c.stable, c.name COLLATE NOCASE, c.id")
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Close()
+
+	var collections []*Collection
+
+	for res.Next() {
+		var id int64
+		var name string
+		var stable bool
+		var md5 *string
+
+		if err = res.Scan(&id, &name, &stable, &md5); err != nil {
+			return nil, err
+		}
+
+		if len(collections) == 0 || collections[len(collections)-1].ID != id {
+			collections = append(collections, &Collection{ID: id, Name: name, Stable: stable})
+		}
+
+		if md5 != nil {
+			c := collections[len(collections)-1]
+			c.MD5s = append(c.MD5s, *md5)
+		}
+	}
+
+	return collections, res.Err()
+}
+
+// GetCollection finds a collection by name, danser's collections take precedence over osu!stable's ones
+func GetCollection(name string) (*Collection, error) {
+	collections, err := GetCollections()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range collections {
+		if c.Name == name {
+			return c, nil
+		}
+	}
+
+	for _, c := range collections {
+		if strings.EqualFold(c.Name, name) {
+			return c, nil
+		}
+	}
+
+	return nil, fmt.Errorf("collection \"%s\" not found", name)
+}
+
+func CreateCollection(name string) (*Collection, error) {
+	if dbFile == nil {
+		return nil, errors.New("database is not initialized")
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("collection name can't be empty")
+	}
+
+	res, err := dbFile.Exec("INSERT INTO collections (name, stable) VALUES (?, 0)", name)
+	if err != nil {
+		return nil, fmt.Errorf("collection \"%s\" already exists", name)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Collection{ID: id, Name: name}, nil
+}
+
+func RenameCollection(c *Collection, name string) error {
+	if err := checkEditable(c); err != nil {
+		return err
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("collection name can't be empty")
+	}
+
+	if _, err := dbFile.Exec("UPDATE collections SET name = ? WHERE id = ?", name, c.ID); err != nil {
+		return fmt.Errorf("collection \"%s\" already exists", name)
+	}
+
+	c.Name = name
+
+	return nil
+}
+
+func DeleteCollection(c *Collection) error {
+	if err := checkEditable(c); err != nil {
+		return err
+	}
+
+	tx, err := dbFile.Begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec("DELETE FROM collectionMaps WHERE collectionID = ?", c.ID); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if _, err = tx.Exec("DELETE FROM collections WHERE id = ?", c.ID); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func AddToCollection(c *Collection, md5 string) error {
+	if err := checkEditable(c); err != nil {
+		return err
+	}
+
+	if c.Contains(md5) {
+		return nil
+	}
+
+	if _, err := dbFile.Exec("INSERT OR IGNORE INTO collectionMaps (collectionID, md5) VALUES (?, ?)", c.ID, md5); err != nil {
+		return err
+	}
+
+	c.MD5s = append(c.MD5s, md5)
+
+	return nil
+}
+
+func RemoveFromCollection(c *Collection, md5 string) error {
+	if err := checkEditable(c); err != nil {
+		return err
+	}
+
+	if _, err := dbFile.Exec("DELETE FROM collectionMaps WHERE collectionID = ? AND md5 = ? COLLATE NOCASE", c.ID, md5); err != nil {
+		return err
+	}
+
+	for i, m := range c.MD5s {
+		if strings.EqualFold(m, md5) {
+			c.MD5s = append(c.MD5s[:i], c.MD5s[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+func checkEditable(c *Collection) error {
+	if dbFile == nil {
+		return errors.New("database is not initialized")
+	}
+
+	if c.Stable {
+		return errors.New("collections imported from osu! can't be edited")
+	}
+
+	return nil
+}
+
+// ImportStableCollections replaces osu!stable's collections in the database with the ones from given collection.db
+func ImportStableCollections(path string) error {
+	if dbFile == nil {
+		return errors.New("database is not initialized")
+	}
+
+	collections, err := stabledb.ReadCollections(path)
+	if err != nil {
+		return err
+	}
+
+	tx, err := dbFile.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.Exec("DELETE FROM collectionMaps WHERE collectionID IN (SELECT id FROM collections WHERE stable = 1)"); err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec("DELETE FROM collections WHERE stable = 1"); err != nil {
+		return err
+	}
+
+	numMaps := 0
+
+	for _, c := range collections {
+		res, err1 := tx.Exec("INSERT OR IGNORE INTO collections (name, stable) VALUES (?, 1)", c.Name)
+		if err = err1; err != nil {
+			return err
+		}
+
+		var id int64
+
+		if affected, _ := res.RowsAffected(); affected == 0 { // osu! allows duplicated names, merge them
+			if err = tx.QueryRow("SELECT id FROM collections WHERE name = ? AND stable = 1", c.Name).Scan(&id); err != nil {
+				return err
+			}
+		} else if id, err = res.LastInsertId(); err != nil {
+			return err
+		}
+
+		for _, md5 := range c.MD5s {
+			if _, err = tx.Exec("INSERT OR IGNORE INTO collectionMaps (collectionID, md5) VALUES (?, ?)", id, md5); err != nil {
+				return err
+			}
+
+			numMaps++
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	log.Println(fmt.Sprintf("DatabaseManager: Imported %d osu! collections with %d beatmaps", len(collections), numMaps))
+
+	return nil
+}
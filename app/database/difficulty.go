@@ -0,0 +1,332 @@
+package database
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/binary"
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/rulesets/osu/performance/pp220930"
+	"github.com/wieku/danser-go/framework/env"
+	"github.com/wieku/danser-go/framework/goroutines"
+	"log"
+	"path/filepath"
+	"sync"
+)
+
+const difficultyTableStmt = `
+		CREATE TABLE IF NOT EXISTS difficulty (md5 TEXT NOT NULL, mods INTEGER NOT NULL, version INTEGER NOT NULL, stars REAL, aim REAL, speed REAL, speedNoteCount REAL, flashlight REAL, sliderFactor REAL, maxCombo INTEGER, steps BLOB, PRIMARY KEY (md5, mods, version));`
+
+// CachedMods are mod combinations for which star rating is calculated in the background.
+// Other mods that don't affect difficulty (like HD without FL) are masked, so HDDT uses DT's entry.
+var CachedMods = []difficulty.Modifier{
+	difficulty.Easy,
+	difficulty.HalfTime,
+	difficulty.HardRock,
+	difficulty.DoubleTime,
+	difficulty.HardRock | difficulty.DoubleTime,
+	difficulty.Flashlight,
+	difficulty.Hidden | difficulty.Flashlight,
+}
+
+var modStarsStop chan struct{}
+var modStarsWait sync.WaitGroup
+
+// stepRecord is the binary layout of a single pp220930.Attributes entry in steps blob
+type stepRecord struct {
+	Total, Aim, Speed, SpeedNoteCount, Flashlight, SliderFactor float64
+
+	ObjectCount, Circles, Sliders, Spinners, MaxCombo int32
+}
+
+func loadModStars(maps []*beatmap.BeatMap) {
+	byMD5 := make(map[string][]*beatmap.BeatMap, len(maps))
+
+	for _, b := range maps {
+		byMD5[b.MD5] = append(byMD5[b.MD5], b)
+	}
+
+	res, err := dbFile.Query("SELECT md5, mods, stars FROM difficulty WHERE version = ?", pp220930.CurrentVersion)
+	if err != nil {
+		log.Println("DatabaseManager: Failed to load star rating cache:", err)
+		return
+	}
+
+	defer res.Close()
+
+	for res.Next() {
+		var md5 string
+		var mods int64
+		var stars float64
+
+		if err = res.Scan(&md5, &mods, &stars); err != nil {
+			log.Println("DatabaseManager: Failed to load star rating cache:", err)
+			return
+		}
+
+		for _, b := range byMD5[md5] {
+			b.ModStars.Set(difficulty.Modifier(mods), stars)
+		}
+	}
+}
+
+// StartModStarRating calculates star rating of CachedMods for maps that don't have them cached yet.
+// Calculations are done in the background and stopped when database is closed.
+func StartModStarRating(maps []*beatmap.BeatMap) {
+	stopModStarRating()
+
+	var toCalculate []*beatmap.BeatMap
+
+	for _, b := range maps {
+		if b.Mode != 0 || b.Stars <= 0 {
+			continue
+		}
+
+		for _, mods := range CachedMods {
+			if _, ok := b.ModStars.Get(mods); !ok {
+				toCalculate = append(toCalculate, b)
+				break
+			}
+		}
+	}
+
+	if len(toCalculate) == 0 {
+		return
+	}
+
+	log.Println("DatabaseManager: Calculating mod star rating for", len(toCalculate), "beatmaps in the background...")
+
+	stop := make(chan struct{})
+	modStarsStop = stop
+
+	modStarsWait.Add(1)
+
+	goroutines.Run(func() {
+		defer modStarsWait.Done()
+
+		type result struct {
+			md5   string
+			mods  difficulty.Modifier
+			attrs pp220930.Attributes
+		}
+
+		var results []result
+
+		flush := func() {
+			if len(results) == 0 {
+				return
+			}
+
+			tx, err := dbFile.Begin()
+			if err != nil {
+				log.Println("DatabaseManager: Failed to save mod star rating:", err)
+				return
+			}
+
+			for _, r := range results {
+				if err = storeAttributes(tx, r.md5, r.mods, r.attrs); err != nil {
+					log.Println("DatabaseManager: Failed to save mod star rating:", err)
+				}
+			}
+
+			if err = tx.Commit(); err != nil {
+				log.Println("DatabaseManager: Failed to save mod star rating:", err)
+			}
+
+			results = results[:0]
+		}
+
+		defer flush()
+
+		for _, b := range toCalculate {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+
+			for mods, attrs := range calculateModStars(b) {
+				b.ModStars.Set(mods, attrs.Total)
+				results = append(results, result{b.MD5, mods, attrs})
+			}
+
+			if len(results) >= 500 { // Commit in batches to not lose progress
+				flush()
+			}
+		}
+
+		log.Println("DatabaseManager: Mod star rating updated!")
+	})
+}
+
+func calculateModStars(bMap *beatmap.BeatMap) (ret map[difficulty.Modifier]pp220930.Attributes) {
+	ret = make(map[difficulty.Modifier]pp220930.Attributes)
+
+	// Song select may use the original at the same time, so objects are parsed in a copy
+	bClone := bMap.Clone()
+
+	defer func() {
+		if err := recover(); err != nil {
+			log.Println("DatabaseManager: Failed to calculate mod star rating of \"", bMap.Dir+"/"+bMap.File, "\":", err)
+		}
+	}()
+
+	beatmap.ParseTimingPointsAndPauses(bClone)
+	beatmap.ParseObjects(bClone, true, false)
+
+	if len(bClone.HitObjects) < 2 {
+		return
+	}
+
+	for _, mods := range CachedMods {
+		if _, ok := bMap.ModStars.Get(mods); ok {
+			continue
+		}
+
+		diff := difficulty.NewDifficulty(bClone.Diff.GetBaseHP(), bClone.Diff.GetBaseCS(), bClone.Diff.GetBaseOD(), bClone.Diff.GetBaseAR())
+		diff.SetMods(mods)
+
+		ret[mods] = pp220930.CalculateSingle(bClone.HitObjects, diff)
+	}
+
+	return
+}
+
+func stopModStarRating() {
+	if modStarsStop != nil {
+		close(modStarsStop)
+		modStarsStop = nil
+	}
+
+	modStarsWait.Wait()
+}
+
+// GetDifficultySteps returns cached successive difficulty attributes (see pp220930.CalculateStep) for given map and mods.
+// Database is opened temporarily if it's closed. Returns nil if attributes are not cached.
+func GetDifficultySteps(md5 string, mods difficulty.Modifier) (steps []pp220930.Attributes) {
+	err := withDatabase(func(db *sql.DB) error {
+		var data []byte
+
+		err := db.QueryRow("SELECT steps FROM difficulty WHERE md5 = ? AND mods = ? AND version = ?", md5, int64(difficulty.GetDiffMaskedMods(mods)), pp220930.CurrentVersion).Scan(&data)
+		if err != nil || len(data) == 0 {
+			return err
+		}
+
+		records := make([]stepRecord, len(data)/binary.Size(stepRecord{}))
+
+		if err = binary.Read(bytes.NewReader(data), binary.LittleEndian, records); err != nil {
+			return err
+		}
+
+		steps = make([]pp220930.Attributes, len(records))
+
+		for i, r := range records {
+			steps[i] = pp220930.Attributes{
+				Total:          r.Total,
+				Aim:            r.Aim,
+				Speed:          r.Speed,
+				SpeedNoteCount: r.SpeedNoteCount,
+				Flashlight:     r.Flashlight,
+				SliderFactor:   r.SliderFactor,
+				ObjectCount:    int(r.ObjectCount),
+				Circles:        int(r.Circles),
+				Sliders:        int(r.Sliders),
+				Spinners:       int(r.Spinners),
+				MaxCombo:       int(r.MaxCombo),
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil && err != sql.ErrNoRows {
+		log.Println("DatabaseManager: Failed to load difficulty attributes:", err)
+	}
+
+	return
+}
+
+// StoreDifficultySteps caches successive difficulty attributes for given map and mods
+func StoreDifficultySteps(md5 string, mods difficulty.Modifier, steps []pp220930.Attributes) {
+	if len(steps) == 0 {
+		return
+	}
+
+	records := make([]stepRecord, len(steps))
+
+	for i, a := range steps {
+		records[i] = stepRecord{
+			Total:          a.Total,
+			Aim:            a.Aim,
+			Speed:          a.Speed,
+			SpeedNoteCount: a.SpeedNoteCount,
+			Flashlight:     a.Flashlight,
+			SliderFactor:   a.SliderFactor,
+			ObjectCount:    int32(a.ObjectCount),
+			Circles:        int32(a.Circles),
+			Sliders:        int32(a.Sliders),
+			Spinners:       int32(a.Spinners),
+			MaxCombo:       int32(a.MaxCombo),
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	_ = binary.Write(buf, binary.LittleEndian, records)
+
+	err := withDatabase(func(db *sql.DB) error {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+
+		if err = storeAttributes(tx, md5, mods, steps[len(steps)-1]); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+
+		if _, err = tx.Exec("UPDATE difficulty SET steps = ? WHERE md5 = ? AND mods = ? AND version = ?", buf.Bytes(), md5, int64(difficulty.GetDiffMaskedMods(mods)), pp220930.CurrentVersion); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+
+		return tx.Commit()
+	})
+
+	if err != nil {
+		log.Println("DatabaseManager: Failed to save difficulty attributes:", err)
+	}
+}
+
+func storeAttributes(tx *sql.Tx, md5 string, mods difficulty.Modifier, attrs pp220930.Attributes) error {
+	_, err := tx.Exec(`INSERT INTO difficulty (md5, mods, version, stars, aim, speed, speedNoteCount, flashlight, sliderFactor, maxCombo) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (md5, mods, version) DO UPDATE SET stars = excluded.stars, aim = excluded.aim, speed = excluded.speed, speedNoteCount = excluded.speedNoteCount, flashlight = excluded.flashlight, sliderFactor = excluded.sliderFactor, maxCombo = excluded.maxCombo`,
+		md5,
+		int64(difficulty.GetDiffMaskedMods(mods)),
+		pp220930.CurrentVersion,
+		attrs.Total,
+		attrs.Aim,
+		attrs.Speed,
+		attrs.SpeedNoteCount,
+		attrs.Flashlight,
+		attrs.SliderFactor,
+		attrs.MaxCombo)
+
+	return err
+}
+
+// withDatabase runs f with the main database if it's open, otherwise danser.db is opened just for f.
+// Tables are expected to be already created by Init earlier in the same run, so the file is never created here.
+func withDatabase(f func(db *sql.DB) error) error {
+	if dbFile != nil {
+		return f(dbFile)
+	}
+
+	db, err := sql.Open("sqlite3", "file:"+filepath.ToSlash(filepath.Join(env.DataDir(), "danser.db"))+"?mode=rw")
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	return f(db)
+}
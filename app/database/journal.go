@@ -0,0 +1,136 @@
+package database
+
+import (
+	"database/sql"
+	"log"
+	"strconv"
+	"time"
+)
+
+const journalTableStmt = `
+		CREATE TABLE IF NOT EXISTS songDirs (dir TEXT NOT NULL PRIMARY KEY, lastModified INTEGER);`
+
+// dirtyDir marks a directory that has to be compared on next scan
+const dirtyDir = -1
+
+// fullCheckInterval is how often .osu files in unmodified directories are compared with the database, editing a file in
+// place doesn't change directory's modification time so such edits made while danser wasn't running are found this way
+const fullCheckInterval = 7 * 24 * time.Hour
+
+// getDirJournal returns modification times of directories in Songs folder recorded during the last scan
+func getDirJournal() map[string]int64 {
+	dirs := make(map[string]int64)
+
+	res, err := dbFile.Query("SELECT dir, lastModified FROM songDirs")
+	if err != nil {
+		log.Println("DatabaseManager: Failed to read change journal:", err)
+		return dirs
+	}
+
+	defer res.Close()
+
+	for res.Next() {
+		var dir string
+		var lastModified int64
+
+		if err = res.Scan(&dir, &lastModified); err == nil {
+			dirs[dir] = lastModified
+		}
+	}
+
+	return dirs
+}
+
+// saveDirJournal replaces the whole journal with modification times from a full scan
+func saveDirJournal(dirs map[string]int64) {
+	tx, err := dbFile.Begin()
+	if err != nil {
+		log.Println("DatabaseManager: Failed to save change journal:", err)
+		return
+	}
+
+	if _, err = tx.Exec("DELETE FROM songDirs"); err != nil {
+		log.Println("DatabaseManager: Failed to save change journal:", err)
+		_ = tx.Rollback()
+
+		return
+	}
+
+	updateDirJournal(tx, dirs)
+}
+
+// updateDirJournal sets modification times of given directories, negative time removes the directory from the journal.
+// Transaction is committed.
+func updateDirJournal(tx *sql.Tx, dirs map[string]int64) {
+	st, err := tx.Prepare("REPLACE INTO songDirs (dir, lastModified) VALUES (?, ?)")
+	if err != nil {
+		log.Println("DatabaseManager: Failed to save change journal:", err)
+		_ = tx.Rollback()
+
+		return
+	}
+
+	delSt, err := tx.Prepare("DELETE FROM songDirs WHERE dir = ?")
+	if err != nil {
+		log.Println("DatabaseManager: Failed to save change journal:", err)
+		_ = st.Close()
+		_ = tx.Rollback()
+
+		return
+	}
+
+	for dir, lastModified := range dirs {
+		if lastModified < 0 {
+			_, err = delSt.Exec(dir)
+		} else {
+			_, err = st.Exec(dir, lastModified)
+		}
+
+		if err != nil {
+			log.Println("DatabaseManager: Failed to save change journal:", err)
+		}
+	}
+
+	_ = st.Close()
+	_ = delSt.Close()
+
+	if err = tx.Commit(); err != nil {
+		log.Println("DatabaseManager: Failed to save change journal:", err)
+	}
+}
+
+// isFullCheckDue returns whether fullCheckInterval has passed since the last scan that compared all .osu files
+func isFullCheckDue() bool {
+	var value string
+
+	if err := dbFile.QueryRow("SELECT value FROM info WHERE key = 'last_full_check'").Scan(&value); err != nil {
+		return true
+	}
+
+	lastCheck, err := strconv.ParseInt(value, 10, 64)
+
+	return err != nil || time.Since(time.UnixMilli(lastCheck)) >= fullCheckInterval
+}
+
+func saveFullCheckTime() {
+	if _, err := dbFile.Exec("REPLACE INTO info (key, value) VALUES ('last_full_check', ?)", strconv.FormatInt(time.Now().UnixMilli(), 10)); err != nil {
+		log.Println("DatabaseManager: Failed to save time of the full check:", err)
+	}
+}
+
+// markDirsDirty removes directories from the journal so they are compared on next start even if danser is closed before they are synced
+func markDirsDirty(dirs []string) {
+	tx, err := dbFile.Begin()
+	if err != nil {
+		log.Println("DatabaseManager: Failed to update change journal:", err)
+		return
+	}
+
+	changes := make(map[string]int64, len(dirs))
+
+	for _, dir := range dirs {
+		changes[dir] = dirtyDir
+	}
+
+	updateDirJournal(tx, changes)
+}
@@ -27,7 +27,7 @@ import (
 
 var dbFile *sql.DB
 
-const databaseVersion = 20220622
+const databaseVersion = 20261019
 
 var currentPreVersion = databaseVersion
 var currentSchemaPreVersion = databaseVersion
@@ -67,6 +67,7 @@ func Init() error {
 		&M20210423{},
 		&M20220605{},
 		&M20220622{},
+		&M20261019{},
 	}
 
 	dbFile, err = sql.Open("sqlite3", filepath.Join(env.DataDir(), "danser.db"))
@@ -78,7 +79,7 @@ func Init() error {
 		CREATE TABLE IF NOT EXISTS beatmaps (dir TEXT, file TEXT, lastModified INTEGER, title TEXT, titleUnicode TEXT, artist TEXT, artistUnicode TEXT, creator TEXT, version TEXT, source TEXT, tags TEXT, cs REAL, ar REAL, sliderMultiplier REAL, sliderTickRate REAL, audioFile TEXT, previewTime INTEGER, sampleSet INTEGER, stackLeniency REAL, mode INTEGER, bg TEXT, md5 TEXT, dateAdded INTEGER, playCount INTEGER, lastPlayed INTEGER, hpdrain REAL, od REAL, stars REAL DEFAULT -1, bpmMin REAL, bpmMax REAL, circles INTEGER, sliders INTEGER, spinners INTEGER, endTime INTEGER, setID INTEGER, mapID INTEGER, starsVersion INTEGER DEFAULT 0, localOffset INTEGER DEFAULT 0);
 		CREATE INDEX IF NOT EXISTS idx ON beatmaps (dir, file);
 		CREATE TABLE IF NOT EXISTS info (key TEXT NOT NULL UNIQUE, value TEXT);
-	`)
+	` + replaysTableStmt + difficultyTableStmt + collectionsTableStmt + stableTableStmt + journalTableStmt)
 
 	if err != nil {
 		return err
@@ -153,13 +154,29 @@ func Init() error {
 }
 
 func LoadBeatmaps(skipDatabaseCheck bool, importListener ImportListener) []*beatmap.BeatMap {
-	var unpackedMaps []string
+	return LoadBeatmapsWithDirs(skipDatabaseCheck, nil, importListener)
+}
+
+// LoadBeatmapsWithDirs works like LoadBeatmaps but given directories of Songs folder are always compared with the database,
+// even with skipDatabaseCheck or if change journal says they weren't modified.
+func LoadBeatmapsWithDirs(skipDatabaseCheck bool, mustCheckDirs []string, importListener ImportListener) []*beatmap.BeatMap {
 	if settings.General.UnpackOszFiles {
-		unpackedMaps = unpackMaps()
+		mustCheckDirs = append(mustCheckDirs, unpackMaps()...)
 	}
 
-	importMaps(skipDatabaseCheck, unpackedMaps, importListener)
+	importMaps(skipDatabaseCheck, mustCheckDirs, importListener)
+
+	stdMaps := loadStdBeatmaps()
+
+	importStableData()
+
+	loadStableInfo(stdMaps)
+	loadLocalBest()
+
+	return stdMaps
+}
 
+func loadStdBeatmaps() []*beatmap.BeatMap {
 	log.Println("DatabaseManager: Loading beatmaps from database...")
 
 	allMaps := loadBeatmapsFromDatabase()
@@ -174,6 +191,8 @@ func LoadBeatmaps(skipDatabaseCheck bool, importListener ImportListener) []*beat
 
 	log.Println("DatabaseManager: Loaded", len(stdMaps), "total.")
 
+	loadModStars(stdMaps)
+
 	return stdMaps
 }
 
@@ -219,16 +238,39 @@ const (
 )
 
 func importMaps(skipDatabaseCheck bool, mustCheckDirs []string, importListener ImportListener) {
-	const workers = 4
-
 	cachedFolders, mapsInDB := getLastModified()
 
+	useJournal := settings.General.ChangeJournal && !skipDatabaseCheck
+
+	var journal map[string]int64
+	if useJournal {
+		journal = getDirJournal()
+	}
+
+	// Directories in the journal are trusted unless it's time to compare their .osu files too
+	fullCheck := !useJournal || len(journal) == 0 || isFullCheckDue()
+
+	// Modification times of directories in Songs folder at the time of the scan, saved to the journal after import
+	dirTimes := make(map[string]int64)
+	unchangedDirs := make(map[string]bool)
+
+	filesInDB := make(map[string]int)
+	for location := range mapsInDB {
+		filesInDB[location.dir]++
+	}
+
 	candidates := make([]mapLocation, 0)
 
 	log.Println(fmt.Sprintf("DatabaseManager: Scanning \"%s\" for .osu files...", songsDir))
 
 	if skipDatabaseCheck {
 		log.Println("DatabaseManager: '-nodbcheck' is active so only new directories will be imported.")
+	} else if useJournal && len(journal) > 0 {
+		if fullCheck {
+			log.Println("DatabaseManager: Change journal is active, directories not modified since the last scan will be checked only by .osu modification times.")
+		} else {
+			log.Println("DatabaseManager: Change journal is active so directories not modified since the last scan will be skipped.")
+		}
 	}
 
 	trySendStatus(importListener, Discovery, 0, 0)
@@ -247,6 +289,20 @@ func importMaps(skipDatabaseCheck bool, mustCheckDirs []string, importListener I
 				}
 			}
 
+			if !skipDatabaseCheck && de.IsDir() && osPathname != songsDir {
+				dirName := filepath.Base(osPathname)
+
+				if stat, err := os.Stat(osPathname); err == nil {
+					dirTimes[dirName] = stat.ModTime().UnixNano() / 1000000
+
+					if lastModified, ok := journal[dirName]; ok && lastModified == dirTimes[dirName] && !slices.Contains(mustCheckDirs, dirName) &&
+						(!fullCheck || isDirUpToDate(osPathname, dirName, mapsInDB, filesInDB[dirName])) {
+						unchangedDirs[dirName] = true
+						return godirwalk.SkipThis
+					}
+				}
+			}
+
 			if strings.HasSuffix(de.Name(), ".osu") {
 				candidates = append(candidates, mapLocation{
 					dir:  filepath.Base(filepath.Dir(osPathname)),
@@ -266,10 +322,68 @@ func importMaps(skipDatabaseCheck bool, mustCheckDirs []string, importListener I
 
 	log.Println("DatabaseManager: Scan complete. Found", len(candidates), "files.")
 
-	if len(candidates) == 0 {
+	if len(candidates) == 0 && len(unchangedDirs) == 0 {
 		return
 	}
 
+	if len(unchangedDirs) > 0 {
+		log.Println("DatabaseManager: Skipped", len(unchangedDirs), "unchanged directories.")
+
+		// Maps in unchanged directories are up-to-date, values left in mapsInDB are removed from database
+		for location := range mapsInDB {
+			if unchangedDirs[location.dir] {
+				delete(mapsInDB, location)
+			}
+		}
+	}
+
+	applyChanges(candidates, mapsInDB, !skipDatabaseCheck, importListener)
+
+	if !skipDatabaseCheck {
+		saveDirJournal(dirTimes)
+
+		if fullCheck {
+			saveFullCheckTime()
+		}
+	}
+}
+
+// isDirUpToDate checks whether the directory has the same .osu files with the same modification times as in the database.
+// Editing a file in place doesn't change directory's modification time, so the journal alone can't tell if it changed.
+func isDirUpToDate(path, dir string, mapsInDB map[mapLocation]int64, filesInDB int) bool {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return false
+	}
+
+	found := 0
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".osu") {
+			continue
+		}
+
+		lastModified, ok := mapsInDB[mapLocation{dir: dir, file: entry.Name()}]
+		if !ok {
+			return false
+		}
+
+		stat, err := os.Stat(filepath.Join(path, entry.Name()))
+		if err != nil || stat.ModTime().UnixNano()/1000000 != lastModified {
+			return false
+		}
+
+		found++
+	}
+
+	return found == filesInDB
+}
+
+// applyChanges imports candidates that are new or have a different modification time than in mapsInDB.
+// If removeLeftovers is true, maps left in mapsInDB that weren't found among candidates are removed from the database.
+func applyChanges(candidates []mapLocation, mapsInDB map[mapLocation]int64, removeLeftovers bool, importListener ImportListener) {
+	const workers = 4
+
 	log.Println("DatabaseManager: Comparing files with database...")
 
 	mapsToImport := make([]mapLocation, 0)
@@ -313,7 +427,7 @@ func importMaps(skipDatabaseCheck bool, mustCheckDirs []string, importListener I
 
 	log.Println("DatabaseManager: Compare complete.")
 
-	if len(mapsInDB) > 0 && !skipDatabaseCheck {
+	if len(mapsInDB) > 0 && removeLeftovers {
 		trySendStatus(importListener, Cleanup, 100, 100)
 
 		log.Println("DatabaseManager: Removing leftover maps from database...")
@@ -827,6 +941,9 @@ func getLastModified() (map[string]uint8, map[mapLocation]int64) {
 }
 
 func Close() {
+	StopWatching()
+	stopModStarRating()
+
 	if dbFile != nil {
 		err := dbFile.Close()
 		if err != nil {
@@ -0,0 +1,358 @@
+package database
+
+import (
+	"archive/zip"
+	"bufio"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"github.com/wieku/danser-go/app/utils"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type PackageStatus string
+
+const (
+	PackageImported  = PackageStatus("imported")
+	PackageNew       = PackageStatus("new") // would be imported, used in dry runs
+	PackageDuplicate = PackageStatus("duplicate")
+	PackageConflict  = PackageStatus("conflict")
+	PackageFailed    = PackageStatus("failed")
+)
+
+// PackageResult describes what happened (or would happen in a dry run) to a single .osz/.olz file
+type PackageResult struct {
+	Path         string
+	SetID        int64
+	Directory    string // directory in Songs folder the package was or would be extracted to
+	Status       PackageStatus
+	Reason       string   // why the package was skipped
+	Conflicting  []string // existing directories with the same set ID
+	Difficulties []string
+}
+
+type packageInfo struct {
+	setID int64
+	md5s  []string
+	diffs []string
+}
+
+var setIDPrefix = regexp.MustCompile(`^(\d+)\s`)
+
+// ExportSet packs all files of given beatmap directory from Songs folder into an .osz file
+func ExportSet(dir, dest string) error {
+	setDir := filepath.Join(songsDir, dir)
+
+	archive := make(map[string]string)
+
+	err := filepath.WalkDir(setDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(setDir, path)
+		if err != nil {
+			return err
+		}
+
+		archive[filepath.ToSlash(rel)] = path
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if len(archive) == 0 {
+		return fmt.Errorf("%s is empty", setDir)
+	}
+
+	return utils.Zip(dest, archive)
+}
+
+// FindPackages expands given paths to .osz and .olz files, directories are searched non-recursively
+func FindPackages(paths []string) (packages []string, err error) {
+	for _, path := range paths {
+		stat, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !stat.IsDir() {
+			packages = append(packages, path)
+			continue
+		}
+
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			if !entry.IsDir() && isPackage(entry.Name()) {
+				packages = append(packages, filepath.Join(path, entry.Name()))
+			}
+		}
+	}
+
+	return
+}
+
+// ImportPackages extracts .osz/.olz files into Songs folder. Packages identical to a set already present in the database
+// or to a package given earlier are skipped as duplicates. Packages with a set ID already present in the database but
+// with different difficulties are reported as conflicts and skipped. If dryRun is true nothing is extracted.
+// Database should be refreshed with LoadBeatmaps first, imported maps are added with LoadBeatmapsWithDirs afterwards.
+func ImportPackages(paths []string, dryRun bool) []*PackageResult {
+	existingSets, existingMD5 := getExistingSets()
+
+	results := make([]*PackageResult, 0, len(paths))
+
+	seenSets := make(map[int64]string)
+	seenMD5 := make(map[string]string)
+	takenDirs := make(map[string]bool)
+
+	for _, path := range paths {
+		result := &PackageResult{
+			Path:   path,
+			Status: PackageFailed,
+		}
+
+		results = append(results, result)
+
+		info, err := readPackage(path)
+		if err != nil {
+			result.Reason = err.Error()
+			continue
+		}
+
+		result.SetID = info.setID
+		result.Difficulties = info.diffs
+
+		if dir := findDuplicate(info.md5s, existingMD5); dir != "" {
+			result.Status = PackageDuplicate
+			result.Directory = dir
+			result.Reason = fmt.Sprintf("all difficulties are already present in \"%s\"", dir)
+
+			continue
+		}
+
+		if other := findDuplicate(info.md5s, seenMD5); other != "" {
+			result.Status = PackageDuplicate
+			result.Reason = fmt.Sprintf("same difficulties as in %s", other)
+
+			continue
+		}
+
+		if info.setID > 0 {
+			if other, ok := seenSets[info.setID]; ok {
+				result.Status = PackageDuplicate
+				result.Reason = fmt.Sprintf("set %d is already imported from %s", info.setID, other)
+
+				continue
+			}
+
+			if dirs, ok := existingSets[info.setID]; ok {
+				result.Status = PackageConflict
+				result.Conflicting = dirs
+				result.Reason = fmt.Sprintf("set %d already exists with different difficulties", info.setID)
+
+				continue
+			}
+		}
+
+		result.Directory = getFreeDirectory(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)), takenDirs)
+		takenDirs[result.Directory] = true
+
+		if dryRun {
+			result.Status = PackageNew
+		} else {
+			log.Println("DatabaseManager: Unpacking", path, "->", filepath.Join(songsDir, result.Directory))
+
+			if _, err = utils.Unzip(path, filepath.Join(songsDir, result.Directory)); err != nil {
+				_ = os.RemoveAll(filepath.Join(songsDir, result.Directory))
+
+				result.Reason = err.Error()
+
+				continue
+			}
+
+			result.Status = PackageImported
+		}
+
+		if info.setID > 0 {
+			seenSets[info.setID] = path
+		}
+
+		for _, hash := range info.md5s {
+			seenMD5[hash] = path
+		}
+	}
+
+	return results
+}
+
+// getExistingSets returns directories of sets with known IDs and directories of all difficulties by md5
+func getExistingSets() (map[int64][]string, map[string]string) {
+	sets := make(map[int64][]string)
+	hashes := make(map[string]string)
+
+	res, err := dbFile.Query("SELECT dir, md5, setID FROM beatmaps")
+	if err != nil {
+		log.Println("DatabaseManager: Failed to read beatmaps:", err)
+		return sets, hashes
+	}
+
+	defer res.Close()
+
+	for res.Next() {
+		var dir, hash string
+		var setID int64
+
+		if err = res.Scan(&dir, &hash, &setID); err != nil {
+			continue
+		}
+
+		hashes[strings.ToLower(hash)] = dir
+
+		if setID > 0 && !slices.Contains(sets[setID], dir) {
+			sets[setID] = append(sets[setID], dir)
+		}
+	}
+
+	return sets, hashes
+}
+
+// findDuplicate returns the source containing all given hashes, empty string otherwise
+func findDuplicate(hashes []string, known map[string]string) string {
+	if len(hashes) == 0 {
+		return ""
+	}
+
+	source, ok := known[hashes[0]]
+	if !ok {
+		return ""
+	}
+
+	for _, hash := range hashes[1:] {
+		if known[hash] != source {
+			return ""
+		}
+	}
+
+	return source
+}
+
+// readPackage reads md5 hashes, difficulty names and set ID of all .osu files in the package
+func readPackage(path string) (*packageInfo, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("not a valid archive: %w", err)
+	}
+
+	defer r.Close()
+
+	info := &packageInfo{
+		setID: -1,
+	}
+
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() || !strings.EqualFold(filepath.Ext(f.Name), ".osu") {
+			continue
+		}
+
+		hash, setID, diff, err := readPackedMap(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
+
+		info.md5s = append(info.md5s, hash)
+		info.diffs = append(info.diffs, diff)
+
+		if setID > 0 {
+			info.setID = setID
+		}
+	}
+
+	if len(info.md5s) == 0 {
+		return nil, fmt.Errorf("no .osu files found")
+	}
+
+	sort.Strings(info.md5s)
+	sort.Strings(info.diffs)
+
+	// osu! names downloaded packages "<setID> <artist> - <title>.osz", old maps may not have BeatmapSetID set
+	if info.setID <= 0 {
+		if match := setIDPrefix.FindStringSubmatch(filepath.Base(path)); match != nil {
+			info.setID, _ = strconv.ParseInt(match[1], 10, 64)
+		}
+	}
+
+	return info, nil
+}
+
+func readPackedMap(f *zip.File) (hash string, setID int64, diff string, err error) {
+	rc, err := f.Open()
+	if err != nil {
+		return
+	}
+
+	defer rc.Close()
+
+	hasher := md5.New()
+
+	scanner := bufio.NewScanner(io.TeeReader(rc, hasher))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if value, ok := strings.CutPrefix(line, "BeatmapSetID:"); ok {
+			setID, _ = strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		} else if value, ok := strings.CutPrefix(line, "Version:"); ok {
+			diff = strings.TrimSpace(value)
+		} else if line == "[HitObjects]" {
+			break
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return
+	}
+
+	// hash the rest of the file
+	if _, err = io.Copy(hasher, rc); err != nil {
+		return
+	}
+
+	hash = hex.EncodeToString(hasher.Sum(nil))
+
+	return
+}
+
+// getFreeDirectory returns a directory name in Songs folder that isn't taken yet, taken holds names reserved by previous packages
+func getFreeDirectory(name string, taken map[string]bool) string {
+	dir := name
+
+	for i := 2; ; i++ {
+		if _, err := os.Stat(filepath.Join(songsDir, dir)); os.IsNotExist(err) && !taken[dir] {
+			return dir
+		}
+
+		dir = fmt.Sprintf("%s (%d)", name, i)
+	}
+}
+
+func isPackage(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".osz" || ext == ".olz"
+}
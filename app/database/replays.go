@@ -0,0 +1,381 @@
+package database
+
+import (
+	"crypto/md5"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"github.com/karrick/godirwalk"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/rplpa"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const replaysTableStmt = `
+		CREATE TABLE IF NOT EXISTS replays (path TEXT NOT NULL UNIQUE, lastModified INTEGER, hash TEXT, beatmapMD5 TEXT, player TEXT, mods INTEGER, score INTEGER, maxCombo INTEGER, count300 INTEGER, count100 INTEGER, count50 INTEGER, countMiss INTEGER, accuracy REAL, date INTEGER);
+		CREATE INDEX IF NOT EXISTS replays_beatmap ON replays (beatmapMD5);
+		CREATE INDEX IF NOT EXISTS replays_hash ON replays (hash);`
+
+// ReplayInfo holds indexed metadata of a replay file. Replay data itself is not stored, it has to be loaded from Path.
+type ReplayInfo struct {
+	Path         string
+	LastModified int64
+	Hash         string
+	BeatmapMD5   string
+	Player       string
+	Mods         difficulty.Modifier
+	Score        int64
+	MaxCombo     int64
+	Count300     int64
+	Count100     int64
+	Count50      int64
+	CountMiss    int64
+	Accuracy     float64
+	Date         time.Time
+}
+
+// ReplayFilter narrows down replays returned by GetReplays and GetLocalScores. Zero values disable given condition.
+type ReplayFilter struct {
+	BeatmapMD5 string
+	Player     string
+
+	// IncludeMods have to be all active, none of ExcludeMods can be active
+	IncludeMods difficulty.Modifier
+	ExcludeMods difficulty.Modifier
+
+	From time.Time
+	To   time.Time
+
+	// TopN limits results to N replays with the highest score
+	TopN int
+}
+
+// IndexReplays scans replayDir and its direct subdirectories for .osr files and updates the replay index.
+// Only new or modified files are parsed, entries of removed files are deleted.
+func IndexReplays(replayDir string) error {
+	if dbFile == nil {
+		return fmt.Errorf("database is not initialized")
+	}
+
+	replayDir, err := filepath.Abs(replayDir)
+	if err != nil {
+		return err
+	}
+
+	indexed, err := getIndexedReplays(replayDir)
+	if err != nil {
+		return err
+	}
+
+	var toIndex []string
+
+	err = godirwalk.Walk(replayDir, &godirwalk.Options{
+		Callback: func(osPathname string, de *godirwalk.Dirent) error {
+			if de.IsDir() && osPathname != replayDir && filepath.Dir(osPathname) != replayDir {
+				return godirwalk.SkipThis
+			}
+
+			if !strings.HasSuffix(de.Name(), ".osr") {
+				return nil
+			}
+
+			stat, err := os.Stat(osPathname)
+			if err != nil {
+				log.Println("DatabaseManager: Failed to read file stats, skipping:", osPathname)
+				return nil
+			}
+
+			lastModified, ok := indexed[osPathname]
+
+			delete(indexed, osPathname)
+
+			if !ok || lastModified != stat.ModTime().UnixMilli() {
+				toIndex = append(toIndex, osPathname)
+			}
+
+			return nil
+		},
+		Unsorted:            true,
+		FollowSymbolicLinks: true,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if len(indexed) > 0 {
+		toRemove := make([]string, 0, len(indexed))
+		for path := range indexed {
+			toRemove = append(toRemove, path)
+		}
+
+		removeReplays(toRemove)
+	}
+
+	if len(toIndex) == 0 {
+		return nil
+	}
+
+	log.Println("DatabaseManager: Indexing", len(toIndex), "new/updated replays...")
+
+	infos := make([]*ReplayInfo, 0, len(toIndex))
+
+	for _, path := range toIndex {
+		info, err := readReplayInfo(path)
+		if err != nil {
+			log.Println(fmt.Sprintf("DatabaseManager: Failed to index \"%s\", skipping. Error: %s", path, err))
+			continue
+		}
+
+		infos = append(infos, info)
+	}
+
+	insertReplays(infos)
+
+	log.Println("DatabaseManager: Indexed", len(infos), "replays.")
+
+	return nil
+}
+
+func readReplayInfo(path string) (*ReplayInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	replay, err := rplpa.ParseReplay(data)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := md5.Sum(data)
+
+	info := &ReplayInfo{
+		Path:         path,
+		LastModified: stat.ModTime().UnixMilli(),
+		Hash:         hex.EncodeToString(hash[:]),
+		BeatmapMD5:   strings.ToLower(replay.BeatmapMD5),
+		Player:       replay.Username,
+		Mods:         difficulty.Modifier(replay.Mods),
+		Score:        int64(replay.Score),
+		MaxCombo:     int64(replay.MaxCombo),
+		Count300:     int64(replay.Count300),
+		Count100:     int64(replay.Count100),
+		Count50:      int64(replay.Count50),
+		CountMiss:    int64(replay.CountMiss),
+		Date:         replay.Timestamp,
+	}
+
+	if total := info.Count300 + info.Count100 + info.Count50 + info.CountMiss; total > 0 {
+		info.Accuracy = float64(info.Count300*300+info.Count100*100+info.Count50*50) / float64(total*300) * 100
+	}
+
+	return info, nil
+}
+
+// GetReplays returns indexed replays matching the filter sorted by score
+func GetReplays(filter ReplayFilter) ([]*ReplayInfo, error) {
+	if dbFile == nil {
+		return nil, fmt.Errorf("database is not initialized")
+	}
+
+	where, args := filter.where()
+
+	query := "SELECT path, lastModified, hash, beatmapMD5, player, mods, score, maxCombo, count300, count100, count50, countMiss, accuracy, date FROM replays" + where + " ORDER BY score DESC"
+
+	if filter.TopN > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.TopN)
+	}
+
+	res, err := dbFile.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Close()
+
+	var replays []*ReplayInfo
+
+	for res.Next() {
+		info := new(ReplayInfo)
+
+		var mods, date int64
+
+		err = res.Scan(
+			&info.Path,
+			&info.LastModified,
+			&info.Hash,
+			&info.BeatmapMD5,
+			&info.Player,
+			&mods,
+			&info.Score,
+			&info.MaxCombo,
+			&info.Count300,
+			&info.Count100,
+			&info.Count50,
+			&info.CountMiss,
+			&info.Accuracy,
+			&date,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		info.Mods = difficulty.Modifier(mods)
+		info.Date = time.UnixMilli(date)
+
+		replays = append(replays, info)
+	}
+
+	return replays, res.Err()
+}
+
+// where builds WHERE clause of the filter, tables using it need beatmapMD5, player, mods and date columns
+func (filter ReplayFilter) where() (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.BeatmapMD5 != "" {
+		conditions = append(conditions, "beatmapMD5 = ?")
+		args = append(args, strings.ToLower(filter.BeatmapMD5))
+	}
+
+	if filter.Player != "" {
+		conditions = append(conditions, "player = ? COLLATE NOCASE")
+		args = append(args, filter.Player)
+	}
+
+	if filter.IncludeMods != difficulty.None {
+		conditions = append(conditions, "mods & ? = ?")
+		args = append(args, int64(filter.IncludeMods), int64(filter.IncludeMods))
+	}
+
+	if filter.ExcludeMods != difficulty.None {
+		conditions = append(conditions, "mods & ? = 0")
+		args = append(args, int64(filter.ExcludeMods))
+	}
+
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "date >= ?")
+		args = append(args, filter.From.UnixMilli())
+	}
+
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "date <= ?")
+		args = append(args, filter.To.UnixMilli())
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+func getIndexedReplays(replayDir string) (map[string]int64, error) {
+	res, err := dbFile.Query("SELECT path, lastModified FROM replays")
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Close()
+
+	replays := make(map[string]int64)
+
+	for res.Next() {
+		var path string
+		var lastModified int64
+
+		if err = res.Scan(&path, &lastModified); err != nil {
+			return nil, err
+		}
+
+		// Keep entries outside of scanned directory untouched
+		if rel, err := filepath.Rel(replayDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+			replays[path] = lastModified
+		}
+	}
+
+	return replays, res.Err()
+}
+
+func insertReplays(infos []*ReplayInfo) {
+	tx, err := dbFile.Begin()
+
+	if err == nil {
+		var st *sql.Stmt
+		st, err = tx.Prepare("REPLACE INTO replays VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+
+		if err == nil {
+			for _, info := range infos {
+				_, err1 := st.Exec(
+					info.Path,
+					info.LastModified,
+					info.Hash,
+					info.BeatmapMD5,
+					info.Player,
+					int64(info.Mods),
+					info.Score,
+					info.MaxCombo,
+					info.Count300,
+					info.Count100,
+					info.Count50,
+					info.CountMiss,
+					info.Accuracy,
+					info.Date.UnixMilli(),
+				)
+
+				if err1 != nil {
+					log.Println(err1)
+				}
+			}
+		} else {
+			panic(err)
+		}
+
+		st.Close()
+		tx.Commit()
+	}
+
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func removeReplays(paths []string) {
+	tx, err := dbFile.Begin()
+
+	if err == nil {
+		st, err := tx.Prepare("DELETE FROM replays WHERE path = ?")
+
+		if err == nil {
+			for _, path := range paths {
+				_, err1 := st.Exec(path)
+
+				if err1 != nil {
+					log.Println(err1)
+				}
+			}
+		} else {
+			panic(err)
+		}
+
+		st.Close()
+		tx.Commit()
+	}
+
+	if err != nil {
+		log.Println(err)
+	}
+}
@@ -0,0 +1,356 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/app/stabledb"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const stableTableStmt = `
+		CREATE TABLE IF NOT EXISTS stableBeatmaps (md5 TEXT NOT NULL PRIMARY KEY, mapID INTEGER, setID INTEGER, status INTEGER, onlineOffset INTEGER, lastPlayed INTEGER);
+		CREATE TABLE IF NOT EXISTS localScores (replayMD5 TEXT, beatmapMD5 TEXT NOT NULL, player TEXT, mode INTEGER, mods INTEGER, score INTEGER, maxCombo INTEGER, count300 INTEGER, count100 INTEGER, count50 INTEGER, countGeki INTEGER, countKatu INTEGER, countMiss INTEGER, perfect INTEGER, accuracy REAL, date INTEGER, onlineID INTEGER, replayFile TEXT);
+		CREATE INDEX IF NOT EXISTS localScores_beatmap ON localScores (beatmapMD5);`
+
+// LocalScore is a score set in osu!stable, imported from scores.db
+type LocalScore struct {
+	ReplayMD5  string
+	BeatmapMD5 string
+	Player     string
+	Mode       int
+	Mods       difficulty.Modifier
+	Score      int64
+	MaxCombo   int64
+	Count300   int64
+	Count100   int64
+	Count50    int64
+	CountGeki  int64
+	CountKatu  int64
+	CountMiss  int64
+	Perfect    bool
+	Accuracy   float64
+	Date       time.Time
+	OnlineID   int64
+
+	// ReplayPath points to the replay in osu!'s Data/r directory, empty if the file doesn't exist
+	ReplayPath string
+}
+
+var localBest map[string]*LocalScore
+
+// importStableData imports osu!stable's collection.db, osu!.db and scores.db if they changed since the last import
+func importStableData() {
+	importStableFile("collection.db", "collections_modified", ImportStableCollections)
+	importStableFile("osu!.db", "osudb_modified", ImportOsuDB)
+	importStableFile("scores.db", "scoresdb_modified", ImportStableScores)
+}
+
+func importStableFile(name, infoKey string, importer func(path string) error) {
+	path := filepath.Join(settings.General.GetOsuDir(), name)
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+
+	modified := strconv.FormatInt(stat.ModTime().UnixNano(), 10)
+
+	var lastModified string
+
+	_ = dbFile.QueryRow("SELECT value FROM info WHERE key = ?", infoKey).Scan(&lastModified)
+
+	if lastModified == modified {
+		return
+	}
+
+	if err = importer(path); err != nil {
+		log.Println(fmt.Sprintf("DatabaseManager: Failed to import %s: %s", name, err))
+		return
+	}
+
+	_, _ = dbFile.Exec("REPLACE INTO info (key, value) VALUES (?, ?)", infoKey, modified)
+}
+
+// ImportOsuDB replaces imported beatmap metadata (ranked status, online offset and ids) with the content of given osu!.db
+func ImportOsuDB(path string) error {
+	if dbFile == nil {
+		return errors.New("database is not initialized")
+	}
+
+	osuDB, err := stabledb.ReadOsuDB(path)
+	if err != nil {
+		return err
+	}
+
+	tx, err := dbFile.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.Exec("DELETE FROM stableBeatmaps"); err != nil {
+		return err
+	}
+
+	st, err := tx.Prepare("REPLACE INTO stableBeatmaps (md5, mapID, setID, status, onlineOffset, lastPlayed) VALUES (?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+
+	defer st.Close()
+
+	for _, b := range osuDB.Beatmaps {
+		if b.MD5 == "" {
+			continue
+		}
+
+		lastPlayed := int64(0)
+		if !b.Unplayed && !b.LastPlayed.IsZero() {
+			lastPlayed = b.LastPlayed.UnixMilli()
+		}
+
+		if _, err = st.Exec(strings.ToLower(b.MD5), b.ID, b.SetID, b.RankedStatus, b.OnlineOffset, lastPlayed); err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	log.Println(fmt.Sprintf("DatabaseManager: Imported %d beatmaps from osu!.db", len(osuDB.Beatmaps)))
+
+	return nil
+}
+
+// ImportStableScores replaces local scores with the ones from given scores.db
+func ImportStableScores(path string) error {
+	if dbFile == nil {
+		return errors.New("database is not initialized")
+	}
+
+	scores, err := stabledb.ReadScores(path)
+	if err != nil {
+		return err
+	}
+
+	tx, err := dbFile.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.Exec("DELETE FROM localScores"); err != nil {
+		return err
+	}
+
+	st, err := tx.Prepare("INSERT INTO localScores VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+
+	defer st.Close()
+
+	for _, s := range scores {
+		accuracy := 0.0
+
+		if total := int64(s.Count300) + int64(s.Count100) + int64(s.Count50) + int64(s.CountMiss); s.Mode == 0 && total > 0 {
+			accuracy = float64(int64(s.Count300)*300+int64(s.Count100)*100+int64(s.Count50)*50) / float64(total*300) * 100
+		}
+
+		_, err = st.Exec(
+			s.ReplayMD5,
+			strings.ToLower(s.BeatmapMD5),
+			s.Player,
+			s.Mode,
+			int64(s.Mods),
+			s.Score,
+			s.MaxCombo,
+			s.Count300,
+			s.Count100,
+			s.Count50,
+			s.CountGeki,
+			s.CountKatu,
+			s.CountMiss,
+			s.Perfect,
+			accuracy,
+			s.Timestamp.UnixMilli(),
+			s.OnlineID,
+			s.ReplayFile(),
+		)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	log.Println(fmt.Sprintf("DatabaseManager: Imported %d local scores from scores.db", len(scores)))
+
+	return nil
+}
+
+// loadStableInfo applies imported osu!.db metadata, beatmap ids are filled only if .osu file doesn't have them
+func loadStableInfo(maps []*beatmap.BeatMap) {
+	byMD5 := make(map[string][]*beatmap.BeatMap, len(maps))
+
+	for _, b := range maps {
+		byMD5[strings.ToLower(b.MD5)] = append(byMD5[strings.ToLower(b.MD5)], b)
+	}
+
+	res, err := dbFile.Query("SELECT md5, mapID, setID, status, onlineOffset FROM stableBeatmaps")
+	if err != nil {
+		log.Println("DatabaseManager: Failed to load osu!.db metadata:", err)
+		return
+	}
+
+	defer res.Close()
+
+	for res.Next() {
+		var md5 string
+		var mapID, setID int64
+		var status, onlineOffset int
+
+		if err = res.Scan(&md5, &mapID, &setID, &status, &onlineOffset); err != nil {
+			log.Println("DatabaseManager: Failed to load osu!.db metadata:", err)
+			return
+		}
+
+		for _, b := range byMD5[md5] {
+			b.RankedStatus = beatmap.RankedStatus(status)
+			b.OnlineOffset = onlineOffset
+
+			if b.ID <= 0 {
+				b.ID = mapID
+			}
+
+			if b.SetID <= 0 {
+				b.SetID = setID
+			}
+		}
+	}
+}
+
+func loadLocalBest() {
+	scores, err := getLocalScores(ReplayFilter{}, false)
+	if err != nil {
+		log.Println("DatabaseManager: Failed to load local scores:", err)
+		return
+	}
+
+	localBest = make(map[string]*LocalScore)
+
+	for _, s := range scores {
+		if _, ok := localBest[s.BeatmapMD5]; !ok && s.Mode == 0 { // Scores are sorted already
+			localBest[s.BeatmapMD5] = s
+		}
+	}
+}
+
+// GetLocalBest returns the best osu!standard score set in osu!stable on given beatmap, nil if there's none
+func GetLocalBest(md5 string) *LocalScore {
+	return localBest[strings.ToLower(md5)]
+}
+
+// GetLocalScores returns scores imported from osu!stable's scores.db matching the filter sorted by score
+func GetLocalScores(filter ReplayFilter) ([]*LocalScore, error) {
+	return getLocalScores(filter, true)
+}
+
+func getLocalScores(filter ReplayFilter, findReplays bool) ([]*LocalScore, error) {
+	if dbFile == nil {
+		return nil, errors.New("database is not initialized")
+	}
+
+	where, args := filter.where()
+
+	query := "SELECT replayMD5, beatmapMD5, player, mode, mods, score, maxCombo, count300, count100, count50, countGeki, countKatu, countMiss, perfect, accuracy, date, onlineID, replayFile FROM localScores" + where + " ORDER BY score DESC"
+
+	if filter.TopN > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.TopN)
+	}
+
+	res, err := dbFile.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Close()
+
+	replayDir := filepath.Join(settings.General.GetOsuDir(), "Data", "r")
+
+	var scores []*LocalScore
+
+	for res.Next() {
+		s := new(LocalScore)
+
+		var mods, date int64
+		var replayFile sql.NullString
+
+		err = res.Scan(
+			&s.ReplayMD5,
+			&s.BeatmapMD5,
+			&s.Player,
+			&s.Mode,
+			&mods,
+			&s.Score,
+			&s.MaxCombo,
+			&s.Count300,
+			&s.Count100,
+			&s.Count50,
+			&s.CountGeki,
+			&s.CountKatu,
+			&s.CountMiss,
+			&s.Perfect,
+			&s.Accuracy,
+			&date,
+			&s.OnlineID,
+			&replayFile,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		s.Mods = difficulty.Modifier(mods)
+		s.Date = time.UnixMilli(date)
+
+		if findReplays && replayFile.String != "" {
+			if path := filepath.Join(replayDir, replayFile.String); fileExists(path) {
+				s.ReplayPath = path
+			}
+		}
+
+		scores = append(scores, s)
+	}
+
+	return scores, res.Err()
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
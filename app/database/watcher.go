@@ -0,0 +1,309 @@
+package database
+
+import (
+	"fmt"
+	"github.com/fsnotify/fsnotify"
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/framework/goroutines"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// syncDelay is how long changes have to settle before they are applied, copying a beatmap set generates a lot of events
+const syncDelay = 3 * time.Second
+
+var watcher *fsnotify.Watcher
+
+// watchMutex guards watcher's state and keeps database open while changes are applied
+var watchMutex sync.Mutex
+
+var pendingDirs map[string]bool
+var pendingOsz bool
+var syncTimer *time.Timer
+var onSync func(beatmaps []*beatmap.BeatMap)
+
+// WatchSongs starts watching Songs folder and beatmap directories in it. Added, removed and edited beatmaps are applied
+// to the database once changes settle, then callback receives all osu!standard beatmaps from the database with updated star rating.
+// Callback is called from a separate goroutine. Does nothing if General.ChangeJournal is disabled or database isn't initialized.
+func WatchSongs(callback func(beatmaps []*beatmap.BeatMap)) {
+	StopWatching()
+
+	if !settings.General.ChangeJournal || dbFile == nil {
+		return
+	}
+
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Println("DatabaseManager: Failed to create file watcher:", err)
+		return
+	}
+
+	if err = w.Add(songsDir); err != nil {
+		log.Println("DatabaseManager: Failed to watch Songs folder:", err)
+		_ = w.Close()
+
+		return
+	}
+
+	entries, err := os.ReadDir(songsDir)
+	if err != nil {
+		log.Println("DatabaseManager: Failed to read Songs folder:", err)
+	}
+
+	failed := 0
+
+	for _, entry := range entries {
+		if isDir(filepath.Join(songsDir, entry.Name())) && w.Add(filepath.Join(songsDir, entry.Name())) != nil {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		log.Println(fmt.Sprintf("DatabaseManager: Failed to watch %d directories, their changes will be found on next start. On Linux fs.inotify.max_user_watches may be too low.", failed))
+	}
+
+	watchMutex.Lock()
+
+	watcher = w
+	onSync = callback
+	pendingDirs = make(map[string]bool)
+	pendingOsz = false
+
+	watchMutex.Unlock()
+
+	log.Println("DatabaseManager: Watching Songs folder for changes...")
+
+	goroutines.Run(func() {
+		for {
+			select {
+			case event, ok := <-w.Events:
+				if !ok {
+					return
+				}
+
+				handleEvent(w, event)
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+
+				log.Println("DatabaseManager: Watcher error:", err)
+			}
+		}
+	})
+}
+
+// StopWatching stops the watcher started by WatchSongs. Changes that weren't applied yet are left marked in the change journal.
+func StopWatching() {
+	watchMutex.Lock()
+
+	w := watcher
+
+	watcher = nil
+	onSync = nil
+
+	if syncTimer != nil {
+		syncTimer.Stop()
+		syncTimer = nil
+	}
+
+	watchMutex.Unlock()
+
+	if w != nil {
+		if err := w.Close(); err != nil {
+			log.Println("DatabaseManager: Failed to close file watcher:", err)
+		}
+	}
+}
+
+func handleEvent(w *fsnotify.Watcher, event fsnotify.Event) {
+	rel, err := filepath.Rel(songsDir, event.Name)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return
+	}
+
+	if settings.General.VerboseImportLogs {
+		log.Println("DatabaseManager: New event:", event.String())
+	}
+
+	dir, inner, _ := strings.Cut(rel, string(os.PathSeparator))
+
+	watchMutex.Lock()
+	defer watchMutex.Unlock()
+
+	if watcher != w {
+		return
+	}
+
+	if inner == "" {
+		if strings.HasSuffix(strings.ToLower(dir), ".osz") {
+			if settings.General.UnpackOszFiles && (event.Has(fsnotify.Create) || event.Has(fsnotify.Write)) {
+				pendingOsz = true
+				scheduleSync(w)
+			}
+
+			return
+		}
+
+		// New directories have to be watched too, removed ones are dropped by fsnotify
+		if event.Has(fsnotify.Create) && isDir(event.Name) {
+			if err = w.Add(event.Name); err != nil {
+				log.Println("DatabaseManager: Failed to watch new directory:", err)
+			}
+		}
+	}
+
+	if !pendingDirs[dir] {
+		pendingDirs[dir] = true
+
+		markDirsDirty([]string{dir})
+	}
+
+	scheduleSync(w)
+}
+
+func scheduleSync(w *fsnotify.Watcher) {
+	if syncTimer != nil {
+		syncTimer.Reset(syncDelay)
+		return
+	}
+
+	syncTimer = time.AfterFunc(syncDelay, func() {
+		syncPending(w)
+	})
+}
+
+func syncPending(w *fsnotify.Watcher) {
+	watchMutex.Lock()
+
+	if watcher != w {
+		watchMutex.Unlock()
+		return
+	}
+
+	syncTimer = nil
+
+	dirs := make([]string, 0, len(pendingDirs))
+
+	for dir := range pendingDirs {
+		dirs = append(dirs, dir)
+	}
+
+	pendingDirs = make(map[string]bool)
+
+	if pendingOsz {
+		pendingOsz = false
+
+		for _, dir := range unpackMaps() {
+			if !slices.Contains(dirs, dir) {
+				dirs = append(dirs, dir)
+			}
+
+			_ = w.Add(filepath.Join(songsDir, dir))
+		}
+	}
+
+	syncDirs(dirs)
+
+	stdMaps := loadStdBeatmaps()
+
+	loadStableInfo(stdMaps)
+	loadLocalBest()
+
+	// Database can't be closed while the lock is held
+	UpdateStarRating(stdMaps, nil)
+	StartModStarRating(stdMaps)
+
+	callback := onSync
+
+	watchMutex.Unlock()
+
+	if callback != nil {
+		callback(stdMaps)
+	}
+}
+
+// syncDirs applies changes in given directories of Songs folder to the database and the change journal
+func syncDirs(dirs []string) {
+	log.Println("DatabaseManager: Applying changes in", len(dirs), "directories...")
+
+	toSync := make(map[string]bool, len(dirs))
+	for _, dir := range dirs {
+		toSync[dir] = true
+	}
+
+	_, allInDB := getLastModified()
+
+	mapsInDB := make(map[mapLocation]int64)
+
+	for location, lastModified := range allInDB {
+		if toSync[location.dir] {
+			mapsInDB[location] = lastModified
+		}
+	}
+
+	candidates := make([]mapLocation, 0)
+	dirTimes := make(map[string]int64, len(dirs))
+
+	for _, dir := range dirs {
+		dirPath := filepath.Join(songsDir, dir)
+
+		stat, err := os.Stat(dirPath)
+		if os.IsNotExist(err) || (err == nil && !stat.IsDir()) {
+			dirTimes[dir] = dirtyDir // removed from the journal
+			continue
+		}
+
+		var entries []os.DirEntry
+		if err == nil {
+			entries, err = os.ReadDir(dirPath)
+		}
+
+		if err != nil {
+			log.Println(fmt.Sprintf("DatabaseManager: Failed to read \"%s\", skipping. Error: %s", dir, err))
+
+			// Keep maps in database, directory will be compared again on next start
+			for location := range mapsInDB {
+				if location.dir == dir {
+					delete(mapsInDB, location)
+				}
+			}
+
+			dirTimes[dir] = dirtyDir
+
+			continue
+		}
+
+		dirTimes[dir] = stat.ModTime().UnixNano() / 1000000
+
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".osu") {
+				candidates = append(candidates, mapLocation{
+					dir:  dir,
+					file: entry.Name(),
+				})
+			}
+		}
+	}
+
+	applyChanges(candidates, mapsInDB, true, nil)
+
+	tx, err := dbFile.Begin()
+	if err != nil {
+		log.Println("DatabaseManager: Failed to update change journal:", err)
+		return
+	}
+
+	updateDirJournal(tx, dirTimes)
+}
+
+func isDir(path string) bool {
+	stat, err := os.Stat(path)
+	return err == nil && stat.IsDir()
+}
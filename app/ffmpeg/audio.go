@@ -28,6 +28,39 @@ var audioWriteQueue chan []byte
 var endSyncAudio *sync.WaitGroup
 
 func startAudio(audioFPS float64) {
+	if !isStreaming() { // Audio pipe is already created by video process when streaming
+		startAudioProcess()
+	}
+
+	audioBufSize := bass.GetMixerRequiredBufferSize(1 / audioFPS)
+
+	audioPool = make(chan []byte, MaxAudioBuffers)
+
+	for i := 0; i < MaxAudioBuffers; i++ {
+		audioPool <- make([]byte, audioBufSize)
+	}
+
+	audioWriteQueue = make(chan []byte, MaxAudioBuffers)
+
+	endSyncAudio = &sync.WaitGroup{}
+	endSyncAudio.Add(1)
+
+	goroutines.RunOS(func() {
+		for data := range audioWriteQueue {
+			if _, err := audioPipe.Write(data); err != nil {
+				panic(fmt.Sprintf("ffmpeg's audio process finished abruptly! Please check if you have enough storage or audio parameters are entered correctly. Error: %s", err))
+			}
+
+			audioPool <- data
+		}
+
+		endSyncAudio.Done()
+	})
+
+	startStems(audioBufSize)
+}
+
+func startAudioProcess() {
 	inputName := "-"
 
 	if runtime.GOOS != "windows" {
@@ -53,26 +86,15 @@ func startAudio(audioFPS float64) {
 		"-vn",
 	}
 
-	audioFilters := strings.TrimSpace(settings.Recording.AudioFilters)
-	if len(audioFilters) > 0 {
-		options = append(options, "-af", audioFilters)
-	}
-
-	options = append(options, "-c:a", settings.Recording.AudioCodec, "-strict", "-2")
-
-	encOptions, err := settings.Recording.GetAudioOptions().GenerateFFmpegArgs()
-	if err != nil {
-		panic(fmt.Sprintf("encoder \"%s\": %s", settings.Recording.AudioCodec, err))
-	} else if encOptions != nil {
-		options = append(options, encOptions...)
-	}
-
+	options = append(options, getAudioEncoderOptions()...)
 	options = append(options, filepath.Join(settings.Recording.GetOutputDir(), output+"_temp", "audio."+settings.Recording.Container))
 
 	log.Println("Running ffmpeg with options:", options)
 
 	cmdAudio = exec.Command(ffmpegExec, options...)
 
+	var err error
+
 	if runtime.GOOS == "windows" {
 		audioPipe, err = cmdAudio.StdinPipe()
 		if err != nil {
@@ -89,31 +111,24 @@ func startAudio(audioFPS float64) {
 	if err != nil {
 		panic(fmt.Sprintf("ffmpeg's audio process failed to start! Please check if audio parameters are entered correctly or audio codec is supported by provided container. Error: %s", err))
 	}
+}
 
-	audioBufSize := bass.GetMixerRequiredBufferSize(1 / audioFPS)
-
-	audioPool = make(chan []byte, MaxAudioBuffers)
-
-	for i := 0; i < MaxAudioBuffers; i++ {
-		audioPool <- make([]byte, audioBufSize)
+func getAudioEncoderOptions() (options []string) {
+	audioFilters := strings.TrimSpace(settings.Recording.AudioFilters)
+	if len(audioFilters) > 0 {
+		options = append(options, "-af", audioFilters)
 	}
 
-	audioWriteQueue = make(chan []byte, MaxAudioBuffers)
-
-	endSyncAudio = &sync.WaitGroup{}
-	endSyncAudio.Add(1)
-
-	goroutines.RunOS(func() {
-		for data := range audioWriteQueue {
-			if _, err := audioPipe.Write(data); err != nil {
-				panic(fmt.Sprintf("ffmpeg's audio process finished abruptly! Please check if you have enough storage or audio parameters are entered correctly. Error: %s", err))
-			}
+	options = append(options, "-c:a", settings.Recording.AudioCodec, "-strict", "-2")
 
-			audioPool <- data
-		}
+	encOptions, err := settings.Recording.GetAudioOptions().GenerateFFmpegArgs()
+	if err != nil {
+		panic(fmt.Sprintf("encoder \"%s\": %s", settings.Recording.AudioCodec, err))
+	} else if encOptions != nil {
+		options = append(options, encOptions...)
+	}
 
-		endSyncAudio.Done()
-	})
+	return
 }
 
 func stopAudio() {
@@ -125,17 +140,25 @@ func stopAudio() {
 
 	_ = audioPipe.Close()
 
-	log.Println("Audio pipe closed. Waiting for audio ffmpeg process to finish...")
+	if cmdAudio != nil {
+		log.Println("Audio pipe closed. Waiting for audio ffmpeg process to finish...")
+
+		_ = cmdAudio.Wait()
 
-	_ = cmdAudio.Wait()
+		log.Println("Audio process finished.")
+	}
 
-	log.Println("Audio process finished.")
+	stopStems()
 }
 
 func PushAudio() {
 	data := <-audioPool
 
-	bass.ProcessMixer(data)
+	if stemWriters != nil {
+		pushStems(data)
+	} else {
+		bass.ProcessMixer(data)
+	}
 
 	audioWriteQueue <- data
 }
@@ -0,0 +1,82 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/framework/bass"
+	"github.com/wieku/danser-go/framework/files"
+	"log"
+	"os"
+	"os/exec"
+)
+
+// EncodeMixer pulls given amount of seconds from BASS's offscreen mixer and encodes it to an mp3 file. BASS has to be initialized in offscreen mode.
+func EncodeMixer(path string, seconds float64) error {
+	execPath, err := files.GetCommandExec("ffmpeg", "ffmpeg")
+	if err != nil {
+		return fmt.Errorf("ffmpeg not found: %w", err)
+	}
+
+	options := []string{
+		"-y",
+
+		"-f", "f32le",
+		"-acodec", "pcm_f32le",
+		"-ar", "48000",
+		"-ac", "2",
+		"-i", "-",
+
+		"-nostats",
+		"-vn",
+		"-c:a", "libmp3lame",
+		"-b:a", "192k",
+		path,
+	}
+
+	log.Println("Running ffmpeg with options:", options)
+
+	cmd := exec.Command(execPath, options...)
+
+	pipe, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+
+	if settings.Recording.ShowFFmpegLogs {
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+	}
+
+	if err = cmd.Start(); err != nil {
+		return fmt.Errorf("ffmpeg process failed to start: %w", err)
+	}
+
+	chunkSize := bass.GetMixerRequiredBufferSize(1)
+	total := bass.GetMixerRequiredBufferSize(seconds)
+
+	buffer := make([]byte, chunkSize)
+
+	for written := 0; written < total; {
+		size := min(chunkSize, total-written)
+		size -= size % 8 // whole stereo float frames
+
+		if size == 0 {
+			break
+		}
+
+		bass.ProcessMixer(buffer[:size])
+
+		if _, err = pipe.Write(buffer[:size]); err != nil {
+			_ = pipe.Close()
+			_ = cmd.Wait()
+
+			return fmt.Errorf("ffmpeg process finished abruptly: %w", err)
+		}
+
+		written += size
+	}
+
+	_ = pipe.Close()
+
+	return cmd.Wait()
+}
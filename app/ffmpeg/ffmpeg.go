@@ -82,13 +82,17 @@ func StartFFmpeg(fps, _w, _h int, audioFPS float64, _output string) {
 
 	output = _output
 
-	log.Println("Starting encoding!")
+	if isStreaming() {
+		log.Println("Starting streaming to:", settings.STREAM)
+	} else {
+		log.Println("Starting encoding!")
 
-	_ = os.RemoveAll(filepath.Join(settings.Recording.GetOutputDir(), output+"_temp"))
+		_ = os.RemoveAll(filepath.Join(settings.Recording.GetOutputDir(), output+"_temp"))
 
-	err := os.MkdirAll(filepath.Join(settings.Recording.GetOutputDir(), output+"_temp"), 0755)
-	if err != nil && !os.IsExist(err) {
-		panic(err)
+		err := os.MkdirAll(filepath.Join(settings.Recording.GetOutputDir(), output+"_temp"), 0755)
+		if err != nil && !os.IsExist(err) {
+			panic(err)
+		}
 	}
 
 	startVideo(fps, _w, _h)
@@ -98,6 +102,16 @@ func StartFFmpeg(fps, _w, _h int, audioFPS float64, _output string) {
 func StopFFmpeg() {
 	log.Println("Finishing rendering...")
 
+	if isStreaming() {
+		// Stream process waits for both inputs to end, so audio has to be closed first
+		stopAudio()
+		stopVideo()
+
+		log.Println("Stream finished.")
+
+		return
+	}
+
 	stopVideo()
 	stopAudio()
 
@@ -106,6 +120,20 @@ func StopFFmpeg() {
 	combine()
 }
 
+// GetOutputPath returns the path of the final video file or stream url of current or last recording
+func GetOutputPath() string {
+	if isStreaming() {
+		return settings.STREAM
+	}
+
+	return filepath.Join(settings.Recording.GetOutputDir(), output+"."+settings.Recording.Container)
+}
+
+// GetOutputName returns the name of current or last recording, without extension
+func GetOutputName() string {
+	return output
+}
+
 func combine() {
 	options := []string{
 		"-y",
@@ -119,7 +147,7 @@ func combine() {
 		options = append(options, "-movflags", "+faststart")
 	}
 
-	finalOutputPath := filepath.Join(settings.Recording.GetOutputDir(), output+"."+settings.Recording.Container)
+	finalOutputPath := GetOutputPath()
 
 	options = append(options, finalOutputPath)
 
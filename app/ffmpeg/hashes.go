@@ -0,0 +1,64 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"fmt"
+	"github.com/wieku/danser-go/app/settings"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+var hashFile *os.File
+var hashWriter *bufio.Writer
+var hashFrame int64
+
+// startHashes opens the frame hash file if deterministic mode is enabled
+func startHashes() {
+	if !settings.DETERMINISTIC {
+		return
+	}
+
+	var err error
+
+	hashFile, err = os.Create(getHashesPath())
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create frame hash file! Error: %s", err))
+	}
+
+	hashWriter = bufio.NewWriter(hashFile)
+	hashFrame = 0
+}
+
+// writeHash saves SHA-1 of frame's raw data, has to be called in submission order
+func writeHash(data []byte) {
+	if hashWriter == nil {
+		return
+	}
+
+	_, _ = fmt.Fprintf(hashWriter, "%d %x\n", hashFrame, sha1.Sum(data))
+
+	hashFrame++
+}
+
+func stopHashes() {
+	if hashWriter == nil {
+		return
+	}
+
+	if err := hashWriter.Flush(); err != nil {
+		log.Println("Failed to write frame hashes:", err)
+	}
+
+	_ = hashFile.Close()
+
+	hashWriter = nil
+	hashFile = nil
+
+	log.Println(fmt.Sprintf("Frame hashes (%d frames) saved to: %s", hashFrame, getHashesPath()))
+}
+
+func getHashesPath() string {
+	return filepath.Join(settings.Recording.GetOutputDir(), output+".hashes.txt")
+}
@@ -0,0 +1,178 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/framework/bass"
+	"github.com/wieku/danser-go/framework/files"
+	"github.com/wieku/danser-go/framework/goroutines"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+type stemWriter struct {
+	stem bass.Stem
+
+	cmd  *exec.Cmd
+	pipe io.WriteCloser
+
+	pool       chan []byte
+	writeQueue chan []byte
+	endSync    *sync.WaitGroup
+
+	outputPath string
+}
+
+var stemWriters []*stemWriter
+var stemBuffers [][]byte
+
+func startStems(bufSize int) {
+	if !bass.StemsEnabled() {
+		return
+	}
+
+	format := strings.ToLower(settings.Recording.AudioStems.Format)
+
+	codec := "pcm_s24le"
+	if format == "flac" {
+		codec = "flac"
+	} else {
+		format = "wav"
+	}
+
+	stemWriters = make([]*stemWriter, bass.StemCount)
+	stemBuffers = make([][]byte, bass.StemCount)
+
+	for i := range stemWriters {
+		stem := bass.Stem(i)
+
+		writer := &stemWriter{
+			stem:       stem,
+			outputPath: filepath.Join(settings.Recording.GetOutputDir(), output+"_"+stem.String()+"."+format),
+		}
+
+		writer.start(codec, bufSize)
+
+		stemWriters[i] = writer
+	}
+}
+
+func (writer *stemWriter) start(codec string, bufSize int) {
+	inputName := "-"
+
+	if runtime.GOOS != "windows" {
+		pipe, err := files.NewNamedPipe("")
+		if err != nil {
+			panic(err)
+		}
+
+		inputName = pipe.Name()
+		writer.pipe = pipe
+	}
+
+	options := []string{
+		"-y",
+
+		"-f", "f32le",
+		"-acodec", "pcm_f32le",
+		"-ar", "48000",
+		"-ac", "2",
+		"-i", inputName,
+
+		"-nostats",
+		"-vn",
+		"-c:a", codec,
+		writer.outputPath,
+	}
+
+	log.Println("Running ffmpeg with options:", options)
+
+	writer.cmd = exec.Command(ffmpegExec, options...)
+
+	var err error
+
+	if runtime.GOOS == "windows" {
+		writer.pipe, err = writer.cmd.StdinPipe()
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	if settings.Recording.ShowFFmpegLogs {
+		writer.cmd.Stdout = os.Stdout
+		writer.cmd.Stderr = os.Stderr
+	}
+
+	err = writer.cmd.Start()
+	if err != nil {
+		panic(fmt.Sprintf("ffmpeg's %s stem process failed to start! Error: %s", writer.stem, err))
+	}
+
+	writer.pool = make(chan []byte, MaxAudioBuffers)
+
+	for i := 0; i < MaxAudioBuffers; i++ {
+		writer.pool <- make([]byte, bufSize)
+	}
+
+	writer.writeQueue = make(chan []byte, MaxAudioBuffers)
+
+	writer.endSync = &sync.WaitGroup{}
+	writer.endSync.Add(1)
+
+	goroutines.RunOS(func() {
+		for data := range writer.writeQueue {
+			if _, err := writer.pipe.Write(data); err != nil {
+				panic(fmt.Sprintf("ffmpeg's %s stem process finished abruptly! Please check if you have enough storage. Error: %s", writer.stem, err))
+			}
+
+			writer.pool <- data
+		}
+
+		writer.endSync.Done()
+	})
+}
+
+func (writer *stemWriter) stop() {
+	close(writer.writeQueue)
+
+	writer.endSync.Wait()
+
+	_ = writer.pipe.Close()
+
+	_ = writer.cmd.Wait()
+
+	log.Println(fmt.Sprintf("Audio stem \"%s\" is available at: %s", writer.stem, writer.outputPath))
+}
+
+func stopStems() {
+	if stemWriters == nil {
+		return
+	}
+
+	log.Println("Stopping audio stem pipes...")
+
+	for _, writer := range stemWriters {
+		writer.stop()
+	}
+
+	stemWriters = nil
+	stemBuffers = nil
+}
+
+func pushStems(data []byte) {
+	for i, writer := range stemWriters {
+		stemBuffers[i] = <-writer.pool
+	}
+
+	bass.ProcessStems(data, stemBuffers)
+
+	for i, writer := range stemWriters {
+		writer.writeQueue <- stemBuffers[i]
+	}
+}
@@ -0,0 +1,58 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/framework/files"
+	"net/url"
+	"strings"
+)
+
+func isStreaming() bool {
+	return settings.STREAM != ""
+}
+
+// getStreamFormat returns ffmpeg's muxer suitable for given streaming url
+func getStreamFormat() string {
+	sURL, err := url.Parse(settings.STREAM)
+	if err != nil {
+		panic(fmt.Sprintf("Invalid stream url \"%s\": %s", settings.STREAM, err))
+	}
+
+	switch strings.ToLower(sURL.Scheme) {
+	case "rtmp", "rtmps":
+		return "flv"
+	case "srt", "udp", "tcp":
+		return "mpegts"
+	}
+
+	panic(fmt.Sprintf("Unsupported stream protocol \"%s\", only rtmp, rtmps, srt, udp and tcp are supported", sURL.Scheme))
+}
+
+// getStreamAudioInput creates audio pipe consumed by the video process and returns ffmpeg's input options for it.
+// Named pipes are used on all platforms because video process already reads frames through stdin on windows.
+func getStreamAudioInput() []string {
+	pipe, err := files.NewNamedPipe("")
+	if err != nil {
+		panic(err)
+	}
+
+	audioPipe = pipe
+	cmdAudio = nil
+
+	return []string{
+		"-f", "f32le",
+		"-acodec", "pcm_f32le",
+		"-ar", "48000",
+		"-ac", "2",
+		"-i", pipe.Name(),
+	}
+}
+
+// getStreamOutput returns ffmpeg's audio encoding and output options for streaming
+func getStreamOutput() []string {
+	options := []string{"-map", "0:v", "-map", "1:a"}
+	options = append(options, getAudioEncoderOptions()...)
+
+	return append(options, "-f", getStreamFormat(), settings.STREAM)
+}
@@ -152,16 +152,25 @@ func startVideo(fps, _w, _h int) {
 		"-pix_fmt", inputPixFmt,
 		"-r", strconv.Itoa(fps), //frames per second
 		"-i", inputName, //The input comes from a videoPipe
+	}
 
-		"-an",
+	if isStreaming() { // Audio is muxed directly by video process
+		options = append(options, getStreamAudioInput()...)
+	} else {
+		options = append(options, "-an")
+	}
 
-		"-vf", "vflip" + videoFilters,
+	options = append(options,
+		"-vf", "vflip"+videoFilters,
 		"-c:v", encoder,
 		"-color_range", "1",
 		"-colorspace", "1",
 		"-color_trc", "1",
 		"-color_primaries", "1",
-		"-movflags", "+write_colr",
+	)
+
+	if !isStreaming() {
+		options = append(options, "-movflags", "+write_colr")
 	}
 
 	if parsedFormat == pixconv.ARGB {
@@ -175,7 +184,11 @@ func startVideo(fps, _w, _h int) {
 		options = append(options, encOptions...)
 	}
 
-	options = append(options, filepath.Join(settings.Recording.GetOutputDir(), output+"_temp", "video."+settings.Recording.Container))
+	if isStreaming() {
+		options = append(options, getStreamOutput()...)
+	} else {
+		options = append(options, filepath.Join(settings.Recording.GetOutputDir(), output+"_temp", "video."+settings.Recording.Container))
+	}
 
 	log.Println("Running ffmpeg with options:", options)
 
@@ -211,6 +224,12 @@ func startVideo(fps, _w, _h int) {
 
 	freePBOPool = make(chan *PBO, MaxVideoBuffers)
 
+	frameNumber = -1
+	frameReadQueue = frameReadQueue[:0]
+
+	rgbToYuvConverter = nil
+	blend = nil
+
 	mainthread.Call(func() {
 		if parsedFormat != pixconv.ARGB {
 			rgbToYuvConverter = effects.NewRGBYUV(w, h, parsedFormat != pixconv.I444 && parsedFormat != pixconv.I422)
@@ -228,7 +247,13 @@ func startVideo(fps, _w, _h int) {
 
 	videoWriteQueue = make(chan *PBO, MaxVideoBuffers)
 
-	limiter = frame.NewLimiter(settings.Recording.EncodingFPSCap)
+	startHashes()
+
+	if isStreaming() { // Pace rendering to real time
+		limiter = frame.NewLimiter(fps)
+	} else {
+		limiter = frame.NewLimiter(settings.Recording.EncodingFPSCap)
+	}
 
 	videoErrorWait = &sync.WaitGroup{}
 	videoErrorWait.Add(1)
@@ -272,6 +297,8 @@ func startVideo(fps, _w, _h int) {
 		for pbo := range videoWriteQueue {
 			pbo.convertSync.Wait() // Wait for conversion to end
 
+			writeHash(pbo.convData)
+
 			if _, err := videoPipe.Write(pbo.convData); err != nil {
 				errorMsg := err.Error()
 
@@ -300,8 +327,12 @@ func stopVideo() {
 
 	endSyncVideo.Wait()
 
+	stopHashes()
+
 	log.Println("Finished! Stopping video pipe...")
 
+	disposePBOs()
+
 	_ = videoPipe.Close()
 
 	log.Println("Video pipe closed. Waiting for video ffmpeg process to finish...")
@@ -311,6 +342,15 @@ func stopVideo() {
 	log.Println("Video process finished.")
 }
 
+func disposePBOs() {
+	for len(freePBOPool) > 0 {
+		pbo := <-freePBOPool
+
+		gl.UnmapNamedBuffer(pbo.handle)
+		gl.DeleteBuffers(1, &pbo.handle)
+	}
+}
+
 func PreFrame() {
 	if settings.Recording.MotionBlur.Enabled {
 		blend.Begin()
@@ -0,0 +1,199 @@
+package knockout
+
+import (
+	"cmp"
+	"fmt"
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/rulesets/osu/performance/pp220930"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/rplpa"
+	"slices"
+	"strings"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// Rules describe which replays are picked for classic knockout. Zero values disable given rule.
+type Rules struct {
+	// SortBy is one of Score, PP or Accuracy, used for ranking replays and TopN
+	SortBy string
+
+	TopN int
+
+	// BestPerPlayer keeps only the highest ranked replay of each player
+	BestPerPlayer bool
+
+	// IncludeMods have to be all active, none of ExcludeMods can be active
+	IncludeMods difficulty.Modifier
+	ExcludeMods difficulty.Modifier
+
+	From time.Time
+	To   time.Time
+
+	MinCombo int64
+}
+
+// RulesFromSettings creates Rules from Knockout.Selection settings. Legacy Knockout.ExcludeMods are merged with excluded mods.
+func RulesFromSettings() (rules Rules, err error) {
+	selection := settings.Knockout.Selection
+
+	rules = Rules{
+		SortBy:        selection.SortBy,
+		TopN:          selection.TopN,
+		BestPerPlayer: selection.BestPerPlayer,
+		IncludeMods:   difficulty.ParseMods(selection.IncludeMods),
+		ExcludeMods:   difficulty.ParseMods(selection.ExcludeMods) | difficulty.ParseMods(settings.Knockout.ExcludeMods),
+		MinCombo:      int64(selection.MinCombo),
+	}
+
+	if rules.From, err = ParseDate(selection.From, false); err != nil {
+		return
+	}
+
+	rules.To, err = ParseDate(selection.To, true)
+
+	return
+}
+
+// ParseDate parses YYYY-MM-DD date in local time, empty string returns zero time. If endOfDay is set, returned time points at the last millisecond of that day.
+func ParseDate(date string, endOfDay bool) (time.Time, error) {
+	date = strings.TrimSpace(date)
+	if date == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.ParseInLocation(dateLayout, date, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date \"%s\", expected YYYY-MM-DD format", date)
+	}
+
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Millisecond)
+	}
+
+	return t, nil
+}
+
+// Candidate is a replay considered for knockout. PP is filled only by CalculatePP.
+type Candidate struct {
+	Path     string
+	Replay   *rplpa.Replay
+	Accuracy float64
+	PP       float64
+}
+
+func NewCandidate(path string, replay *rplpa.Replay) *Candidate {
+	candidate := &Candidate{
+		Path:   path,
+		Replay: replay,
+	}
+
+	total := int(replay.Count300) + int(replay.Count100) + int(replay.Count50) + int(replay.CountMiss)
+	if total > 0 {
+		candidate.Accuracy = float64(int(replay.Count300)*300+int(replay.Count100)*100+int(replay.Count50)*50) / float64(total*300) * 100
+	}
+
+	return candidate
+}
+
+// CalculatePP computes pp of candidates from their hit counts. Objects of bMap have to be already parsed.
+func CalculatePP(bMap *beatmap.BeatMap, candidates []*Candidate) {
+	if len(bMap.HitObjects) == 0 {
+		return
+	}
+
+	attributes := make(map[difficulty.Modifier]pp220930.Attributes)
+
+	for _, c := range candidates {
+		mods := difficulty.Modifier(c.Replay.Mods)
+
+		diff := difficulty.NewDifficulty(bMap.Diff.GetBaseHP(), bMap.Diff.GetBaseCS(), bMap.Diff.GetBaseOD(), bMap.Diff.GetBaseAR())
+		diff.SetMods(mods)
+
+		maskedMods := difficulty.GetDiffMaskedMods(mods)
+
+		attribs, ok := attributes[maskedMods]
+		if !ok {
+			attribs = pp220930.CalculateSingle(bMap.HitObjects, diff)
+			attributes[maskedMods] = attribs
+		}
+
+		pp := &pp220930.PPv2{}
+		pp.PPv2x(attribs, int(c.Replay.MaxCombo), int(c.Replay.Count300), int(c.Replay.Count100), int(c.Replay.Count50), int(c.Replay.CountMiss), diff)
+
+		c.PP = pp.Results.Total
+	}
+}
+
+// Select filters candidates by the rules and returns them sorted from the best one. PP has to be calculated beforehand if rules sort by PP.
+func Select(candidates []*Candidate, rules Rules) []*Candidate {
+	selected := make([]*Candidate, 0, len(candidates))
+
+	for _, c := range candidates {
+		if rules.Matches(c) {
+			selected = append(selected, c)
+		}
+	}
+
+	slices.SortStableFunc(selected, func(a, b *Candidate) int {
+		return -cmp.Compare(rules.rankValue(a), rules.rankValue(b))
+	})
+
+	if rules.BestPerPlayer {
+		players := make(map[string]bool)
+
+		selected = slices.DeleteFunc(selected, func(c *Candidate) bool {
+			name := strings.ToLower(c.Replay.Username)
+
+			if players[name] {
+				return true
+			}
+
+			players[name] = true
+
+			return false
+		})
+	}
+
+	if rules.TopN > 0 {
+		selected = selected[:min(len(selected), rules.TopN)]
+	}
+
+	return selected
+}
+
+// Matches checks whether candidate passes mod, date and combo rules
+func (rules Rules) Matches(c *Candidate) bool {
+	mods := difficulty.Modifier(c.Replay.Mods)
+
+	if mods&rules.IncludeMods != rules.IncludeMods {
+		return false
+	}
+
+	if mods&rules.ExcludeMods > 0 {
+		return false
+	}
+
+	if !rules.From.IsZero() && c.Replay.Timestamp.Before(rules.From) {
+		return false
+	}
+
+	if !rules.To.IsZero() && c.Replay.Timestamp.After(rules.To) {
+		return false
+	}
+
+	return int64(c.Replay.MaxCombo) >= rules.MinCombo
+}
+
+func (rules Rules) rankValue(c *Candidate) float64 {
+	switch rules.SortBy {
+	case "PP":
+		return c.PP
+	case "Accuracy":
+		return c.Accuracy
+	}
+
+	return float64(c.Replay.Score)
+}
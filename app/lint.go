@@ -0,0 +1,72 @@
+package app
+
+import (
+	"fmt"
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/lint"
+	"github.com/wieku/danser-go/app/settings"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// runLint checks the beatmap set of given beatmap and returns the exit code, 1 if any errors were found
+func runLint(beatMap *beatmap.BeatMap, format string) int {
+	log.Println(fmt.Sprintf("Linting \"%s\"...", beatMap.Dir))
+
+	reports, err := lint.LintSet(filepath.Join(settings.General.GetSongsDir(), beatMap.Dir))
+	if err != nil {
+		log.Println("Failed to lint the beatmap set:", err)
+		return 1
+	}
+
+	write := lint.WriteText
+	if format == "json" {
+		write = lint.WriteJSON
+	}
+
+	var w io.Writer = os.Stdout
+
+	if strings.TrimSpace(output) != "" {
+		path := filepath.Join(settings.Recording.GetOutputDir(), output+".lint."+format)
+
+		if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			log.Println("Failed to save the lint report! Error:", err)
+			return 1
+		}
+
+		file, err := os.Create(path)
+		if err != nil {
+			log.Println("Failed to save the lint report! Error:", err)
+			return 1
+		}
+
+		defer file.Close()
+
+		w = file
+
+		log.Println("Lint report will be saved to:", path)
+	}
+
+	if err = write(w, reports); err != nil {
+		log.Println("Failed to write the lint report! Error:", err)
+		return 1
+	}
+
+	errors, warnings := 0, 0
+
+	for _, report := range reports {
+		errors += report.Count(lint.Error)
+		warnings += report.Count(lint.Warning)
+	}
+
+	log.Println(fmt.Sprintf("Linting finished: %d errors, %d warnings in %d files", errors, warnings, len(reports)))
+
+	if errors > 0 {
+		return 1
+	}
+
+	return 0
+}
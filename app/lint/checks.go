@@ -0,0 +1,664 @@
+package lint
+
+import (
+	"cmp"
+	"fmt"
+	"github.com/wieku/danser-go/app/beatmap/objects"
+	"github.com/wieku/danser-go/framework/files"
+	"github.com/wieku/danser-go/framework/math/curves"
+	"github.com/wieku/danser-go/framework/math/vector"
+	"math"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Object times are saved as integers, so snapped times can be off by up to 1ms after rounding
+	unsnapTolerance = 1.0
+
+	maxPathLength = 100_000_000 // The same sanity limit as in objects.Slider
+
+	minSV = 0.1
+	maxSV = 10.0
+
+	minSliderMultiplier = 0.4
+	maxSliderMultiplier = 3.6
+
+	minBPM = 10.0
+	maxBPM = 1000.0
+)
+
+var snapDivisors = []float64{1, 2, 3, 4, 6, 8, 12, 16}
+
+var sampleSetNames = []string{"Auto", "Normal", "Soft", "Drum"}
+
+func sampleSetName(set int) string {
+	if set >= 0 && set < len(sampleSetNames) {
+		return sampleSetNames[set]
+	}
+
+	return strconv.Itoa(set)
+}
+
+// prepare sorts timing points the way osu! does and calculates end times of sliders and spinners
+func (f *osuFile) prepare() {
+	slices.SortStableFunc(f.timings, func(a, b timingPoint) int {
+		if a.time != b.time {
+			return cmp.Compare(a.time, b.time)
+		}
+
+		if a.uninherited != b.uninherited { // uninherited points go first
+			if a.uninherited {
+				return -1
+			}
+
+			return 1
+		}
+
+		return 0
+	})
+
+	for i := range f.objects {
+		obj := &f.objects[i]
+
+		switch {
+		case obj.kind&int(objects.SLIDER) > 0 && len(obj.data) > 7:
+			pixelLength, _ := strconv.ParseFloat(obj.data[7], 64)
+			slides, _ := strconv.Atoi(obj.data[6])
+
+			red := f.redPointAt(obj.time)
+			if red == nil || red.beatLength <= 0 || pixelLength <= 0 || slides < 1 || f.sliderMultiplier <= 0 {
+				continue
+			}
+
+			velocity := 100 * f.sliderMultiplier * f.svAt(obj.time) / red.beatLength
+
+			obj.endTime = obj.time + pixelLength*float64(slides)/velocity
+		case obj.kind&int(objects.SPINNER) > 0 && len(obj.data) > 5:
+			if endTime, err := strconv.ParseFloat(obj.data[5], 64); err == nil {
+				obj.endTime = endTime
+			}
+		}
+	}
+}
+
+// pointAt returns the timing point active at the given time, the first point is used for times before it
+func (f *osuFile) pointAt(time float64) *timingPoint {
+	if len(f.timings) == 0 {
+		return nil
+	}
+
+	point := &f.timings[0]
+
+	for i := range f.timings {
+		if f.timings[i].time > time {
+			break
+		}
+
+		point = &f.timings[i]
+	}
+
+	return point
+}
+
+// redPointAt returns the uninherited timing point active at the given time
+func (f *osuFile) redPointAt(time float64) (point *timingPoint) {
+	for i := range f.timings {
+		p := &f.timings[i]
+
+		if !p.uninherited {
+			continue
+		}
+
+		if point != nil && p.time > time {
+			break
+		}
+
+		point = p
+	}
+
+	return
+}
+
+func (f *osuFile) svAt(time float64) float64 {
+	point := f.pointAt(time)
+	if point == nil || point.uninherited || !(point.beatLength < 0) {
+		return 1
+	}
+
+	return min(max(-100/point.beatLength, minSV), maxSV)
+}
+
+func (f *osuFile) sampleSetAt(time float64) int {
+	point := f.pointAt(time)
+	if point == nil || point.sampleSet == 0 {
+		return f.sampleSet
+	}
+
+	return point.sampleSet
+}
+
+func objectName(kind int) string {
+	switch {
+	case kind&int(objects.CIRCLE) > 0:
+		return "circle"
+	case kind&int(objects.SLIDER) > 0:
+		return "slider"
+	case kind&int(objects.SPINNER) > 0:
+		return "spinner"
+	}
+
+	return "object"
+}
+
+func (f *osuFile) checkFormat() {
+	if f.version == 0 {
+		f.addIssue(CheckFormat, Error, 1, -1, "missing \"osu file format\" header")
+	}
+
+	first := f.redPointAt(math.Inf(-1))
+
+	if first == nil {
+		f.addIssue(CheckFormat, Error, 0, -1, "beatmap has no uninherited timing points")
+	}
+
+	if len(f.objects) == 0 {
+		f.addIssue(CheckFormat, Error, 0, -1, "beatmap has no hit objects")
+		return
+	}
+
+	if first != nil && f.objects[0].time < first.time {
+		f.addIssue(CheckFormat, Warning, f.objects[0].line, f.objects[0].time, "first object is placed before the first uninherited timing point (line %d)", first.line)
+	}
+
+	for _, obj := range f.objects {
+		typeBits := obj.kind & int(objects.CIRCLE|objects.SLIDER|objects.SPINNER)
+
+		if typeBits == 0 || typeBits&(typeBits-1) != 0 {
+			f.addIssue(CheckFormat, Error, obj.line, obj.time, "invalid object type %d", obj.kind)
+			continue
+		}
+
+		if obj.kind&int(objects.SPINNER) > 0 {
+			if len(obj.data) < 6 {
+				f.addIssue(CheckFormat, Error, obj.line, obj.time, "spinner has no end time")
+			} else if obj.endTime < obj.time {
+				f.addIssue(CheckFormat, Error, obj.line, obj.time, "spinner ends before it starts")
+			}
+		}
+	}
+}
+
+func (f *osuFile) checkSV() {
+	if f.sliderMultiplier < minSliderMultiplier || f.sliderMultiplier > maxSliderMultiplier {
+		f.addIssue(CheckSV, Warning, 0, -1, "slider multiplier %g is outside of %g-%g range", f.sliderMultiplier, minSliderMultiplier, maxSliderMultiplier)
+	}
+
+	first := f.redPointAt(math.Inf(-1))
+
+	for _, point := range f.timings {
+		if point.uninherited {
+			if math.IsNaN(point.beatLength) || math.IsInf(point.beatLength, 0) || point.beatLength <= 0 {
+				f.addIssue(CheckSV, Error, point.line, point.time, "uninherited timing point has an invalid beat length %g", point.beatLength)
+				continue
+			}
+
+			if bpm := 60000 / point.beatLength; bpm < minBPM || bpm > maxBPM {
+				f.addIssue(CheckSV, Warning, point.line, point.time, "abnormal BPM %.2f", bpm)
+			}
+
+			continue
+		}
+
+		if first != nil && point.time < first.time {
+			f.addIssue(CheckSV, Warning, point.line, point.time, "inherited timing point is placed before the first uninherited one")
+		}
+
+		if math.IsNaN(point.beatLength) || point.beatLength >= 0 {
+			f.addIssue(CheckSV, Error, point.line, point.time, "inherited timing point has a non-negative beat length %g, it's treated as 1.00x", point.beatLength)
+			continue
+		}
+
+		if sv := -100 / point.beatLength; sv < minSV || sv > maxSV {
+			f.addIssue(CheckSV, Warning, point.line, point.time, "SV %.2fx is outside of %.2fx-%.2fx range and gets clamped", sv, minSV, maxSV)
+		}
+	}
+}
+
+// snapDistance returns the distance in ms to the closest 1/1-1/16 beat tick of the uninherited timing point
+func snapDistance(time float64, red *timingPoint) float64 {
+	distance := math.Inf(1)
+
+	for _, divisor := range snapDivisors {
+		step := red.beatLength / divisor
+		snapped := red.time + math.Round((time-red.time)/step)*step
+
+		distance = min(distance, math.Abs(time-snapped))
+	}
+
+	return distance
+}
+
+func (f *osuFile) checkUnsnapped() {
+	check := func(obj hitObject, time float64, what string) {
+		red := f.redPointAt(time)
+		if red == nil || !(red.beatLength > 0) || math.IsInf(red.beatLength, 0) {
+			return
+		}
+
+		if distance := snapDistance(time, red); distance > unsnapTolerance {
+			f.addIssue(CheckUnsnapped, Warning, obj.line, time, "%s is unsnapped by %.1fms", what, distance)
+		}
+	}
+
+	for _, obj := range f.objects {
+		name := objectName(obj.kind)
+
+		check(obj, obj.time, name)
+
+		if obj.endTime > obj.time {
+			check(obj, obj.endTime, name+" end")
+		}
+	}
+}
+
+func (f *osuFile) checkOverlaps() {
+	for i := 1; i < len(f.objects); i++ {
+		prev, obj := f.objects[i-1], f.objects[i]
+
+		switch {
+		case obj.time < prev.time:
+			f.addIssue(CheckOverlap, Error, obj.line, obj.time, "objects are out of order, %s starts %.0fms before the previous object (line %d)", objectName(obj.kind), prev.time-obj.time, prev.line)
+		case obj.time == prev.time:
+			f.addIssue(CheckOverlap, Error, obj.line, obj.time, "%s starts at the same time as the previous object (line %d)", objectName(obj.kind), prev.line)
+		case obj.time < math.Floor(prev.endTime):
+			f.addIssue(CheckOverlap, Error, obj.line, obj.time, "%s starts %.0fms before the previous %s (line %d) ends", objectName(obj.kind), prev.endTime-obj.time, objectName(prev.kind), prev.line)
+		}
+	}
+}
+
+func tryGetType(str string) curves.CType {
+	switch str {
+	case "P":
+		return curves.CCirArc
+	case "L":
+		return curves.CLine
+	case "B":
+		return curves.CBezier
+	case "C":
+		return curves.CCatmull
+	default:
+		return -1
+	}
+}
+
+func (f *osuFile) checkSliders() {
+	for _, obj := range f.objects {
+		if obj.kind&int(objects.SLIDER) == 0 {
+			continue
+		}
+
+		if len(obj.data) < 8 {
+			f.addIssue(CheckSlider, Error, obj.line, obj.time, "slider has %d fields instead of at least 8", len(obj.data))
+			continue
+		}
+
+		slides, err := strconv.Atoi(obj.data[6])
+		if err != nil || slides < 1 {
+			f.addIssue(CheckSlider, Error, obj.line, obj.time, "invalid repeat count \"%s\"", obj.data[6])
+			continue
+		}
+
+		pixelLength, err := strconv.ParseFloat(obj.data[7], 64)
+		if err != nil || math.IsNaN(pixelLength) || pixelLength < 0 {
+			f.addIssue(CheckSlider, Error, obj.line, obj.time, "invalid pixel length \"%s\"", obj.data[7])
+			continue
+		}
+
+		if pixelLength*float64(slides) > maxPathLength*10 {
+			f.addIssue(CheckSlider, Error, obj.line, obj.time, "slider is too long (%.0fpx with %d repeats) and is skipped by danser", pixelLength, slides-1)
+			continue
+		}
+
+		f.checkCurve(obj, pixelLength)
+	}
+}
+
+func (f *osuFile) checkCurve(obj hitObject, pixelLength float64) {
+	x, _ := strconv.ParseFloat(obj.data[0], 32)
+	y, _ := strconv.ParseFloat(obj.data[1], 32)
+
+	start := vector.NewVec2f(float32(x), float32(y))
+
+	var defs []curves.CurveDef
+
+	cDef := curves.CurveDef{
+		CurveType: curves.CType(-1),
+		Points:    []vector.Vector2f{start},
+	}
+
+	nextType := curves.CType(-1)
+	typeSpecified := false
+
+	j := 0
+
+	for i, token := range strings.Split(obj.data[5], "|") {
+		split := strings.Split(token, ":")
+
+		if len(split) == 1 {
+			tType := tryGetType(token)
+			if tType < 0 {
+				f.addIssue(CheckSlider, Error, obj.line, obj.time, "unknown curve type \"%s\"", token)
+				return
+			}
+
+			if i == 0 {
+				typeSpecified = true
+			}
+
+			if cDef.CurveType == -1 {
+				cDef.CurveType = tType
+			} else {
+				nextType = tType
+			}
+
+			continue
+		}
+
+		px, errX := strconv.ParseFloat(split[0], 32)
+		py, errY := strconv.ParseFloat(split[1], 32)
+
+		if len(split) != 2 || errX != nil || errY != nil {
+			f.addIssue(CheckSlider, Error, obj.line, obj.time, "invalid control point \"%s\"", token)
+			return
+		}
+
+		vec := vector.NewVec2f(float32(px), float32(py))
+
+		if j > 0 || vec != start { // the first point is skipped if it's the same as start position, like in objects.Slider
+			cDef.Points = append(cDef.Points, vec)
+		}
+
+		j++
+
+		if nextType > -1 {
+			defs = append(defs, cDef)
+
+			cDef = curves.CurveDef{
+				CurveType: nextType,
+				Points:    []vector.Vector2f{vec},
+			}
+
+			nextType = -1
+		}
+	}
+
+	if !typeSpecified {
+		f.addIssue(CheckSlider, Warning, obj.line, obj.time, "curve type is missing, catmull is used")
+	}
+
+	if len(cDef.Points) > 1 || len(defs) == 0 {
+		if cDef.CurveType == -1 {
+			cDef.CurveType = curves.CCatmull
+		}
+
+		defs = append(defs, cDef)
+	}
+
+	if len(defs) == 1 && len(defs[0].Points) < 2 {
+		f.addIssue(CheckSlider, Error, obj.line, obj.time, "slider has no control points")
+		return
+	}
+
+	for _, def := range defs {
+		switch def.CurveType {
+		case curves.CCatmull:
+			if typeSpecified {
+				f.addIssue(CheckSlider, Warning, obj.line, obj.time, "catmull curves are deprecated")
+			}
+		case curves.CCirArc:
+			if len(def.Points) != 3 {
+				f.addIssue(CheckSlider, Warning, obj.line, obj.time, "perfect curve has %d points instead of 3, it's drawn as bezier", len(def.Points))
+			} else if p := def.Points; math.Abs(float64((p[1].Y-p[0].Y)*(p[2].X-p[0].X)-(p[1].X-p[0].X)*(p[2].Y-p[0].Y))) < 0.001 {
+				f.addIssue(CheckSlider, Warning, obj.line, obj.time, "perfect curve points are collinear, it's drawn as a line")
+			}
+		case curves.CBezier:
+			var controlDistance float32
+
+			for i := 1; i < len(def.Points); i++ {
+				controlDistance += def.Points[i].Dst(def.Points[i-1])
+			}
+
+			if controlDistance >= 2*maxPathLength {
+				f.addIssue(CheckSlider, Error, obj.line, obj.time, "bezier curve is too expensive to calculate and is skipped by danser")
+				return
+			}
+		}
+	}
+
+	pathLength := float64(curves.NewMultiCurve(defs).GetLength())
+
+	if math.IsNaN(pathLength) || pathLength <= 0 {
+		f.addIssue(CheckSlider, Error, obj.line, obj.time, "slider path has zero length")
+		return
+	}
+
+	if pixelLength == 0 {
+		f.addIssue(CheckSlider, Warning, obj.line, obj.time, "pixel length is 0, full path length %.1fpx is used", pathLength)
+	} else if pixelLength > pathLength+1 {
+		f.addIssue(CheckSlider, Warning, obj.line, obj.time, "pixel length %.1fpx is longer than the path (%.1fpx)", pixelLength, pathLength)
+	}
+
+	if end := curves.NewMultiCurveT(defs, pixelLength).PointAt(1); math.IsNaN(float64(end.X)) || math.IsNaN(float64(end.Y)) {
+		f.addIssue(CheckSlider, Error, obj.line, obj.time, "slider end position can't be calculated")
+	}
+}
+
+func parseSampleSets(value string) (normal, addition int, ok bool) {
+	split := strings.Split(value, ":")
+	if len(split) < 2 {
+		return 0, 0, false
+	}
+
+	var err1, err2 error
+
+	normal, err1 = strconv.Atoi(split[0])
+	addition, err2 = strconv.Atoi(split[1])
+
+	return normal, addition, err1 == nil && err2 == nil
+}
+
+func validSampleSet(set int) bool {
+	return set >= 0 && set <= 3
+}
+
+// hitSampleIndex returns the index of hitSample field, it's the last field of every object type
+func hitSampleIndex(kind int) int {
+	switch {
+	case kind&int(objects.SLIDER) > 0:
+		return 10
+	case kind&int(objects.SPINNER) > 0:
+		return 6
+	}
+
+	return 5
+}
+
+func (f *osuFile) checkHitSounds(maps []*osuFile) {
+	for _, point := range f.timings {
+		if !validSampleSet(point.sampleSet) {
+			f.addIssue(CheckHitSounds, Error, point.line, point.time, "timing point has an invalid sample set %d", point.sampleSet)
+		}
+
+		if point.sampleIndex < 0 {
+			f.addIssue(CheckHitSounds, Error, point.line, point.time, "timing point has an invalid custom sample index %d", point.sampleIndex)
+		}
+	}
+
+	for _, obj := range f.objects {
+		if idx := hitSampleIndex(obj.kind); idx < len(obj.data) && obj.data[idx] != "" {
+			if normal, addition, ok := parseSampleSets(obj.data[idx]); !ok || !validSampleSet(normal) || !validSampleSet(addition) {
+				f.addIssue(CheckHitSounds, Error, obj.line, obj.time, "invalid hit sample \"%s\"", obj.data[idx])
+			}
+		}
+
+		if obj.kind&int(objects.SLIDER) == 0 || len(obj.data) < 9 {
+			continue
+		}
+
+		slides, _ := strconv.Atoi(obj.data[6])
+
+		if edges := len(strings.Split(obj.data[8], "|")); edges != slides+1 {
+			f.addIssue(CheckHitSounds, Warning, obj.line, obj.time, "slider has %d edge sounds for %d edges", edges, slides+1)
+		}
+
+		if len(obj.data) < 10 {
+			continue
+		}
+
+		edgeSets := strings.Split(obj.data[9], "|")
+
+		if len(edgeSets) != slides+1 {
+			f.addIssue(CheckHitSounds, Warning, obj.line, obj.time, "slider has %d edge sample sets for %d edges", len(edgeSets), slides+1)
+		}
+
+		for _, edgeSet := range edgeSets {
+			if normal, addition, ok := parseSampleSets(edgeSet); !ok || !validSampleSet(normal) || !validSampleSet(addition) {
+				f.addIssue(CheckHitSounds, Error, obj.line, obj.time, "invalid edge sample set \"%s\"", edgeSet)
+				break
+			}
+		}
+	}
+
+	f.checkSampleSetConsistency(maps)
+}
+
+// checkSampleSetConsistency compares sample sets at every timing point with other difficulties of the set
+func (f *osuFile) checkSampleSetConsistency(maps []*osuFile) {
+	lastTime := math.NaN()
+
+	for _, point := range f.timings {
+		if point.time == lastTime {
+			continue
+		}
+
+		lastTime = point.time
+
+		set := f.sampleSetAt(point.time)
+
+		for _, other := range maps {
+			if other == f || len(other.timings) == 0 || other.timings[0].time > point.time {
+				continue
+			}
+
+			if otherSet := other.sampleSetAt(point.time); otherSet != set {
+				f.addIssue(CheckHitSounds, Warning, point.line, point.time, "sample set %s differs from %s used in [%s]", sampleSetName(set), sampleSetName(otherSet), other.difficulty)
+				break
+			}
+		}
+	}
+}
+
+func (f *osuFile) checkFiles(fileMap *files.FileMap) {
+	if f.audio == "" {
+		f.addIssue(CheckFiles, Error, 0, -1, "audio file is not set")
+	} else if _, err := fileMap.GetFile(f.audio); err != nil {
+		f.addIssue(CheckFiles, Error, f.audioLine, -1, "audio file \"%s\" doesn't exist", f.audio)
+	}
+
+	if f.background == "" {
+		f.addIssue(CheckFiles, Warning, 0, -1, "background image is not set")
+	} else if _, err := fileMap.GetFile(f.background); err != nil {
+		f.addIssue(CheckFiles, Warning, f.backgroundLine, -1, "background image \"%s\" doesn't exist", f.background)
+	}
+
+	for _, video := range f.videos {
+		if _, err := fileMap.GetFile(video.file); err != nil {
+			f.addIssue(CheckFiles, Warning, video.line, -1, "video \"%s\" doesn't exist", video.file)
+		}
+	}
+
+	for _, obj := range f.objects {
+		idx := hitSampleIndex(obj.kind)
+		if idx >= len(obj.data) {
+			continue
+		}
+
+		if split := strings.Split(obj.data[idx], ":"); len(split) > 4 && split[4] != "" {
+			if _, err := fileMap.GetFile(split[4]); err != nil {
+				f.addIssue(CheckFiles, Warning, obj.line, obj.time, "custom hit sound \"%s\" doesn't exist", split[4])
+			}
+		}
+	}
+}
+
+func (f *osuFile) checkStoryboard(fileMap *files.FileMap) {
+	type missingFile struct {
+		line  int
+		file  string
+		count int
+	}
+
+	var missing []*missingFile
+	missingMap := make(map[string]*missingFile)
+
+	addMissing := func(line int, file string) {
+		key := strings.ToLower(file)
+
+		if m, ok := missingMap[key]; ok {
+			m.count++
+			return
+		}
+
+		m := &missingFile{line: line, file: file, count: 1}
+
+		missingMap[key] = m
+		missing = append(missing, m)
+	}
+
+	for _, image := range f.images {
+		if image.frames == 0 {
+			if _, err := fileMap.GetFile(image.file); err != nil {
+				addMissing(image.line, image.file)
+			}
+
+			continue
+		}
+
+		ext := filepath.Ext(image.file)
+		base := strings.TrimSuffix(image.file, ext)
+
+		for i := 0; i < image.frames; i++ {
+			if _, err := fileMap.GetFile(base + strconv.Itoa(i) + ext); err != nil {
+				addMissing(image.line, base+strconv.Itoa(i)+ext)
+			}
+		}
+	}
+
+	for _, m := range missing {
+		f.addIssue(CheckStoryboard, Warning, m.line, -1, "storyboard image \"%s\" doesn't exist%s", m.file, referenceCount(m.count))
+	}
+
+	missing = missing[:0]
+	clear(missingMap)
+
+	for _, sample := range f.samples {
+		if _, err := fileMap.GetFile(sample.file); err != nil {
+			addMissing(sample.line, sample.file)
+		}
+	}
+
+	for _, m := range missing {
+		f.addIssue(CheckStoryboard, Warning, m.line, -1, "storyboard sample \"%s\" doesn't exist%s", m.file, referenceCount(m.count))
+	}
+}
+
+func referenceCount(count int) string {
+	if count > 1 {
+		return fmt.Sprintf(" (referenced %d times)", count)
+	}
+
+	return ""
+}
@@ -0,0 +1,202 @@
+package lint
+
+import (
+	"fmt"
+	"github.com/wieku/danser-go/framework/files"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+type Severity string
+
+const (
+	Warning = Severity("warning")
+	Error   = Severity("error")
+)
+
+type Check string
+
+const (
+	CheckFormat     = Check("format")
+	CheckUnsnapped  = Check("unsnapped")
+	CheckOverlap    = Check("overlap")
+	CheckSlider     = Check("slider")
+	CheckFiles      = Check("files")
+	CheckHitSounds  = Check("hitsounds")
+	CheckStoryboard = Check("storyboard")
+	CheckSV         = Check("sv")
+)
+
+type Issue struct {
+	Check    Check    `json:"check"`
+	Severity Severity `json:"severity"`
+	Line     int      `json:"line,omitempty"`
+	Time     string   `json:"time,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// Report holds issues found in a single .osu or .osb file
+type Report struct {
+	File       string  `json:"file"`
+	MD5        string  `json:"md5,omitempty"`
+	Artist     string  `json:"artist,omitempty"`
+	Title      string  `json:"title,omitempty"`
+	Difficulty string  `json:"difficulty,omitempty"`
+	Creator    string  `json:"creator,omitempty"`
+	Issues     []Issue `json:"issues"`
+}
+
+func (report *Report) Count(severity Severity) (count int) {
+	for _, issue := range report.Issues {
+		if issue.Severity == severity {
+			count++
+		}
+	}
+
+	return
+}
+
+// LintSet checks all osu!standard difficulties and the storyboard of the beatmap set in the given directory
+func LintSet(dir string) ([]*Report, error) {
+	fileMap, err := files.NewFileMap(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var maps []*osuFile
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".osu") {
+			continue
+		}
+
+		parsed, err := parseFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			log.Println(fmt.Sprintf("Failed to read %s: %s", entry.Name(), err))
+			continue
+		}
+
+		if parsed.mode != 0 {
+			log.Println(fmt.Sprintf("Skipping %s: only osu!standard difficulties are checked", entry.Name()))
+			continue
+		}
+
+		maps = append(maps, parsed)
+	}
+
+	if len(maps) == 0 {
+		return nil, fmt.Errorf("no osu!standard difficulties found in %s", dir)
+	}
+
+	slices.SortFunc(maps, func(a, b *osuFile) int {
+		return strings.Compare(a.difficulty, b.difficulty)
+	})
+
+	reports := make([]*Report, 0, len(maps)+1)
+
+	for _, m := range maps {
+		m.prepare()
+	}
+
+	for _, m := range maps {
+		m.checkFormat()
+		m.checkSV()
+		m.checkUnsnapped()
+		m.checkOverlaps()
+		m.checkSliders()
+		m.checkHitSounds(maps)
+		m.checkFiles(fileMap)
+		m.checkStoryboard(fileMap)
+
+		reports = append(reports, m.report(dir))
+	}
+
+	if osb := findStoryboard(dir, fileMap, maps[0]); osb != "" {
+		parsed, err := parseFile(osb)
+		if err != nil {
+			log.Println(fmt.Sprintf("Failed to read %s: %s", filepath.Base(osb), err))
+		} else {
+			parsed.checkStoryboard(fileMap)
+
+			reports = append(reports, parsed.report(dir))
+		}
+	}
+
+	return reports, nil
+}
+
+// findStoryboard returns the path of set's .osb file, osu! looks for "Artist - Title (Creator).osb" but falls back to any .osb in the directory
+func findStoryboard(dir string, fileMap *files.FileMap, bMap *osuFile) string {
+	if path, err := fileMap.GetFile(files.FixName(fmt.Sprintf("%s - %s (%s).osb", bMap.artist, bMap.title, bMap.creator))); err == nil {
+		return path
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.osb"))
+	if len(matches) > 0 {
+		return matches[0]
+	}
+
+	return ""
+}
+
+func (f *osuFile) report(dir string) *Report {
+	slices.SortStableFunc(f.issues, func(a, b Issue) int {
+		return a.Line - b.Line
+	})
+
+	rel, err := filepath.Rel(dir, f.path)
+	if err != nil {
+		rel = filepath.Base(f.path)
+	}
+
+	report := &Report{
+		File:   rel,
+		MD5:    f.md5,
+		Issues: f.issues,
+	}
+
+	if strings.EqualFold(filepath.Ext(f.path), ".osu") {
+		report.Artist = f.artist
+		report.Title = f.title
+		report.Difficulty = f.difficulty
+		report.Creator = f.creator
+	}
+
+	if report.Issues == nil {
+		report.Issues = make([]Issue, 0)
+	}
+
+	return report
+}
+
+// addIssue records an issue, time is in ms and is omitted if negative
+func (f *osuFile) addIssue(check Check, severity Severity, line int, time float64, format string, args ...any) {
+	issue := Issue{
+		Check:    check,
+		Severity: severity,
+		Line:     line,
+		Message:  fmt.Sprintf(format, args...),
+	}
+
+	if time >= 0 && !math.IsInf(time, 0) {
+		issue.Time = formatTime(time)
+	}
+
+	f.issues = append(f.issues, issue)
+}
+
+// formatTime formats time in ms like osu!'s editor does: mm:ss:ms
+func formatTime(time float64) string {
+	t := int64(math.Round(time))
+
+	return fmt.Sprintf("%02d:%02d:%03d", t/60000, (t/1000)%60, t%1000)
+}
@@ -0,0 +1,338 @@
+package lint
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"github.com/wieku/danser-go/framework/files"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const bufferSize = 10 * 1024 * 1024
+
+type timingPoint struct {
+	line        int
+	time        float64
+	beatLength  float64
+	sampleSet   int
+	sampleIndex int
+	uninherited bool
+}
+
+type hitObject struct {
+	line    int
+	time    float64
+	endTime float64
+	kind    int
+	data    []string
+}
+
+type fileRef struct {
+	line   int
+	file   string
+	frames int
+}
+
+// osuFile holds raw .osu/.osb data with line numbers, nothing is clamped or fixed like in beatmap.ParseBeatMap
+type osuFile struct {
+	path string
+	md5  string
+
+	version int
+	mode    int
+
+	artist     string
+	title      string
+	difficulty string
+	creator    string
+
+	audio     string
+	audioLine int
+	sampleSet int
+
+	sliderMultiplier float64
+
+	background     string
+	backgroundLine int
+
+	videos  []fileRef
+	images  []fileRef
+	samples []fileRef
+
+	timings []timingPoint
+	objects []hitObject
+
+	issues []Issue
+}
+
+func parseFile(path string) (*osuFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := md5.Sum(data)
+
+	parsed := &osuFile{
+		path:             path,
+		md5:              hex.EncodeToString(hash[:]),
+		sampleSet:        1,
+		sliderMultiplier: 1.4,
+	}
+
+	scanner := files.NewScannerBuf(bytes.NewReader(data), bufferSize)
+
+	var currentSection string
+	var variables [][2]string
+
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, "osu file format v") {
+			parsed.version, _ = strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "osu file format v")))
+			continue
+		}
+
+		if strings.HasPrefix(line, "//") || strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			currentSection = strings.TrimSuffix(strings.TrimPrefix(trimmed, "["), "]")
+			continue
+		}
+
+		switch currentSection {
+		case "General":
+			parsed.parseGeneral(line, lineNum)
+		case "Metadata":
+			parsed.parseMetadata(line)
+		case "Difficulty":
+			parsed.parseDifficulty(line, lineNum)
+		case "Variables":
+			if split := strings.SplitN(line, "=", 2); len(split) == 2 {
+				variables = append(variables, [2]string{split[0], split[1]})
+			}
+		case "Events":
+			if strings.ContainsRune(line, '$') {
+				for _, v := range variables {
+					line = strings.ReplaceAll(line, v[0], v[1])
+				}
+			}
+
+			parsed.parseEvent(line, lineNum)
+		case "TimingPoints":
+			parsed.parseTimingPoint(line, lineNum)
+		case "HitObjects":
+			parsed.parseHitObject(line, lineNum)
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return parsed, nil
+}
+
+func splitKeyValue(line string) (string, string, bool) {
+	split := strings.SplitN(line, ":", 2)
+	if len(split) < 2 {
+		return "", "", false
+	}
+
+	return strings.TrimSpace(split[0]), strings.TrimSpace(split[1]), true
+}
+
+func (f *osuFile) parseGeneral(line string, lineNum int) {
+	key, value, ok := splitKeyValue(line)
+	if !ok {
+		return
+	}
+
+	switch key {
+	case "AudioFilename":
+		f.audio = value
+		f.audioLine = lineNum
+	case "Mode":
+		f.mode, _ = strconv.Atoi(value)
+	case "SampleSet":
+		switch value {
+		case "Normal", "All":
+			f.sampleSet = 1
+		case "Soft", "None":
+			f.sampleSet = 2
+		case "Drum":
+			f.sampleSet = 3
+		default:
+			f.addIssue(CheckHitSounds, Error, lineNum, -1, "unknown default sample set \"%s\"", value)
+		}
+	}
+}
+
+func (f *osuFile) parseMetadata(line string) {
+	key, value, ok := splitKeyValue(line)
+	if !ok {
+		return
+	}
+
+	switch key {
+	case "Artist":
+		f.artist = value
+	case "Title":
+		f.title = value
+	case "Version":
+		f.difficulty = value
+	case "Creator":
+		f.creator = value
+	}
+}
+
+func (f *osuFile) parseDifficulty(line string, lineNum int) {
+	key, value, ok := splitKeyValue(line)
+	if !ok || key != "SliderMultiplier" {
+		return
+	}
+
+	sm, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		f.addIssue(CheckFormat, Error, lineNum, -1, "invalid slider multiplier \"%s\"", value)
+		return
+	}
+
+	f.sliderMultiplier = sm
+}
+
+func unquote(value string) string {
+	return strings.TrimSpace(strings.ReplaceAll(value, "\"", ""))
+}
+
+func (f *osuFile) parseEvent(line string, lineNum int) {
+	if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "_") { // storyboard commands
+		return
+	}
+
+	split := strings.Split(line, ",")
+	for i := range split {
+		split[i] = strings.TrimSpace(split[i])
+	}
+
+	switch split[0] {
+	case "0", "Background":
+		if len(split) > 2 {
+			f.background = unquote(split[2])
+			f.backgroundLine = lineNum
+		}
+	case "1", "Video":
+		if len(split) > 2 {
+			f.videos = append(f.videos, fileRef{line: lineNum, file: unquote(split[2])})
+		}
+	case "4", "Sprite":
+		if len(split) < 6 {
+			f.addIssue(CheckStoryboard, Error, lineNum, -1, "sprite definition has %d fields instead of 6", len(split))
+			return
+		}
+
+		f.images = append(f.images, fileRef{line: lineNum, file: withExtension(unquote(split[3]), ".png")})
+	case "6", "Animation":
+		if len(split) < 8 {
+			f.addIssue(CheckStoryboard, Error, lineNum, -1, "animation definition has %d fields instead of 8", len(split))
+			return
+		}
+
+		frames, err := strconv.Atoi(split[6])
+		if err != nil || frames <= 0 {
+			f.addIssue(CheckStoryboard, Error, lineNum, -1, "invalid animation frame count \"%s\"", split[6])
+			return
+		}
+
+		f.images = append(f.images, fileRef{line: lineNum, file: withExtension(unquote(split[3]), ".png"), frames: frames})
+	case "5", "Sample":
+		if len(split) < 4 {
+			f.addIssue(CheckStoryboard, Error, lineNum, -1, "sample definition has %d fields instead of 4", len(split))
+			return
+		}
+
+		f.samples = append(f.samples, fileRef{line: lineNum, file: withExtension(unquote(split[3]), ".wav")})
+	}
+}
+
+func withExtension(file, ext string) string {
+	if filepath.Ext(file) == "" {
+		return file + ext
+	}
+
+	return file
+}
+
+func (f *osuFile) parseTimingPoint(line string, lineNum int) {
+	split := strings.Split(line, ",")
+	if len(split) < 2 {
+		f.addIssue(CheckFormat, Error, lineNum, -1, "timing point has %d fields instead of 8", len(split))
+		return
+	}
+
+	time, err1 := strconv.ParseFloat(strings.TrimSpace(split[0]), 64)
+	beatLength, err2 := strconv.ParseFloat(strings.TrimSpace(split[1]), 64)
+
+	if err1 != nil || err2 != nil {
+		f.addIssue(CheckFormat, Error, lineNum, -1, "timing point has an invalid time or beat length")
+		return
+	}
+
+	point := timingPoint{
+		line:        lineNum,
+		time:        time,
+		beatLength:  beatLength,
+		sampleSet:   f.sampleSet,
+		sampleIndex: 1,
+		uninherited: true,
+	}
+
+	if len(split) > 3 {
+		point.sampleSet, _ = strconv.Atoi(strings.TrimSpace(split[3]))
+	}
+
+	if len(split) > 4 {
+		point.sampleIndex, _ = strconv.Atoi(strings.TrimSpace(split[4]))
+	}
+
+	if len(split) > 6 {
+		point.uninherited = strings.TrimSpace(split[6]) != "0"
+	}
+
+	f.timings = append(f.timings, point)
+}
+
+func (f *osuFile) parseHitObject(line string, lineNum int) {
+	split := strings.Split(line, ",")
+	for i := range split {
+		split[i] = strings.TrimSpace(split[i])
+	}
+
+	if len(split) < 5 {
+		f.addIssue(CheckFormat, Error, lineNum, -1, "hit object has %d fields instead of at least 5", len(split))
+		return
+	}
+
+	_, errX := strconv.ParseFloat(split[0], 64)
+	_, errY := strconv.ParseFloat(split[1], 64)
+	time, errT := strconv.ParseFloat(split[2], 64)
+	kind, errK := strconv.Atoi(split[3])
+
+	if errX != nil || errY != nil || errT != nil || errK != nil {
+		f.addIssue(CheckFormat, Error, lineNum, -1, "hit object has an invalid position, time or type")
+		return
+	}
+
+	f.objects = append(f.objects, hitObject{
+		line:    lineNum,
+		time:    time,
+		endTime: time,
+		kind:    kind,
+		data:    split,
+	})
+}
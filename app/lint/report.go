@@ -0,0 +1,85 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteText writes issues of all reports in a human-readable form, grouped by difficulty
+func WriteText(w io.Writer, reports []*Report) error {
+	tw := &textWriter{w: w}
+
+	for i, report := range reports {
+		if i > 0 {
+			tw.line("")
+		}
+
+		title := report.File
+		if report.Difficulty != "" {
+			title = fmt.Sprintf("%s - %s [%s] (%s)", report.Artist, report.Title, report.Difficulty, report.Creator)
+		}
+
+		tw.line("%s", title)
+		tw.line("%s", strings.Repeat("=", len(title)))
+
+		if report.Difficulty != "" {
+			tw.line("File: %s (%s)", report.File, report.MD5)
+		}
+
+		tw.line("Issues: %d errors, %d warnings", report.Count(Error), report.Count(Warning))
+
+		if len(report.Issues) > 0 {
+			tw.line("")
+		}
+
+		for _, issue := range report.Issues {
+			location := ""
+			if issue.Line > 0 {
+				location = fmt.Sprintf("line %d", issue.Line)
+			}
+
+			if issue.Time != "" {
+				if location != "" {
+					location += ", "
+				}
+
+				location += issue.Time
+			}
+
+			if location != "" {
+				location = " (" + location + ")"
+			}
+
+			tw.line("  %-7s %-10s %s%s", issue.Severity, issue.Check, issue.Message, location)
+		}
+	}
+
+	return tw.err
+}
+
+// WriteJSON writes all reports as a JSON array
+func WriteJSON(w io.Writer, reports []*Report) error {
+	data, err := json.MarshalIndent(reports, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(data, '\n'))
+
+	return err
+}
+
+type textWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (w *textWriter) line(format string, args ...any) {
+	if w.err != nil {
+		return
+	}
+
+	_, w.err = fmt.Fprintf(w.w, format+"\n", args...)
+}
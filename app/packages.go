@@ -0,0 +1,115 @@
+package app
+
+import (
+	"fmt"
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/database"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/framework/files"
+	"log"
+	"path/filepath"
+	"strings"
+)
+
+// runSetExport packs beatmap's set as it is into an .osz in Recording.OutputDir and returns the exit code
+func runSetExport(beatMap *beatmap.BeatMap) int {
+	log.Println(fmt.Sprintf("Exporting \"%s\"...", beatMap.Dir))
+
+	name := output
+	if strings.TrimSpace(name) == "" {
+		name = files.FixName(beatMap.Dir)
+	}
+
+	path := filepath.Join(settings.Recording.GetOutputDir(), name+".osz")
+
+	if err := database.ExportSet(beatMap.Dir, path); err != nil {
+		log.Println("Failed to save the .osz file:", err)
+		return 1
+	}
+
+	log.Println("Exported beatmap set is available at:", path)
+
+	return 0
+}
+
+// runImport imports .osz/.olz files given by -import, prints the report and returns the exit code, 1 if any package failed.
+// Database has to be initialized.
+func runImport(paths string, dryRun bool, skipDatabaseCheck bool) int {
+	var inputs []string
+
+	for _, p := range strings.Split(paths, "|") {
+		if p = strings.TrimSpace(p); p != "" {
+			inputs = append(inputs, p)
+		}
+	}
+
+	packages, err := database.FindPackages(inputs)
+	if err != nil {
+		log.Println("Failed to find beatmap packages:", err)
+		return 1
+	}
+
+	if len(packages) == 0 {
+		log.Println("No .osz or .olz files found, closing...")
+		return 1
+	}
+
+	if dryRun {
+		log.Println(fmt.Sprintf("Checking %d packages (dry run)...", len(packages)))
+	} else {
+		log.Println(fmt.Sprintf("Importing %d packages...", len(packages)))
+	}
+
+	// Packages are compared with the database, so it has to be up-to-date with Songs folder first
+	database.LoadBeatmaps(skipDatabaseCheck, nil)
+
+	results := database.ImportPackages(packages, dryRun)
+
+	counts := make(map[database.PackageStatus]int)
+
+	var importedDirs []string
+
+	for _, r := range results {
+		counts[r.Status]++
+
+		if r.Status == database.PackageImported {
+			importedDirs = append(importedDirs, r.Directory)
+		}
+
+		setID := "unknown set"
+		if r.SetID > 0 {
+			setID = fmt.Sprintf("set %d", r.SetID)
+		}
+
+		log.Println(fmt.Sprintf("[%s] %s (%s, %d difficulties)", r.Status, r.Path, setID, len(r.Difficulties)))
+
+		switch r.Status {
+		case database.PackageImported:
+			log.Println("    extracted to:", r.Directory)
+		case database.PackageNew:
+			log.Println("    would be extracted to:", r.Directory)
+		default:
+			log.Println("    skipped:", r.Reason)
+		}
+
+		for _, dir := range r.Conflicting {
+			log.Println("    existing directory:", dir)
+		}
+	}
+
+	if dryRun {
+		log.Println(fmt.Sprintf("Dry run finished: %d new, %d duplicates, %d conflicts, %d failed", counts[database.PackageNew], counts[database.PackageDuplicate], counts[database.PackageConflict], counts[database.PackageFailed]))
+	} else {
+		log.Println(fmt.Sprintf("Import finished: %d imported, %d duplicates, %d conflicts, %d failed", counts[database.PackageImported], counts[database.PackageDuplicate], counts[database.PackageConflict], counts[database.PackageFailed]))
+
+		if len(importedDirs) > 0 {
+			database.LoadBeatmapsWithDirs(skipDatabaseCheck, importedDirs, nil)
+		}
+	}
+
+	if counts[database.PackageFailed] > 0 {
+		return 1
+	}
+
+	return 0
+}
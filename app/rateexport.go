@@ -0,0 +1,226 @@
+package app
+
+import (
+	"fmt"
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/ffmpeg"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/app/utils"
+	"github.com/wieku/danser-go/framework/bass"
+	"github.com/wieku/danser-go/framework/files"
+	"github.com/wieku/danser-go/framework/math/mutils"
+	"io/fs"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// runRateExport saves beatmap's set with changed speed and pitch as .osz in Recording.OutputDir and returns the exit code
+func runRateExport(beatMap *beatmap.BeatMap, speed, pitch, ar, od, cs, hp float64) int {
+	suffix := mutils.FormatWOZeros(speed, 2) + "x"
+	if pitch != 1 {
+		suffix += fmt.Sprintf(" (%sx pitch)", mutils.FormatWOZeros(pitch, 2))
+	}
+
+	log.Println(fmt.Sprintf("Exporting \"%s\" at %s...", beatMap.Dir, suffix))
+
+	dir := filepath.Join(settings.General.GetSongsDir(), beatMap.Dir)
+
+	maps := loadSetDifficulties(beatMap.Dir)
+	if len(maps) == 0 {
+		log.Println("No osu!standard difficulties found, closing...")
+		return 1
+	}
+
+	fileMap, err := files.NewFileMap(dir)
+	if err != nil {
+		log.Println("Failed to read beatmap's directory:", err)
+		return 1
+	}
+
+	tmpDir, err := os.MkdirTemp("", "danser-export-")
+	if err != nil {
+		log.Println("Failed to create a temporary directory:", err)
+		return 1
+	}
+
+	defer os.RemoveAll(tmpDir)
+
+	bass.Init(true)
+
+	archive := make(map[string]string)
+	excluded := make(map[string]bool)
+
+	renderedAudio := make(map[string]string)
+
+	for _, bMap := range maps {
+		audioPath, err := fileMap.GetFile(bMap.Audio)
+		if err != nil {
+			log.Println(fmt.Sprintf("Audio file \"%s\" of [%s] doesn't exist, skipping...", bMap.Audio, bMap.Difficulty))
+			continue
+		}
+
+		excluded[strings.ToLower(audioPath)] = true
+
+		audioName, ok := renderedAudio[strings.ToLower(audioPath)]
+		if !ok {
+			audioName = strings.TrimSuffix(bMap.Audio, filepath.Ext(bMap.Audio)) + "_" + mutils.FormatWOZeros(speed, 2) + "x"
+			if pitch != 1 {
+				audioName += "_" + mutils.FormatWOZeros(pitch, 2) + "p"
+			}
+
+			audioName += ".mp3"
+
+			if err = renderRateAudio(audioPath, filepath.Join(tmpDir, audioName), speed, pitch); err != nil {
+				log.Println("Failed to render the audio:", err)
+				return 1
+			}
+
+			renderedAudio[strings.ToLower(audioPath)] = audioName
+			archive[audioName] = filepath.Join(tmpDir, audioName)
+		}
+
+		if bMap.Video != "" {
+			if videoPath, err := fileMap.GetFile(bMap.Video); err == nil {
+				excluded[strings.ToLower(videoPath)] = true
+			}
+		}
+
+		bMap.ApplyRate(speed)
+
+		if !math.IsNaN(ar) {
+			bMap.Diff.SetARCustom(ar)
+		}
+
+		if !math.IsNaN(od) {
+			bMap.Diff.SetODCustom(od)
+		}
+
+		if !math.IsNaN(cs) {
+			bMap.Diff.SetCSCustom(cs)
+		}
+
+		if !math.IsNaN(hp) {
+			bMap.Diff.SetHPCustom(hp)
+		}
+
+		bMap.Audio = audioName
+		bMap.Video = "" // can't be sped up
+		bMap.Difficulty = fmt.Sprintf("%s %s", bMap.Difficulty, suffix)
+		bMap.ID = 0
+		bMap.SetID = -1
+
+		osuName := files.FixName(fmt.Sprintf("%s - %s (%s) [%s].osu", bMap.Artist, bMap.Name, bMap.Creator, bMap.Difficulty))
+
+		// Storyboard can't be sped up, so it's not written
+		if err = beatmap.SaveBeatMap(bMap, filepath.Join(tmpDir, osuName), false); err != nil {
+			log.Println(fmt.Sprintf("Failed to save [%s]: %s", bMap.Difficulty, err))
+			return 1
+		}
+
+		archive[osuName] = filepath.Join(tmpDir, osuName)
+	}
+
+	if len(renderedAudio) == 0 {
+		log.Println("Nothing to export, closing...")
+		return 1
+	}
+
+	// Backgrounds, hit sounds and skin elements are copied as they are, storyboard can't be sped up
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		ext := strings.ToLower(filepath.Ext(path))
+		if ext == ".osu" || ext == ".osb" || excluded[strings.ToLower(path)] {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		archive[rel] = path
+
+		return nil
+	})
+
+	if err != nil {
+		log.Println("Failed to read beatmap's directory:", err)
+		return 1
+	}
+
+	name := output
+	if strings.TrimSpace(name) == "" {
+		name = files.FixName(fmt.Sprintf("%s - %s (%s) %s", beatMap.Artist, beatMap.Name, beatMap.Creator, suffix))
+	}
+
+	path := filepath.Join(settings.Recording.GetOutputDir(), name+".osz")
+
+	if err = utils.Zip(path, archive); err != nil {
+		log.Println("Failed to save the .osz file:", err)
+		return 1
+	}
+
+	log.Println("Exported beatmap set is available at:", path)
+
+	return 0
+}
+
+// loadSetDifficulties parses all osu!standard difficulties in beatmap set's directory with their objects
+func loadSetDifficulties(dir string) (maps []*beatmap.BeatMap) {
+	entries, err := os.ReadDir(filepath.Join(settings.General.GetSongsDir(), dir))
+	if err != nil {
+		log.Println("Failed to read beatmap's directory:", err)
+		return
+	}
+
+	settings.Objects.LoadSpinners = true // spinners are skipped by the parser otherwise
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".osu") {
+			continue
+		}
+
+		bMap := beatmap.NewBeatMap()
+		bMap.Dir = dir
+		bMap.File = entry.Name()
+
+		if err = beatmap.ParseBeatMap(bMap); err != nil {
+			log.Println(fmt.Sprintf("Failed to parse %s: %s", entry.Name(), err))
+			continue
+		}
+
+		if bMap.Mode != 0 {
+			log.Println(fmt.Sprintf("Skipping %s: only osu!standard difficulties are exported", entry.Name()))
+			continue
+		}
+
+		beatmap.ParseObjects(bMap, true, false)
+
+		maps = append(maps, bMap)
+	}
+
+	return
+}
+
+func renderRateAudio(source, destination string, speed, pitch float64) error {
+	log.Println(fmt.Sprintf("Rendering \"%s\"...", filepath.Base(source)))
+
+	track := bass.NewTrack(source)
+	if track == nil {
+		return fmt.Errorf("failed to load %s", source)
+	}
+
+	track.SetTempo(speed)
+	track.SetPitch(pitch)
+	track.PlayV(1)
+
+	defer track.Stop()
+
+	return ffmpeg.EncodeMixer(destination, track.GetLength()/speed)
+}
@@ -0,0 +1,161 @@
+package replayedit
+
+import (
+	"github.com/wieku/rplpa"
+	"math"
+)
+
+const seedFrameTime = -12345
+
+// Timeline holds replay frames with absolute times. Mania seed frame and the leading frame with 0 delta
+// (which is skipped by osu! and danser) are kept aside and restored by Encode.
+type Timeline struct {
+	Frames []*rplpa.ReplayData
+
+	lead *rplpa.ReplayData
+	seed *rplpa.ReplayData
+}
+
+// NewTimeline converts delta-timed replay frames to absolute times. Source frames are not modified.
+func NewTimeline(data []*rplpa.ReplayData) *Timeline {
+	timeline := &Timeline{
+		Frames: make([]*rplpa.ReplayData, 0, len(data)),
+	}
+
+	time := int64(0)
+
+	for i, frame := range data {
+		if frame.Time == seedFrameTime {
+			timeline.seed = copyFrame(frame)
+			continue
+		}
+
+		if i == 0 && frame.Time == 0 {
+			timeline.lead = copyFrame(frame)
+			continue
+		}
+
+		time += frame.Time
+
+		abs := copyFrame(frame)
+		abs.Time = time
+
+		timeline.Frames = append(timeline.Frames, abs)
+	}
+
+	return timeline
+}
+
+// Trim removes frames outside [start, end] range. Returns the number of removed frames.
+func (timeline *Timeline) Trim(start, end int64) int {
+	before := len(timeline.Frames)
+
+	frames := timeline.Frames[:0]
+
+	for _, frame := range timeline.Frames {
+		if frame.Time >= start && frame.Time <= end {
+			frames = append(frames, frame)
+		}
+	}
+
+	timeline.Frames = frames
+
+	return before - len(frames)
+}
+
+// Offset shifts all frames by given amount of milliseconds
+func (timeline *Timeline) Offset(offset int64) {
+	for _, frame := range timeline.Frames {
+		frame.Time += offset
+	}
+}
+
+// Repair removes frames with invalid positions, frames going back in time and duplicated frames. Returns the number of removed frames.
+func (timeline *Timeline) Repair() int {
+	before := len(timeline.Frames)
+
+	frames := timeline.Frames[:0]
+
+	var last *rplpa.ReplayData
+
+	for _, frame := range timeline.Frames {
+		if !isFinite(frame.MouseX) || !isFinite(frame.MouseY) {
+			continue
+		}
+
+		if frame.KeyPressed == nil {
+			frame.KeyPressed = &rplpa.KeyPressed{}
+		}
+
+		if last != nil {
+			if frame.Time < last.Time {
+				continue
+			}
+
+			if frame.Time == last.Time && frame.MouseX == last.MouseX && frame.MouseY == last.MouseY && *frame.KeyPressed == *last.KeyPressed {
+				continue
+			}
+		}
+
+		frames = append(frames, frame)
+		last = frame
+	}
+
+	timeline.Frames = frames
+
+	return before - len(frames)
+}
+
+// Duration returns the time of the last frame
+func (timeline *Timeline) Duration() int64 {
+	if len(timeline.Frames) == 0 {
+		return 0
+	}
+
+	return timeline.Frames[len(timeline.Frames)-1].Time
+}
+
+// Encode converts frames back to delta times, restoring the leading and seed frames
+func (timeline *Timeline) Encode() []*rplpa.ReplayData {
+	data := make([]*rplpa.ReplayData, 0, len(timeline.Frames)+2)
+
+	if timeline.lead != nil {
+		data = append(data, copyFrame(timeline.lead))
+	}
+
+	time := int64(0)
+
+	for _, frame := range timeline.Frames {
+		delta := copyFrame(frame)
+		delta.Time = frame.Time - time
+
+		if delta.KeyPressed == nil {
+			delta.KeyPressed = &rplpa.KeyPressed{}
+		}
+
+		time = frame.Time
+
+		data = append(data, delta)
+	}
+
+	if timeline.seed != nil {
+		data = append(data, copyFrame(timeline.seed))
+	}
+
+	return data
+}
+
+func copyFrame(frame *rplpa.ReplayData) *rplpa.ReplayData {
+	cp := *frame
+
+	if frame.KeyPressed != nil {
+		keys := *frame.KeyPressed
+		cp.KeyPressed = &keys
+	}
+
+	return &cp
+}
+
+func isFinite(v float32) bool {
+	return !math.IsNaN(float64(v)) && !math.IsInf(float64(v), 0)
+}
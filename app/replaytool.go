@@ -0,0 +1,204 @@
+package app
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	difficulty2 "github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/dance"
+	"github.com/wieku/danser-go/app/replayedit"
+	"github.com/wieku/danser-go/app/states"
+	"github.com/wieku/rplpa"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const replayCommand = "replay"
+
+var recalcMode bool
+var recalcPath string
+
+// runReplayTool edits a replay according to "danser-cli replay" arguments and saves it.
+// If score recalculation is requested, it returns arguments for a regular run simulating the edited replay.
+func runReplayTool(args []string) (runArgs []string, err error) {
+	fs := flag.NewFlagSet(replayCommand, flag.ContinueOnError)
+
+	out := fs.String("out", "", "Path of edited replay. Defaults to <input>_edited.osr")
+	trimStart := fs.Int64("trimstart", math.MinInt64, "Remove frames before given time in ms")
+	trimEnd := fs.Int64("trimend", math.MaxInt64, "Remove frames after given time in ms")
+	offset := fs.Int64("offset", 0, "Shift all frames by given amount of ms. Applied before trimming")
+	name := fs.String("name", "", "Change player's name")
+	mods := fs.String("mods", "", "Change replay's mods, for example HDDT. Use NM to remove all mods")
+	repair := fs.Bool("repair", false, "Remove frames with invalid positions, duplicated frames and frames going back in time")
+	recalc := fs.Bool("recalc", false, "Recalculate score, hit counts and max combo by simulating the edited replay. Beatmap has to be present in danser's database")
+	settingsVersion := fs.String("settings", "", "Settings used for score recalculation, same as main -settings flag")
+
+	fs.Usage = func() {
+		_, _ = fmt.Fprintln(fs.Output(), "Usage: danser-cli replay [flags] <replay.osr>")
+		fs.PrintDefaults()
+	}
+
+	if err = fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return nil, errors.New("exactly one replay file has to be provided")
+	}
+
+	input := fs.Arg(0)
+
+	data, err := os.ReadFile(input)
+	if err != nil {
+		return nil, err
+	}
+
+	replay, err := rplpa.ParseReplay(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse replay: %w", err)
+	}
+
+	if replay.ReplayData == nil || len(replay.ReplayData) == 0 {
+		return nil, errors.New("replay is missing input data")
+	}
+
+	timeline := replayedit.NewTimeline(replay.ReplayData)
+
+	log.Println(fmt.Sprintf("ReplayTool: Loaded \"%s\" by %s, %d frames, %dms", input, replay.Username, len(timeline.Frames), timeline.Duration()))
+
+	if *repair {
+		log.Println("ReplayTool: Removed broken frames:", timeline.Repair())
+	}
+
+	if *offset != 0 {
+		timeline.Offset(*offset)
+
+		for i := range replay.LifebarGraph {
+			replay.LifebarGraph[i].Time += int32(*offset)
+		}
+
+		log.Println(fmt.Sprintf("ReplayTool: Shifted frames by %dms", *offset))
+	}
+
+	if *trimStart != math.MinInt64 || *trimEnd != math.MaxInt64 {
+		if *trimStart > *trimEnd {
+			return nil, errors.New("-trimstart has to be lower than -trimend")
+		}
+
+		log.Println("ReplayTool: Trimmed frames:", timeline.Trim(*trimStart, *trimEnd))
+
+		lifebar := replay.LifebarGraph[:0]
+
+		for _, l := range replay.LifebarGraph {
+			if int64(l.Time) >= *trimStart && int64(l.Time) <= *trimEnd {
+				lifebar = append(lifebar, l)
+			}
+		}
+
+		replay.LifebarGraph = lifebar
+	}
+
+	if len(timeline.Frames) < 2 {
+		return nil, errors.New("edited replay has less than 2 frames")
+	}
+
+	replay.ReplayData = timeline.Encode()
+
+	if *name != "" {
+		log.Println(fmt.Sprintf("ReplayTool: Changed player's name: %s -> %s", replay.Username, *name))
+		replay.Username = *name
+	}
+
+	if *mods != "" {
+		newMods := difficulty2.ParseMods(*mods)
+		if !newMods.Compatible() {
+			return nil, errors.New("incompatible mods selected")
+		}
+
+		log.Println(fmt.Sprintf("ReplayTool: Changed mods: %s -> %s", difficulty2.Modifier(replay.Mods).String(), newMods.String()))
+		replay.Mods = uint32(newMods)
+	}
+
+	outPath := *out
+	if outPath == "" {
+		outPath = strings.TrimSuffix(input, filepath.Ext(input)) + "_edited.osr"
+	}
+
+	if err = writeReplay(outPath, replay); err != nil {
+		return nil, err
+	}
+
+	log.Println("ReplayTool: Replay saved to:", outPath)
+
+	if !*recalc {
+		return nil, nil
+	}
+
+	recalcMode = true
+	recalcPath = outPath
+
+	runArgs = []string{"-replay=" + outPath}
+
+	if *settingsVersion != "" {
+		runArgs = append(runArgs, "-settings="+*settingsVersion)
+	}
+
+	return runArgs, nil
+}
+
+func writeReplay(path string, replay *rplpa.Replay) error {
+	data, err := rplpa.WriteReplay(replay)
+	if err != nil {
+		return fmt.Errorf("failed to serialize replay: %w", err)
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+func mainLoopRecalc() {
+	p, _ := player.(*states.Player)
+
+	controller, ok := p.GetController().(*dance.ReplayController)
+	if !ok {
+		log.Println("Score recalculation requires a replay, skipping...")
+		return
+	}
+
+	log.Println("ReplayTool: Recalculating score...")
+
+	for !p.Update(1) {
+	}
+
+	score := controller.GetRuleset().GetScore(controller.GetCursors()[0])
+
+	replay, err := loadReplay(recalcPath)
+	if err != nil {
+		log.Println("ReplayTool: Failed to load edited replay:", err)
+		return
+	}
+
+	log.Println(fmt.Sprintf("ReplayTool: Score: %d -> %d", replay.Score, score.Score))
+	log.Println(fmt.Sprintf("ReplayTool: Hits (300/100/50/Miss): %d/%d/%d/%d -> %d/%d/%d/%d", replay.Count300, replay.Count100, replay.Count50, replay.CountMiss, score.Count300, score.Count100, score.Count50, score.CountMiss))
+	log.Println(fmt.Sprintf("ReplayTool: Max combo: %d -> %d", replay.MaxCombo, score.Combo))
+
+	replay.Score = int32(score.Score)
+	replay.Count300 = uint16(score.Count300)
+	replay.CountGeki = uint16(score.CountGeki)
+	replay.Count100 = uint16(score.Count100)
+	replay.CountKatu = uint16(score.CountKatu)
+	replay.Count50 = uint16(score.Count50)
+	replay.CountMiss = uint16(score.CountMiss)
+	replay.MaxCombo = uint16(score.Combo)
+	replay.Fullcombo = score.PerfectCombo
+
+	if err = writeReplay(recalcPath, replay); err != nil {
+		log.Println("ReplayTool: Failed to save recalculated replay:", err)
+		return
+	}
+
+	log.Println("ReplayTool: Recalculated replay saved to:", recalcPath)
+}
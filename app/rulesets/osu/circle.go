@@ -36,6 +36,17 @@ func (circle *Circle) Init(ruleSet *OsuRuleSet, object objects.IHitObject, playe
 	}
 }
 
+// getVisual returns the circle animated by player's hits, nil if they aren't shown
+func (circle *Circle) getVisual(player *difficultyPlayer) *objects.Circle {
+	if len(circle.players) == 1 {
+		return circle.hitCircle
+	}
+
+	visual, _ := player.viewObjects[circle.hitCircle.GetID()].(*objects.Circle)
+
+	return visual
+}
+
 func (circle *Circle) UpdateFor(_ *difficultyPlayer, _ int64, _ bool) bool {
 	return true
 }
@@ -69,7 +80,10 @@ func (circle *Circle) UpdateClickFor(player *difficultyPlayer, time int64) bool
 					hit := Miss
 
 					relative := int64(math.Abs(float64(time) - circle.hitCircle.GetEndTime()))
-					if relative < player.diff.Hit300 {
+
+					if circle.hitCircle.Target {
+						hit = getTargetResult(relative, player.cursor.RawPosition.Dst(position)/radius, player)
+					} else if relative < player.diff.Hit300 {
 						hit = Hit300
 					} else if relative < player.diff.Hit100 {
 						hit = Hit100
@@ -87,8 +101,8 @@ func (circle *Circle) UpdateClickFor(player *difficultyPlayer, time int64) bool
 							}
 						}
 
-						if len(circle.players) == 1 {
-							circle.hitCircle.Arm(hit != Miss, float64(time))
+						if visual := circle.getVisual(player); visual != nil {
+							visual.Arm(hit != Miss, float64(time))
 						}
 
 						circle.ruleSet.SendResult(time, player.cursor, circle, position.X, position.Y, hit, combo)
@@ -99,8 +113,8 @@ func (circle *Circle) UpdateClickFor(player *difficultyPlayer, time int64) bool
 					player.leftCondE = false
 					player.rightCondE = false
 
-					if action == Shake && len(circle.players) == 1 {
-						circle.hitCircle.Shake(float64(time))
+					if visual := circle.getVisual(player); action == Shake && visual != nil {
+						visual.Shake(float64(time))
 					}
 				}
 			} else if action == Click {
@@ -112,6 +126,20 @@ func (circle *Circle) UpdateClickFor(player *difficultyPlayer, time int64) bool
 	return !state.isHit
 }
 
+// getTargetResult judges Target Practice hits by the distance from the center of the target, timing only has to fit in 50's hit window
+func getTargetResult(relative int64, distance float32, player *difficultyPlayer) HitResult {
+	switch {
+	case relative >= player.diff.Hit50:
+		return Miss
+	case distance < 1.0/3:
+		return Hit300
+	case distance < 2.0/3:
+		return Hit100
+	}
+
+	return Hit50
+}
+
 func (circle *Circle) UpdatePostFor(player *difficultyPlayer, time int64, _ bool) bool {
 	state := circle.state[player]
 
@@ -119,8 +147,8 @@ func (circle *Circle) UpdatePostFor(player *difficultyPlayer, time int64, _ bool
 		position := circle.hitCircle.GetStackedPositionAtMod(float64(time), player.diff.Mods)
 		circle.ruleSet.SendResult(time, player.cursor, circle, position.X, position.Y, Miss, Reset)
 
-		if len(circle.players) == 1 {
-			circle.hitCircle.Arm(false, float64(time))
+		if visual := circle.getVisual(player); visual != nil {
+			visual.Arm(false, float64(time))
 		}
 
 		state.isHit = true
@@ -232,7 +232,11 @@ func (hp *HealthProcessor) AddResult(result HitResult) {
 	case SliderMiss:
 		hpAdd += difficulty.DifficultyRate(hp.diff.HPMod, -4.0, -15.0, -28.0)
 	case Miss:
-		hpAdd += difficulty.DifficultyRate(hp.diff.HPMod, -6.0, -25.0, -40.0)
+		if hp.diff.CheckModActive(difficulty.Target) { // Targets appear on every beat, so missing one is punished like a slider break
+			hpAdd += difficulty.DifficultyRate(hp.diff.HPMod, -4.0, -15.0, -28.0)
+		} else {
+			hpAdd += difficulty.DifficultyRate(hp.diff.HPMod, -6.0, -25.0, -40.0)
+		}
 	case Hit50:
 		hpAdd += hp.HpMultiplierNormal * difficulty.DifficultyRate(hp.diff.HPMod, 8*Hp50, Hp50, Hp50)
 	case Hit100:
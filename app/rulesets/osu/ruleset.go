@@ -68,6 +68,9 @@ type difficultyPlayer struct {
 	leftCondE       bool
 	rightCond       bool
 	rightCondE      bool
+
+	// Objects animated by player's hits when there's more than one player, by ID
+	viewObjects map[int64]objects.IHitObject
 }
 
 type scoreProcessor interface {
@@ -94,6 +97,13 @@ type Score struct {
 	PP           pp220930.PPv2Results
 }
 
+// HitError is the timing offset of a hit on a circle or slider head
+type HitError struct {
+	Number int64
+	Time   int64
+	Error  float64
+}
+
 type subSet struct {
 	player *difficultyPlayer
 
@@ -106,6 +116,8 @@ type subSet struct {
 
 	numObjects uint
 
+	hitErrors []HitError
+
 	ppv2 *pp220930.PPv2
 
 	recoveries int
@@ -137,7 +149,9 @@ type OsuRuleSet struct {
 	experimentalPP bool
 }
 
-func NewOsuRuleset(beatMap *beatmap.BeatMap, cursors []*graphics.Cursor, mods []difficulty.Modifier) *OsuRuleSet {
+// NewOsuRuleset creates a ruleset for given cursors playing with given mods. Difficulty attributes in cachedSteps (keyed by
+// masked mods) are used instead of calculating them if they match the beatmap, cachedSteps can be nil.
+func NewOsuRuleset(beatMap *beatmap.BeatMap, cursors []*graphics.Cursor, mods []difficulty.Modifier, cachedSteps map[difficulty.Modifier][]pp220930.Attributes) *OsuRuleSet {
 	log.Println("Creating osu! ruleset...")
 
 	ruleset := new(OsuRuleSet)
@@ -167,7 +181,11 @@ func NewOsuRuleset(beatMap *beatmap.BeatMap, cursors []*graphics.Cursor, mods []
 		maskedMods := difficulty.GetDiffMaskedMods(mods[i])
 
 		if ruleset.oppDiffs[maskedMods] == nil {
-			ruleset.oppDiffs[maskedMods] = pp220930.CalculateStep(ruleset.beatMap.HitObjects, diff)
+			if steps := cachedSteps[maskedMods]; len(steps) == len(beatMap.HitObjects) {
+				ruleset.oppDiffs[maskedMods] = steps
+			} else {
+				ruleset.oppDiffs[maskedMods] = pp220930.CalculateStep(ruleset.beatMap.HitObjects, diff)
+			}
 
 			star := ruleset.oppDiffs[maskedMods][len(ruleset.oppDiffs[maskedMods])-1]
 
@@ -260,6 +278,11 @@ func NewOsuRuleset(beatMap *beatmap.BeatMap, cursors []*graphics.Cursor, mods []
 	return ruleset
 }
 
+// GetDifficultySteps returns successive difficulty attributes for every mod combination used by players, keyed by masked mods
+func (set *OsuRuleSet) GetDifficultySteps() map[difficulty.Modifier][]pp220930.Attributes {
+	return set.oppDiffs
+}
+
 func (set *OsuRuleSet) Update(time int64) {
 	if len(set.processed) > 0 {
 		for i := 0; i < len(set.processed); i++ {
@@ -435,6 +458,19 @@ func (set *OsuRuleSet) SendResult(time int64, cursor *graphics.Cursor, src HitOb
 		return
 	}
 
+	object := set.beatMap.HitObjects[number]
+
+	_, isCircle := object.(*objects.Circle)
+	_, isSlider := object.(*objects.Slider)
+
+	if (isCircle && result&BaseHits > 0) || (isSlider && result&SliderStart > 0) {
+		subSet.hitErrors = append(subSet.hitErrors, HitError{
+			Number: number,
+			Time:   time,
+			Error:  float64(time) - object.GetStartTime(),
+		})
+	}
+
 	if (subSet.player.diff.Mods.Active(difficulty.SuddenDeath|difficulty.Perfect) && comboResult == Reset) ||
 		(subSet.player.diff.Mods.Active(difficulty.Perfect) && (result&BaseHitsM > 0 && result&BaseHitsM != Hit300)) {
 		if result&BaseHitsM > 0 {
@@ -704,11 +740,28 @@ func (set *OsuRuleSet) GetScore(cursor *graphics.Cursor) Score {
 	return *(set.cursors[cursor].score)
 }
 
+// GetHitErrors returns timing offsets of all hit circles and slider heads in hit order
+func (set *OsuRuleSet) GetHitErrors(cursor *graphics.Cursor) []HitError {
+	return set.cursors[cursor].hitErrors
+}
+
 func (set *OsuRuleSet) GetHP(cursor *graphics.Cursor) float64 {
 	subSet := set.cursors[cursor]
 	return subSet.hp.Health / MaxHp
 }
 
+// SetViewObjects sets a copy of beatmap's objects animated by cursor's hits, used when every player has its own playfield.
+// Beatmap's own objects are animated only if there's one player.
+func (set *OsuRuleSet) SetViewObjects(cursor *graphics.Cursor, objs []objects.IHitObject) {
+	player := set.cursors[cursor].player
+
+	player.viewObjects = make(map[int64]objects.IHitObject, len(objs))
+
+	for _, o := range objs {
+		player.viewObjects[o.GetID()] = o
+	}
+}
+
 func (set *OsuRuleSet) GetPlayer(cursor *graphics.Cursor) *difficultyPlayer {
 	subSet := set.cursors[cursor]
 	return subSet.player
@@ -51,6 +51,17 @@ func (slider *Slider) GetNumber() int64 {
 	return slider.hitSlider.GetID()
 }
 
+// getVisual returns the slider animated by player's hits, nil if they aren't shown
+func (slider *Slider) getVisual(player *difficultyPlayer) *objects.Slider {
+	if len(slider.players) == 1 {
+		return slider.hitSlider
+	}
+
+	visual, _ := player.viewObjects[slider.hitSlider.GetID()].(*objects.Slider)
+
+	return visual
+}
+
 func (slider *Slider) IsSliding(player *difficultyPlayer) bool {
 	return slider.state[player].sliding
 }
@@ -145,8 +156,8 @@ func (slider *Slider) UpdateClickFor(player *difficultyPlayer, time int64) bool
 				}
 
 				if hit != Ignore {
-					if len(slider.players) == 1 {
-						slider.hitSlider.HitEdge(0, float64(time), hit != SliderMiss)
+					if visual := slider.getVisual(player); visual != nil {
+						visual.HitEdge(0, float64(time), hit != SliderMiss)
 					}
 
 					slider.ruleSet.SendResult(time, player.cursor, slider, position.X, position.Y, hit, combo)
@@ -232,8 +243,8 @@ func (slider *Slider) UpdateFor(player *difficultyPlayer, time int64, processSli
 			state.sliding = true
 			state.slideStart = time
 
-			if len(slider.players) == 1 {
-				slider.hitSlider.InitSlide(float64(time))
+			if visual := slider.getVisual(player); visual != nil {
+				visual.InitSlide(float64(time))
 			}
 		}
 
@@ -277,8 +288,8 @@ func (slider *Slider) UpdateFor(player *difficultyPlayer, time int64, processSli
 		}
 
 		if !allowable && state.sliding && state.scored+state.missed < len(state.points) {
-			if len(slider.players) == 1 {
-				slider.hitSlider.KillSlide(float64(time))
+			if visual := slider.getVisual(player); visual != nil {
+				visual.KillSlide(float64(time))
 			}
 
 			state.sliding = false
@@ -292,8 +303,8 @@ func (slider *Slider) UpdatePostFor(player *difficultyPlayer, time int64, proces
 	state := slider.state[player]
 
 	if time > int64(slider.hitSlider.GetStartTime())+player.diff.Hit50 && !state.isStartHit {
-		if len(slider.players) == 1 && !state.isHit { //don't fade if slider already ended (and armed the start)
-			slider.hitSlider.ArmStart(false, float64(time))
+		if visual := slider.getVisual(player); visual != nil && !state.isHit { //don't fade if slider already ended (and armed the start)
+			visual.ArmStart(false, float64(time))
 		}
 
 		position := slider.hitSlider.GetStackedEndPositionMod(player.diff.Mods)
@@ -313,8 +324,8 @@ func (slider *Slider) UpdatePostFor(player *difficultyPlayer, time int64, proces
 	}
 
 	if (time >= int64(slider.hitSlider.GetEndTime()) || (processSliderEndsAhead && int64(slider.hitSlider.GetEndTime())-time == 1)) && !state.isHit {
-		if len(slider.players) == 1 && !state.isStartHit {
-			slider.hitSlider.ArmStart(false, float64(time))
+		if visual := slider.getVisual(player); visual != nil && !state.isStartHit {
+			visual.ArmStart(false, float64(time))
 		}
 
 		if state.startResult != Miss {
@@ -326,8 +337,8 @@ func (slider *Slider) UpdatePostFor(player *difficultyPlayer, time int64, proces
 
 		rate := float64(state.scored) / float64(len(state.points)+1)
 
-		if rate > 0 && len(slider.players) == 1 {
-			slider.hitSlider.HitEdge(len(slider.hitSlider.TickReverse), float64(time), true)
+		if visual := slider.getVisual(player); rate > 0 && visual != nil {
+			visual.HitEdge(len(visual.TickReverse), float64(time), true)
 		}
 
 		if rate == 1.0 {
@@ -38,6 +38,17 @@ func (spinner *Spinner) GetNumber() int64 {
 	return spinner.hitSpinner.GetID()
 }
 
+// getVisual returns the spinner animated by player's actions, nil if they aren't shown
+func (spinner *Spinner) getVisual(player *difficultyPlayer) *objects.Spinner {
+	if len(spinner.players) == 1 {
+		return spinner.hitSpinner
+	}
+
+	visual, _ := player.viewObjects[spinner.hitSpinner.GetID()].(*objects.Spinner)
+
+	return visual
+}
+
 func (spinner *Spinner) Init(ruleSet *OsuRuleSet, object objects.IHitObject, players []*difficultyPlayer) {
 	spinner.ruleSet = ruleSet
 	spinner.hitSpinner = object.(*objects.Spinner)
@@ -102,11 +113,11 @@ func (spinner *Spinner) UpdateFor(player *difficultyPlayer, time int64, _ bool)
 
 			state.currentVelocity = max(-0.05, min(state.currentVelocity, 0.05))
 
-			if len(spinner.players) == 1 {
+			if visual := spinner.getVisual(player); visual != nil {
 				if state.currentVelocity == 0 {
-					spinner.hitSpinner.PauseSpinSample()
+					visual.PauseSpinSample()
 				} else {
-					spinner.hitSpinner.StartSpinSample()
+					visual.StartSpinSample()
 				}
 			}
 
@@ -168,10 +179,10 @@ func (spinner *Spinner) UpdateFor(player *difficultyPlayer, time int64, _ bool)
 			state.rotationCountFD += rotationAddition
 			state.rotationCountF += float32(math.Abs(float64(float32(rotationAddition)) / math.Pi))
 
-			if len(spinner.players) == 1 {
-				spinner.hitSpinner.SetRotation(player.diff.GetModifiedTime(state.rotationCountFD))
-				spinner.hitSpinner.SetRPM(state.rpm)
-				spinner.hitSpinner.UpdateCompletion(float64(state.rotationCountF) / float64(state.requirement))
+			if visual := spinner.getVisual(player); visual != nil {
+				visual.SetRotation(player.diff.GetModifiedTime(state.rotationCountFD))
+				visual.SetRPM(state.rpm)
+				visual.UpdateCompletion(float64(state.rotationCountF) / float64(state.requirement))
 			}
 
 			state.rotationCount = int64(state.rotationCountF)
@@ -179,13 +190,13 @@ func (spinner *Spinner) UpdateFor(player *difficultyPlayer, time int64, _ bool)
 			if state.rotationCount != state.lastRotationCount {
 				state.scoringRotationCount++
 
-				if state.scoringRotationCount == spinner.getRequirementClear(player) && len(spinner.players) == 1 {
-					spinner.hitSpinner.Clear()
+				if visual := spinner.getVisual(player); state.scoringRotationCount == spinner.getRequirementClear(player) && visual != nil {
+					visual.Clear()
 				}
 
 				if state.scoringRotationCount > state.requirement+3 && (state.scoringRotationCount-(state.requirement+3))%2 == 0 {
-					if len(spinner.players) == 1 {
-						spinner.hitSpinner.Bonus()
+					if visual := spinner.getVisual(player); visual != nil {
+						visual.Bonus()
 					}
 
 					spinner.ruleSet.SendResult(time, player.cursor, spinner, spinnerPosition.X, spinnerPosition.Y, SpinnerBonus, Hold)
@@ -222,9 +233,9 @@ func (spinner *Spinner) UpdatePostFor(player *difficultyPlayer, time int64, _ bo
 			combo = Increase
 		}
 
-		if len(spinner.players) == 1 {
-			spinner.hitSpinner.StopSpinSample()
-			spinner.hitSpinner.Hit(float64(time), hit != Miss)
+		if visual := spinner.getVisual(player); visual != nil {
+			visual.StopSpinSample()
+			visual.Hit(float64(time), hit != Miss)
 		}
 
 		spinner.ruleSet.SendResult(time, player.cursor, spinner, spinner.hitSpinner.GetPosition().X, spinner.hitSpinner.GetPosition().Y, hit, combo)
@@ -228,6 +228,14 @@ func initGameplay() *gameplay {
 			Path:       "",
 			AboveHpBar: false,
 		},
+		Ghost: &ghost{
+			CursorOpacity:       0.4,
+			ShowIndicators:      true,
+			IndicatorDuration:   1.0,
+			TimingThreshold:     10,
+			PositionThreshold:   16,
+			ShowScoreDifference: true,
+		},
 		HUDFont:                 "",
 		ShowResultsScreen:       true,
 		ResultsScreenTime:       5,
@@ -255,6 +263,7 @@ type gameplay struct {
 	Mods                    *mods
 	Boundaries              *boundaries
 	Underlay                *underlay
+	Ghost                   *ghost
 	HUDFont                 string  `label:"Overlay (HUD) font" file:"Select HUD font" filter:"TrueType/OpenType Font (*.ttf, *.otf)|ttf,otf" tooltip:"Sets the font that will be used for PP/UR/hit counts" liveedit:"false"`
 	ShowResultsScreen       bool    `liveedit:"false"`
 	ResultsScreenTime       float64 `label:"Results screen duration" min:"1" max:"20" format:"%.1fs" liveedit:"false"`
@@ -412,3 +421,12 @@ type underlay struct {
 	Path       string `file:"Select underlay image" filter:"PNG file (*.png)|png" tooltip:"PNG file that will be used as HUD background (similar to custom HP bar backgrounds). It's scaled automatically to fit the screen vertically" liveedit:"false"`
 	AboveHpBar bool   `label:"Show underlay above HP bar" tooltip:"Use this if HP bar background is large"`
 }
+
+type ghost struct {
+	CursorOpacity       float64 `label:"Ghost cursor opacity" scale:"100" format:"%.0f%%"`
+	ShowIndicators      bool    `label:"Show divergence indicators" tooltip:"Shows timing and position differences between the plays on every object"`
+	IndicatorDuration   float64 `min:"0.1" max:"5" format:"%.1fs" showif:"ShowIndicators=true"`
+	TimingThreshold     float64 `label:"Highlighted timing difference" min:"1" max:"100" format:"%.0fms" showif:"ShowIndicators=true"`
+	PositionThreshold   float64 `label:"Highlighted position difference" min:"1" max:"128" format:"%.0f o!px" showif:"ShowIndicators=true"`
+	ShowScoreDifference bool
+}
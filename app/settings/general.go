@@ -17,6 +17,7 @@ func initGeneral() *general {
 		DiscordPresenceOn: true,
 		UnpackOszFiles:    true,
 		VerboseImportLogs: false,
+		ChangeJournal:     true,
 	}
 }
 
@@ -39,6 +40,10 @@ type general struct {
 	// Whether import details should be shown. If false, only failures will be logged.
 	VerboseImportLogs bool
 
+	// Whether danser should remember modification times of beatmap directories, unmodified directories are skipped on start
+	// and their .osu files are compared with the database once a week. Changes made while danser or its launcher is running are applied right away.
+	ChangeJournal bool `tooltip:"Directories not modified since the last scan are skipped on start, their .osu files are checked once a week.\nChanges made while danser or its launcher is running are applied right away"`
+
 	songsDir   *string
 	skinsDir   *string
 	replaysDir *string
@@ -85,3 +90,8 @@ func (g *general) GetReplaysDir() string {
 
 	return *g.replaysDir
 }
+
+// GetOsuDir returns osu!stable's installation directory, assumed to be the parent of Songs directory
+func (g *general) GetOsuDir() string {
+	return filepath.Dir(g.GetSongsDir())
+}
@@ -9,6 +9,7 @@ var START = 0.0
 var END = math.Inf(1)
 var KNOCKOUT = false
 var KNOCKOUTREPLAYS []string = nil
+var KNOCKOUTTEAMS []*KnockoutTeam = nil
 var PLAYERS = 1
 var DIVIDES = 1
 var SPEED = 1.0
@@ -16,4 +17,9 @@ var PITCH = 1.0
 var TAG = 1
 var RECORD = false
 var REPLAY = ""
+var GHOST = ""
+var SPECTATE = ""
+var SPECTATORNAME = ""
 var LOCALOFFSET = 0
+var DETERMINISTIC = false
+var STREAM = ""
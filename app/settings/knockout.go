@@ -1,5 +1,11 @@
 package settings
 
+import (
+	color2 "github.com/wieku/danser-go/framework/math/color"
+	"strconv"
+	"strings"
+)
+
 var Knockout = initKnockout()
 
 func initKnockout() *knockout {
@@ -13,23 +19,38 @@ func initKnockout() *knockout {
 		RevivePlayersAtEnd:  false,
 		LiveSort:            true,
 		SortBy:              "Score",
+		TeamScoring:         "ScoreV1",
 		HideOverlayOnBreaks: false,
+		ShowStandings:       false,
+		ExportTimeline:      false,
+		TimelineFormat:      "json",
 		MinCursorSize:       3.0,
 		MaxCursorSize:       7.0,
 		AddDanser:           false,
 		DanserName:          "danser",
+		Selection: &knockoutSelection{
+			SortBy:        "Score",
+			TopN:          0,
+			BestPerPlayer: false,
+			IncludeMods:   "",
+			ExcludeMods:   "",
+			From:          "",
+			To:            "",
+			MinCombo:      0,
+			StableReplays: false,
+		},
 	}
 }
 
 type knockout struct {
-	// Knockout mode. More info below
-	Mode KnockoutMode `combo:"0|Combo Break,1|Max Combo,2|Replay Showcase,3|Vs Mode,4|SS or Quit" liveedit:"false"`
+	// Knockout mode. More info below. Team vs Team falls back to Combo Break if no teams are defined
+	Mode KnockoutMode `combo:"0|Combo Break,1|Max Combo,2|Replay Showcase,3|Vs Mode,4|SS or Quit,5|Team vs Team,6|Split Screen" liveedit:"false" tooltip:"Team vs Team needs teams defined with -teams flag or in -knockout2 entries, otherwise Combo Break is used"`
 
 	// In Mode = ComboBreak it won't knock out the player if they break combo before GraceEndTime (in seconds)
 	GraceEndTime float64 `string:"true" min:"-10" max:"1000000" showif:"Mode=0"`
 
 	// In Mode = XReplays it will show combo break bubble if combo was bigger than BubbleMinimumCombo
-	BubbleMinimumCombo int `label:"Minimum combo to show break bubble" string:"true" min:"1" max:"1000000" showif:"Mode=2"`
+	BubbleMinimumCombo int `label:"Minimum combo to show break bubble" string:"true" min:"1" max:"1000000" showif:"Mode=2,5"`
 
 	// Exclude plays which contain one of the mods set here
 	ExcludeMods string `skip:"true" label:"Excluded mods (legacy)" tooltip:"Applicable only to classic knockout" liveedit:"false"`
@@ -40,12 +61,18 @@ type knockout struct {
 	// Max players shown (excluding danser) on a map. Caps at 50.
 	MaxPlayers int `skip:"true" label:"Max players loaded (legacy)" string:"true" min:"0" max:"100" tooltip:"Applicable only to classic knockout"`
 
+	// Rules used to pick replays in classic knockout
+	Selection *knockoutSelection `label:"Replay selection" tooltip:"Applicable only to classic knockout" liveedit:"false"`
+
 	// Min players shown on a map.
 	MinPlayers int `label:"Minimum alive players" string:"true" min:"0" max:"100" showif:"Mode=0,1,4"`
 
 	// Whether knocked out players should appear on map end
 	RevivePlayersAtEnd bool `showif:"Mode=0,1,4"`
 
+	// In Mode = TeamVsTeam teams are compared by the sum of players' ScoreV1 or ScoreV2
+	TeamScoring string `combo:"ScoreV1|Sum of ScoreV1,ScoreV2|Sum of ScoreV2" showif:"Mode=5" tooltip:"ScoreV2 forces ScoreV2 scoring for all players" liveedit:"false"`
+
 	// Whether scores should be sorted in real time
 	LiveSort bool
 
@@ -55,6 +82,15 @@ type knockout struct {
 	// Whether knockout overlay (player list with stats) should be hidden in breaks
 	HideOverlayOnBreaks bool
 
+	// Whether final standings should be shown at the end of the map
+	ShowStandings bool `label:"Show final standings"`
+
+	// Whether eliminations, combo breaks and final standings should be saved next to the recorded video
+	ExportTimeline bool `label:"Export elimination timeline" tooltip:"Saves eliminations, combo breaks and final standings next to the recorded video" liveedit:"false"`
+
+	// Format of the exported timeline. CSV exports events and standings to separate files
+	TimelineFormat string `combo:"json|JSON,csv|CSV" showif:"ExportTimeline=true" liveedit:"false"`
+
 	//Minimum cursor size (when all players are alive)
 	MinCursorSize float64 `min:"1" max:"20"`
 
@@ -66,6 +102,33 @@ type knockout struct {
 	DanserName string `label:"Danser's name" tooltip:"It's also used in danser replay mode" liveedit:"false"`
 }
 
+type knockoutSelection struct {
+	// Replays are ranked by Score, PP or Accuracy when picking top ones
+	SortBy string `label:"Rank replays by" combo:"Score,PP,Accuracy"`
+
+	// Only N best replays are loaded, 0 disables the limit
+	TopN int `label:"Top N replays" string:"true" min:"0" max:"1000" tooltip:"0 disables the limit"`
+
+	// Only the best replay of each player is loaded
+	BestPerPlayer bool `label:"Best replay per player"`
+
+	// All of these mods have to be present in a replay
+	IncludeMods string `label:"Required mods" tooltip:"For example: HDDT"`
+
+	// Replays containing any of these mods are excluded
+	ExcludeMods string `label:"Excluded mods" tooltip:"For example: NFEZ"`
+
+	// Replays played before/after given dates (YYYY-MM-DD) are excluded, empty disables the limit
+	From string `label:"Played after" tooltip:"Date in YYYY-MM-DD format, empty disables the limit"`
+	To   string `label:"Played before" tooltip:"Date in YYYY-MM-DD format, empty disables the limit"`
+
+	// Replays with lower max combo are excluded
+	MinCombo int `label:"Minimum max combo" string:"true" min:"0" max:"100000"`
+
+	// Replays of local scores from osu!'s Data/r directory are loaded too, they are found using imported scores.db
+	StableReplays bool `label:"Include osu! local replays" tooltip:"Loads replays of local scores from osu!'s Data/r folder"`
+}
+
 type KnockoutMode int
 
 const (
@@ -83,4 +146,63 @@ const (
 
 	// Forced Perfect mod
 	SSOrQuit
+
+	// XReplays but players are grouped in teams and team score totals are compared
+	TeamVsTeam
+
+	// Up to 4 replays are shown on separate playfields, each with its own hit objects and HUD
+	SplitScreen
 )
+
+func (mode KnockoutMode) String() string {
+	switch mode {
+	case ComboBreak:
+		return "Combo Break"
+	case MaxCombo:
+		return "Max Combo"
+	case XReplays:
+		return "Replay Showcase"
+	case OneVsOne:
+		return "Vs Mode"
+	case SSOrQuit:
+		return "SS or Quit"
+	case TeamVsTeam:
+		return "Team vs Team"
+	case SplitScreen:
+		return "Split Screen"
+	}
+
+	return "Unknown"
+}
+
+// KnockoutTeam describes a team used in TeamVsTeam knockout mode
+type KnockoutTeam struct {
+	Name string `json:"name"`
+
+	// Hex color (#RRGGBB) of the team, picked automatically if empty
+	Color string `json:"color"`
+
+	// Usernames or paths of replay files belonging to this team
+	Players []string `json:"players"`
+}
+
+// GetColor returns team's color, falling back to a hue derived from team's index if color is not set or invalid
+func (team *KnockoutTeam) GetColor(index int) color2.Color {
+	hex := strings.TrimPrefix(strings.TrimSpace(team.Color), "#")
+
+	if len(hex) == 6 {
+		if val, err := strconv.ParseUint(hex, 16, 32); err == nil {
+			return color2.NewIRGB(uint8(val>>16), uint8(val>>8), uint8(val))
+		}
+	}
+
+	hue := float32(index) * 137.5
+	if index < len(defaultTeamHues) {
+		hue = defaultTeamHues[index]
+	}
+
+	return color2.NewHSV(hue, 0.65, 1)
+}
+
+// red, blue, green, yellow, purple, cyan
+var defaultTeamHues = []float32{0, 215, 120, 50, 280, 180}
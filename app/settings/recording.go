@@ -87,7 +87,17 @@ func initRecording() *recording {
 		CustomAudioSettings: &custom{
 			CustomOptions: "",
 		},
-		AudioFilters:   "",
+		AudioFilters: "",
+		AudioStems: &audioStems{
+			Enabled: false,
+			Format:  "wav",
+		},
+		Thumbnail: &thumbnail{
+			Enabled: false,
+			Width:   1280,
+			Height:  720,
+			Layout:  "card",
+		},
 		OutputDir:      "videos",
 		Container:      "mp4",
 		ShowFFmpegLogs: true,
@@ -124,9 +134,11 @@ type recording struct {
 	FLACSettings        *flacSettings      `json:"flac" label:"FLAC Settings" showif:"AudioCodec=flac"`
 	CustomAudioSettings *custom            `json:"customAudio" label:"Custom Audio Settings" showif:"AudioCodec=!"`
 	//AudioOptions        string             `label:"Audio Encoder Options"`
-	AudioFilters   string `label:"FFmpeg Audio Filters"`
-	OutputDir      string `path:"Select video output directory"`
-	Container      string `combo:"mp4,mkv"`
+	AudioFilters   string      `label:"FFmpeg Audio Filters"`
+	AudioStems     *audioStems `label:"Audio Stems"`
+	Thumbnail      *thumbnail  `label:"Thumbnail"`
+	OutputDir      string      `path:"Select video output directory"`
+	Container      string      `combo:"mp4,mkv"`
 	ShowFFmpegLogs bool
 	MotionBlur     *motionblur
 
@@ -190,6 +202,19 @@ type motionblur struct {
 	BlendWeights         *blendWeights `json:",omitempty"` // Deprecated
 }
 
+type audioStems struct {
+	Enabled bool   `label:"Export audio stems" tooltip:"Additionally saves music, hitsounds and storyboard samples as separate audio files next to the video"`
+	Format  string `combo:"wav|WAV,flac|FLAC" showif:"Enabled=true"`
+}
+
+type thumbnail struct {
+	Enabled    bool   `label:"Save thumbnail" tooltip:"Saves a PNG results card with map, player, mods, accuracy, pp and rank next to the video after recording.\nOnly single player renders are supported"`
+	resolution string `vector:"true" combo:"640x360|360p,1280x720|720p (HD),1920x1080|1080p (FullHD),custom" left:"Width" right:"Height" showif:"Enabled=true"`
+	Width      int    `min:"1" max:"7680"`
+	Height     int    `min:"1" max:"4320"`
+	Layout     string `combo:"card|Card,ranking|Ranking screen" showif:"Enabled=true"`
+}
+
 type blendWeights struct {
 	UseManualWeights bool
 	ManualWeights    string  `showif:"UseManualWeights=true"`
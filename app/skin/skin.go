@@ -36,6 +36,7 @@ var soundLock = &sync.Mutex{}
 var textureLock = &sync.Mutex{}
 
 var atlas *texture.TextureAtlas
+var singleTextures []*texture.TextureSingle
 
 var animationCache = make(map[string][]*texture.TextureRegion)
 
@@ -122,6 +123,43 @@ func tryLoadSkin(name, fallbackName string) {
 	}
 }
 
+// Unload disposes all textures and drops fonts and samples loaded from skins so the skin set in settings is loaded again on next access.
+// Must be called from the GL thread when nothing uses skin textures anymore.
+func Unload() {
+	fontLock.Lock()
+	soundLock.Lock()
+	textureLock.Lock()
+
+	defer fontLock.Unlock()
+	defer soundLock.Unlock()
+	defer textureLock.Unlock()
+
+	info = nil
+
+	skinPathCache = nil
+	fallbackPathCache = nil
+
+	if atlas != nil {
+		atlas.Dispose()
+		atlas = nil
+	}
+
+	for _, tx := range singleTextures {
+		tx.Dispose()
+	}
+
+	singleTextures = nil
+
+	animationCache = make(map[string][]*texture.TextureRegion)
+	skinCache = make(map[string]*texture.TextureRegion)
+	fallbackCache = make(map[string]*texture.TextureRegion)
+	defaultCache = make(map[string]*texture.TextureRegion)
+	fontCache = make(map[string]*font.Font)
+	sampleCache = make(map[string]*bass.Sample)
+
+	sourceCache = make(map[*texture.TextureRegion]Source)
+}
+
 func GetInfo() *SkinInfo {
 	checkInit()
 	return info
@@ -390,6 +428,8 @@ func loadTexture(name string, source Source) *texture.TextureRegion {
 				tx := texture.NewTextureSingle(image.Width, image.Height, mipmaps)
 				tx.SetData(0, 0, image.Width, image.Height, image.Data)
 
+				singleTextures = append(singleTextures, tx)
+
 				reg := tx.GetRegion()
 				rg = &reg
 
@@ -492,6 +532,11 @@ func AddBeatmapColor(data []string) {
 	})
 }
 
+func ResetBeatmapColors() {
+	beatmapColorsI = nil
+	beatmapColors = nil
+}
+
 func FinishBeatmapColors() {
 	if len(beatmapColorsI) > 0 {
 		sort.SliceStable(beatmapColorsI, func(i, j int) bool {
@@ -0,0 +1,249 @@
+package spectator
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/wieku/rplpa"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Server receives replay frames streamed by an external client (tournament client, test feeder etc.).
+// Frames use the same text format as decompressed .osr data: "delta|x|y|keys", separated by commas or new lines.
+type Server struct {
+	address string
+
+	listener   net.Listener
+	httpServer *http.Server
+
+	mutex       sync.Mutex
+	frames      []*rplpa.ReplayData
+	connections int
+}
+
+// Listen starts accepting connections on given address. Supported forms:
+// "tcp://host:port" (or just "host:port") for raw TCP stream and "ws://host:port/path" for WebSocket messages.
+func Listen(address string) (*Server, error) {
+	server := &Server{address: address}
+
+	scheme, host, found := strings.Cut(address, "://")
+	if !found {
+		scheme, host = "tcp", address
+	}
+
+	path := "/"
+	if i := strings.Index(host, "/"); i > -1 {
+		host, path = host[:i], host[i:]
+	}
+
+	listener, err := net.Listen("tcp", host)
+	if err != nil {
+		return nil, err
+	}
+
+	server.listener = listener
+
+	switch strings.ToLower(scheme) {
+	case "tcp":
+		go server.acceptTCP()
+	case "ws":
+		mux := http.NewServeMux()
+		mux.HandleFunc(path, server.handleWebSocket)
+
+		server.httpServer = &http.Server{Handler: mux}
+
+		go func() {
+			if err := server.httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Println("Spectator: Server stopped:", err)
+			}
+		}()
+	default:
+		_ = listener.Close()
+		return nil, fmt.Errorf("unsupported scheme: %s", scheme)
+	}
+
+	log.Println("Spectator: Waiting for frames on:", address)
+
+	return server, nil
+}
+
+// Poll returns frames received since the last call
+func (server *Server) Poll() (frames []*rplpa.ReplayData) {
+	server.mutex.Lock()
+
+	frames = server.frames
+	server.frames = nil
+
+	server.mutex.Unlock()
+
+	return
+}
+
+// IsConnected checks whether any client is streaming at the moment
+func (server *Server) IsConnected() bool {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	return server.connections > 0
+}
+
+func (server *Server) Close() {
+	if server.httpServer != nil {
+		_ = server.httpServer.Close()
+	} else {
+		_ = server.listener.Close()
+	}
+}
+
+func (server *Server) acceptTCP() {
+	for {
+		conn, err := server.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Println("Spectator: Failed to accept connection:", err)
+			}
+
+			return
+		}
+
+		go server.readTCP(conn)
+	}
+}
+
+func (server *Server) readTCP(conn net.Conn) {
+	server.connected(conn)
+	defer server.disconnected(conn)
+
+	parser := new(frameParser)
+	reader := bufio.NewReader(conn)
+	buf := make([]byte, 4096)
+
+	for {
+		n, err := reader.Read(buf)
+
+		server.push(parser.feed(buf[:n]))
+
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				log.Println("Spectator: Connection error:", err)
+			}
+
+			server.push(parser.flush())
+
+			return
+		}
+	}
+}
+
+func (server *Server) connected(conn net.Conn) {
+	server.mutex.Lock()
+	server.connections++
+	server.mutex.Unlock()
+
+	log.Println("Spectator: Client connected:", conn.RemoteAddr().String())
+}
+
+func (server *Server) disconnected(conn net.Conn) {
+	_ = conn.Close()
+
+	server.mutex.Lock()
+	server.connections--
+	server.mutex.Unlock()
+
+	log.Println("Spectator: Client disconnected:", conn.RemoteAddr().String())
+}
+
+func (server *Server) push(frames []*rplpa.ReplayData) {
+	if len(frames) == 0 {
+		return
+	}
+
+	server.mutex.Lock()
+	server.frames = append(server.frames, frames...)
+	server.mutex.Unlock()
+}
+
+// frameParser splits incoming data into frames, keeping incomplete ones until the rest arrives
+type frameParser struct {
+	partial []byte
+}
+
+func (parser *frameParser) feed(data []byte) (frames []*rplpa.ReplayData) {
+	for _, b := range data {
+		if b == ',' || b == '\n' || b == '\r' {
+			frames = parser.appendFrame(frames)
+			continue
+		}
+
+		parser.partial = append(parser.partial, b)
+	}
+
+	return
+}
+
+func (parser *frameParser) flush() []*rplpa.ReplayData {
+	return parser.appendFrame(nil)
+}
+
+func (parser *frameParser) appendFrame(frames []*rplpa.ReplayData) []*rplpa.ReplayData {
+	if len(parser.partial) == 0 {
+		return frames
+	}
+
+	frame, err := ParseFrame(string(parser.partial))
+	parser.partial = parser.partial[:0]
+
+	if err != nil {
+		log.Println("Spectator: Skipping invalid frame:", err)
+		return frames
+	}
+
+	return append(frames, frame)
+}
+
+// ParseFrame parses a single "delta|x|y|keys" replay frame
+func ParseFrame(text string) (*rplpa.ReplayData, error) {
+	spl := strings.Split(strings.TrimSpace(text), "|")
+	if len(spl) < 4 {
+		return nil, fmt.Errorf("expected 4 values, got %d: %s", len(spl), text)
+	}
+
+	delta, err := strconv.ParseFloat(spl[0], 64)
+	if err != nil {
+		return nil, fmt.Errorf("parsing time: %w", err)
+	}
+
+	mouseX, err := strconv.ParseFloat(spl[1], 32)
+	if err != nil {
+		return nil, fmt.Errorf("parsing x: %w", err)
+	}
+
+	mouseY, err := strconv.ParseFloat(spl[2], 32)
+	if err != nil {
+		return nil, fmt.Errorf("parsing y: %w", err)
+	}
+
+	keys, err := strconv.Atoi(spl[3])
+	if err != nil {
+		return nil, fmt.Errorf("parsing keys: %w", err)
+	}
+
+	return &rplpa.ReplayData{
+		Time:   int64(delta),
+		MouseX: float32(mouseX),
+		MouseY: float32(mouseY),
+		KeyPressed: &rplpa.KeyPressed{
+			LeftClick:  keys&rplpa.LEFTCLICK > 0,
+			RightClick: keys&rplpa.RIGHTCLICK > 0,
+			Key1:       keys&rplpa.KEY1 > 0,
+			Key2:       keys&rplpa.KEY2 > 0,
+			Smoke:      keys&rplpa.SMOKE > 0,
+		},
+	}, nil
+}
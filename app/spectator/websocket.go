@@ -0,0 +1,191 @@
+package spectator
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Minimal RFC 6455 server, only what's needed to receive frame messages
+
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+const maxMessageSize = 16 << 20
+
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+func (server *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") {
+		http.Error(w, "WebSocket upgrade expected", http.StatusBadRequest)
+		return
+	}
+
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		http.Error(w, "Missing Sec-WebSocket-Key", http.StatusBadRequest)
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "Connection can't be upgraded", http.StatusInternalServerError)
+		return
+	}
+
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		log.Println("Spectator: Failed to upgrade connection:", err)
+		return
+	}
+
+	hash := sha1.Sum([]byte(key + websocketGUID))
+
+	_, _ = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+	_, _ = rw.WriteString("Upgrade: websocket\r\n")
+	_, _ = rw.WriteString("Connection: Upgrade\r\n")
+	_, _ = rw.WriteString("Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(hash[:]) + "\r\n\r\n")
+
+	if err = rw.Flush(); err != nil {
+		_ = conn.Close()
+		return
+	}
+
+	server.readWebSocket(conn, rw.Reader)
+}
+
+func (server *Server) readWebSocket(conn net.Conn, reader *bufio.Reader) {
+	server.connected(conn)
+	defer server.disconnected(conn)
+
+	parser := new(frameParser)
+
+	var message []byte
+
+	for {
+		fin, opcode, payload, err := readWSFrame(reader)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				log.Println("Spectator: Connection error:", err)
+			}
+
+			return
+		}
+
+		switch opcode {
+		case opText, opBinary, opContinuation:
+			message = append(message, payload...)
+
+			if len(message) > maxMessageSize {
+				log.Println("Spectator: Message too big, closing connection")
+				_ = writeWSFrame(conn, opClose, []byte{0x03, 0xF1}) // 1009: message too big
+				return
+			}
+
+			if fin {
+				// Every message ends with a complete frame
+				server.push(append(parser.feed(message), parser.flush()...))
+				message = message[:0]
+			}
+		case opPing:
+			if err = writeWSFrame(conn, opPong, payload); err != nil {
+				return
+			}
+		case opClose:
+			_ = writeWSFrame(conn, opClose, payload)
+			return
+		}
+	}
+}
+
+func readWSFrame(reader *bufio.Reader) (fin bool, opcode byte, payload []byte, err error) {
+	var header [2]byte
+	if _, err = io.ReadFull(reader, header[:]); err != nil {
+		return
+	}
+
+	fin = header[0]&0x80 > 0
+	opcode = header[0] & 0x0F
+
+	masked := header[1]&0x80 > 0
+	length := uint64(header[1] & 0x7F)
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(reader, ext[:]); err != nil {
+			return
+		}
+
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(reader, ext[:]); err != nil {
+			return
+		}
+
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+
+	if length > maxMessageSize {
+		err = fmt.Errorf("frame too big: %d bytes", length)
+		return
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err = io.ReadFull(reader, mask[:]); err != nil {
+			return
+		}
+	}
+
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(reader, payload); err != nil {
+		return
+	}
+
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+
+	return
+}
+
+// writeWSFrame writes a single unmasked control frame, payload can't exceed 125 bytes
+func writeWSFrame(conn net.Conn, opcode byte, payload []byte) error {
+	if len(payload) > 125 {
+		payload = payload[:125]
+	}
+
+	_, err := conn.Write(append([]byte{0x80 | opcode, byte(len(payload))}, payload...))
+
+	return err
+}
+
+func headerContains(header http.Header, name, value string) bool {
+	for _, v := range header.Values(name) {
+		for _, token := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), value) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
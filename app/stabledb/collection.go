@@ -0,0 +1,54 @@
+package stabledb
+
+import (
+	"fmt"
+	"os"
+)
+
+// Collection is a named list of beatmap md5 hashes from osu!stable's collection.db
+type Collection struct {
+	Name string
+	MD5s []string
+}
+
+// ReadCollections parses osu!stable's collection.db
+func ReadCollections(path string) ([]*Collection, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	r := newReader(file)
+
+	_ = r.int32() // version
+
+	count := r.int32()
+
+	if r.err == nil && count < 0 {
+		return nil, fmt.Errorf("invalid number of collections: %d", count)
+	}
+
+	collections := make([]*Collection, 0, max(count, 0))
+
+	for i := 0; i < int(count) && r.err == nil; i++ {
+		collection := &Collection{Name: r.string()}
+
+		numMaps := r.int32()
+
+		for j := 0; j < int(numMaps) && r.err == nil; j++ {
+			if md5 := r.string(); md5 != "" {
+				collection.MD5s = append(collection.MD5s, md5)
+			}
+		}
+
+		collections = append(collections, collection)
+	}
+
+	if r.err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, r.err)
+	}
+
+	return collections, nil
+}
@@ -0,0 +1,182 @@
+package stabledb
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// Versions of osu!.db that changed its layout
+const (
+	versionFloatDifficulty = 20140609
+	versionNoEntrySize     = 20191106
+)
+
+// Beatmap holds metadata of a single difficulty from osu!stable's osu!.db that can't be read from .osu files
+type Beatmap struct {
+	Artist     string
+	Title      string
+	Difficulty string
+	Creator    string
+	MD5        string
+	File       string
+	Folder     string
+
+	// RankedStatus is osu!'s submission status: 0 - unknown, 1 - unsubmitted, 2 - pending/WIP/graveyard, 4 - ranked, 5 - approved, 6 - qualified, 7 - loved
+	RankedStatus byte
+
+	ID    int32
+	SetID int32
+	Mode  byte
+
+	LocalOffset  int16
+	OnlineOffset int16
+
+	Unplayed   bool
+	LastPlayed time.Time
+}
+
+// OsuDB is the content of osu!stable's osu!.db
+type OsuDB struct {
+	Version  int32
+	Player   string
+	Beatmaps []*Beatmap
+}
+
+// ReadOsuDB parses osu!stable's osu!.db
+func ReadOsuDB(path string) (*OsuDB, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	r := newReader(file)
+
+	db := &OsuDB{Version: r.int32()}
+
+	_ = r.int32() // folder count
+	_ = r.bool()  // account unlocked
+	_ = r.int64() // unlock date
+
+	db.Player = r.string()
+
+	count := r.int32()
+
+	if r.err == nil && count < 0 {
+		return nil, fmt.Errorf("invalid number of beatmaps: %d", count)
+	}
+
+	db.Beatmaps = make([]*Beatmap, 0, max(count, 0))
+
+	for i := 0; i < int(count) && r.err == nil; i++ {
+		db.Beatmaps = append(db.Beatmaps, readBeatmap(r, db.Version))
+	}
+
+	if r.err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, r.err)
+	}
+
+	return db, nil
+}
+
+func readBeatmap(r *reader, version int32) *Beatmap {
+	if version < versionNoEntrySize {
+		_ = r.int32() // entry size
+	}
+
+	b := new(Beatmap)
+
+	b.Artist = r.string()
+	_ = r.string() // artist unicode
+	b.Title = r.string()
+	_ = r.string() // title unicode
+	b.Creator = r.string()
+	b.Difficulty = r.string()
+	_ = r.string() // audio file
+	b.MD5 = r.string()
+	b.File = r.string()
+	b.RankedStatus = r.byte()
+
+	r.skip(2 * 3) // circles, sliders, spinners
+	r.skip(8)     // last modification time
+
+	if version < versionFloatDifficulty {
+		r.skip(4) // AR, CS, HP, OD
+	} else {
+		r.skip(4 * 4)
+	}
+
+	r.skip(8) // slider velocity
+
+	if version >= versionFloatDifficulty {
+		for mode := 0; mode < 4 && r.err == nil; mode++ { // cached star ratings of each mode
+			pairs := r.int32()
+
+			for j := 0; j < int(pairs) && r.err == nil; j++ {
+				skipPair(r)
+			}
+		}
+	}
+
+	r.skip(4 * 3) // drain time, total time, preview time
+
+	timingPoints := r.int32()
+	if r.err == nil && timingPoints < 0 {
+		r.err = fmt.Errorf("invalid number of timing points: %d", timingPoints)
+	}
+
+	r.skip(int(max(timingPoints, 0)) * 17) // bpm, offset, inherited
+
+	b.ID = r.int32()
+	b.SetID = r.int32()
+
+	r.skip(4)     // thread id
+	r.skip(4 * 1) // grades
+	b.LocalOffset = r.int16()
+	r.skip(4) // stack leniency
+	b.Mode = r.byte()
+
+	_ = r.string() // source
+	_ = r.string() // tags
+
+	b.OnlineOffset = r.int16()
+
+	_ = r.string() // title font
+
+	b.Unplayed = r.bool()
+	b.LastPlayed = r.dateTime()
+
+	r.skip(1) // osz2
+
+	b.Folder = r.string()
+
+	r.skip(8)     // last online check
+	r.skip(5 * 1) // ignore sounds, ignore skin, disable storyboard, disable video, visual override
+
+	if version < versionFloatDifficulty {
+		r.skip(2)
+	}
+
+	r.skip(4) // last modification time
+	r.skip(1) // mania scroll speed
+
+	return b
+}
+
+// skipPair skips osu!'s Int-Double (or Int-Float in newer versions) pair, each value is prefixed with its type
+func skipPair(r *reader) {
+	for k := 0; k < 2 && r.err == nil; k++ {
+		switch t := r.byte(); t {
+		case 0x08, 0x0c: // int, float
+			r.skip(4)
+		case 0x0d: // double
+			r.skip(8)
+		default:
+			if r.err == nil {
+				r.err = fmt.Errorf("unknown pair value type: 0x%02x", t)
+			}
+		}
+	}
+}
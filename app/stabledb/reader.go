@@ -0,0 +1,143 @@
+package stabledb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"time"
+)
+
+// reader reads primitive types used by osu!stable's .db files, all of them are little endian
+type reader struct {
+	r   *bufio.Reader
+	err error
+}
+
+func newReader(r io.Reader) *reader {
+	return &reader{r: bufio.NewReader(r)}
+}
+
+func (r *reader) read(data []byte) {
+	if r.err != nil {
+		return
+	}
+
+	_, r.err = io.ReadFull(r.r, data)
+}
+
+func (r *reader) byte() byte {
+	var b [1]byte
+	r.read(b[:])
+
+	return b[0]
+}
+
+func (r *reader) int32() int32 {
+	var b [4]byte
+	r.read(b[:])
+
+	return int32(binary.LittleEndian.Uint32(b[:]))
+}
+
+func (r *reader) uleb128() (value uint64) {
+	for shift := 0; shift < 64; shift += 7 {
+		b := r.byte()
+		if r.err != nil {
+			return 0
+		}
+
+		value |= uint64(b&0x7F) << shift
+
+		if b&0x80 == 0 {
+			return
+		}
+	}
+
+	r.err = errors.New("invalid ULEB128 value")
+
+	return 0
+}
+
+// string reads osu!'s string: 0x00 for empty string or 0x0b followed by ULEB128 length and UTF-8 bytes
+func (r *reader) string() string {
+	switch r.byte() {
+	case 0x00:
+		return ""
+	case 0x0b:
+		length := r.uleb128()
+		if length > 1<<20 {
+			r.err = errors.New("string too long")
+			return ""
+		}
+
+		data := make([]byte, length)
+		r.read(data)
+
+		return string(data)
+	}
+
+	if r.err == nil {
+		r.err = errors.New("invalid string header")
+	}
+
+	return ""
+}
+
+func (r *reader) bool() bool {
+	return r.byte() != 0
+}
+
+func (r *reader) int16() int16 {
+	var b [2]byte
+	r.read(b[:])
+
+	return int16(binary.LittleEndian.Uint16(b[:]))
+}
+
+func (r *reader) int64() int64 {
+	var b [8]byte
+	r.read(b[:])
+
+	return int64(binary.LittleEndian.Uint64(b[:]))
+}
+
+func (r *reader) float32() float32 {
+	var b [4]byte
+	r.read(b[:])
+
+	return math.Float32frombits(binary.LittleEndian.Uint32(b[:]))
+}
+
+func (r *reader) float64() float64 {
+	var b [8]byte
+	r.read(b[:])
+
+	return math.Float64frombits(binary.LittleEndian.Uint64(b[:]))
+}
+
+func (r *reader) skip(n int) {
+	if r.err != nil {
+		return
+	}
+
+	_, r.err = r.r.Discard(n)
+}
+
+// dateTime reads .NET DateTime ticks (100ns intervals since 0001-01-01)
+func (r *reader) dateTime() time.Time {
+	return ticksToTime(r.int64())
+}
+
+const unixEpochTicks = 621355968000000000
+
+func ticksToTime(ticks int64) time.Time {
+	if ticks <= unixEpochTicks {
+		return time.Time{}
+	}
+
+	ticks -= unixEpochTicks
+
+	return time.Unix(ticks/10000000, (ticks%10000000)*100)
+}
@@ -0,0 +1,123 @@
+package stabledb
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+const targetPractice = 1 << 23
+
+// replayTicksOffset is subtracted from score's timestamp in names of replays saved in Data/r
+const replayTicksOffset = 504911232000000000
+
+// Score is a local score from osu!stable's scores.db
+type Score struct {
+	Mode       byte
+	Version    int32
+	BeatmapMD5 string
+	Player     string
+	ReplayMD5  string
+
+	Count300  int16
+	Count100  int16
+	Count50   int16
+	CountGeki int16
+	CountKatu int16
+	CountMiss int16
+
+	Score    int32
+	MaxCombo int16
+	Perfect  bool
+	Mods     uint32
+
+	Timestamp time.Time
+	OnlineID  int64
+
+	ticks int64
+}
+
+// ReplayFile returns the name of score's replay file in osu!'s Data/r directory
+func (s *Score) ReplayFile() string {
+	return s.BeatmapMD5 + "-" + strconv.FormatInt(s.ticks-replayTicksOffset, 10) + ".osr"
+}
+
+// ReadScores parses osu!stable's scores.db
+func ReadScores(path string) ([]*Score, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	r := newReader(file)
+
+	_ = r.int32() // version
+
+	count := r.int32()
+
+	if r.err == nil && count < 0 {
+		return nil, fmt.Errorf("invalid number of beatmaps: %d", count)
+	}
+
+	var scores []*Score
+
+	for i := 0; i < int(count) && r.err == nil; i++ {
+		_ = r.string() // beatmap md5, repeated in every score
+
+		numScores := r.int32()
+
+		for j := 0; j < int(numScores) && r.err == nil; j++ {
+			scores = append(scores, readScore(r))
+		}
+	}
+
+	if r.err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, r.err)
+	}
+
+	return scores, nil
+}
+
+func readScore(r *reader) *Score {
+	s := new(Score)
+
+	s.Mode = r.byte()
+	s.Version = r.int32()
+	s.BeatmapMD5 = r.string()
+	s.Player = r.string()
+	s.ReplayMD5 = r.string()
+
+	s.Count300 = r.int16()
+	s.Count100 = r.int16()
+	s.Count50 = r.int16()
+	s.CountGeki = r.int16()
+	s.CountKatu = r.int16()
+	s.CountMiss = r.int16()
+
+	s.Score = r.int32()
+	s.MaxCombo = r.int16()
+	s.Perfect = r.bool()
+	s.Mods = uint32(r.int32())
+
+	_ = r.string() // life bar graph, always empty
+
+	s.ticks = r.int64()
+	s.Timestamp = ticksToTime(s.ticks)
+
+	r.skip(4) // replay data length, always -1
+
+	if s.Version >= 20140721 {
+		s.OnlineID = r.int64()
+	} else if s.Version >= 20121008 {
+		s.OnlineID = int64(r.int32())
+	}
+
+	if s.Mods&targetPractice > 0 {
+		r.skip(8) // additional accuracy
+	}
+
+	return s
+}
@@ -364,6 +364,19 @@ func (bg *Background) GetStoryboard() *storyboard.Storyboard {
 	return bg.storyboard
 }
 
+func (bg *Background) Dispose() {
+	if bg.background != nil {
+		bg.background.Dispose()
+		bg.background = nil
+	}
+
+	if bg.storyboard != nil {
+		bg.storyboard.Dispose()
+	}
+
+	bg.blur.Dispose()
+}
+
 func (bg *Background) getColors(image *texture.Pixmap) []color2.Color {
 	newCol := make([]color2.Color, 0)
 
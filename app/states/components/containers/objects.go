@@ -118,7 +118,7 @@ func (container *HitObjectContainer) addProxy(proxy *renderableProxy) {
 }
 
 func (container *HitObjectContainer) Update(time float64) {
-	container.spriteManager.Update(time)
+	container.UpdateFollowPoints(time)
 
 	if time > 0 {
 		delta := time - container.lastTime
@@ -131,6 +131,11 @@ func (container *HitObjectContainer) Update(time float64) {
 	}
 }
 
+// UpdateFollowPoints updates only container's own sprites, object colors are left to the main container
+func (container *HitObjectContainer) UpdateFollowPoints(time float64) {
+	container.spriteManager.Update(time)
+}
+
 func (container *HitObjectContainer) preProcessQueue(time float64) {
 	if len(container.objectQueue) > 0 {
 		for i := 0; i < len(container.objectQueue); i++ {
@@ -0,0 +1,306 @@
+package overlays
+
+import (
+	"fmt"
+	"github.com/wieku/danser-go/app/beatmap/objects"
+	camera2 "github.com/wieku/danser-go/app/bmath/camera"
+	"github.com/wieku/danser-go/app/graphics"
+	"github.com/wieku/danser-go/app/rulesets/osu"
+	"github.com/wieku/danser-go/app/rulesets/osu/performance/pp220930"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/app/utils"
+	"github.com/wieku/danser-go/framework/graphics/batch"
+	"github.com/wieku/danser-go/framework/graphics/font"
+	"github.com/wieku/danser-go/framework/math/animation"
+	color2 "github.com/wieku/danser-go/framework/math/color"
+	"github.com/wieku/danser-go/framework/math/vector"
+	"math"
+)
+
+var (
+	ghostColor     = color2.NewRGB(0.55, 0.8, 1)
+	divergentColor = color2.NewRGB(1, 0.55, 0.2)
+)
+
+type ghostJudgement struct {
+	judged bool
+	result osu.HitResult
+
+	headHit  bool
+	headTime int64
+	headPos  vector.Vector2f
+}
+
+type divergence struct {
+	position vector.Vector2d
+
+	main  ghostJudgement
+	ghost ghostJudgement
+
+	timeDiff  float64
+	posDiff   float64
+	divergent bool
+
+	endTime float64
+	fade    *animation.Glider
+}
+
+// GhostOverlay compares the main replay with a reference (ghost) replay played on the same beatmap.
+// Main replay's hits are passed to its ScoreOverlay, ghost's hits are used only for divergence indicators.
+type GhostOverlay struct {
+	scoreOverlay *ScoreOverlay
+	ruleset      *osu.OsuRuleSet
+
+	mainCursor  *graphics.Cursor
+	ghostCursor *graphics.Cursor
+	ghostName   string
+
+	judgements [2][]ghostJudgement
+
+	divergences []*divergence
+
+	font   *font.Font
+	camera *camera2.Camera
+}
+
+func NewGhostOverlay(scoreOverlay *ScoreOverlay, ruleset *osu.OsuRuleSet, mainCursor, ghostCursor *graphics.Cursor, ghostName string) *GhostOverlay {
+	overlay := new(GhostOverlay)
+	overlay.scoreOverlay = scoreOverlay
+	overlay.ruleset = ruleset
+	overlay.mainCursor = mainCursor
+	overlay.ghostCursor = ghostCursor
+	overlay.ghostName = cleanName(ghostName)
+
+	numObjects := len(ruleset.GetBeatMap().HitObjects)
+
+	overlay.judgements[0] = make([]ghostJudgement, numObjects)
+	overlay.judgements[1] = make([]ghostJudgement, numObjects)
+
+	overlay.font = font.GetFont("Quicksand Bold")
+
+	overlay.camera = camera2.NewCamera()
+	overlay.camera.SetViewportF(0, int(scoreOverlay.ScaledHeight), int(scoreOverlay.ScaledWidth), 0)
+	overlay.camera.Update()
+
+	// ScoreOverlay listens to all cursors, ghost's hits can't reach it
+	ruleset.SetListener(overlay.hitReceived)
+
+	return overlay
+}
+
+func (overlay *GhostOverlay) hitReceived(c *graphics.Cursor, time int64, number int64, position vector.Vector2d, result osu.HitResult, comboResult osu.ComboResult, ppResults pp220930.PPv2Results, score int64) {
+	index := 0
+
+	switch c {
+	case overlay.mainCursor:
+		overlay.scoreOverlay.hitReceived(c, time, number, position, result, comboResult, ppResults, score)
+	case overlay.ghostCursor:
+		index = 1
+	default:
+		return
+	}
+
+	if result == osu.PositionalMiss {
+		return
+	}
+
+	object := overlay.ruleset.GetBeatMap().HitObjects[number]
+	if object.GetType() == objects.SPINNER {
+		return
+	}
+
+	judgement := &overlay.judgements[index][number]
+
+	_, isCircle := object.(*objects.Circle)
+	_, isSlider := object.(*objects.Slider)
+
+	if !judgement.headHit && ((isCircle && result&osu.BaseHits > 0) || (isSlider && result&osu.SliderStart > 0)) {
+		judgement.headHit = true
+		judgement.headTime = time
+		judgement.headPos = c.Position
+	}
+
+	if result&osu.BaseHitsM == 0 || judgement.judged {
+		return
+	}
+
+	judgement.judged = true
+	judgement.result = result & osu.BaseHitsM
+
+	if other := overlay.judgements[1-index][number]; other.judged {
+		overlay.addDivergence(object, float64(time), number)
+	}
+}
+
+func (overlay *GhostOverlay) addDivergence(object objects.IHitObject, time float64, number int64) {
+	if !settings.Gameplay.Ghost.ShowIndicators {
+		return
+	}
+
+	div := &divergence{
+		position: object.GetStackedStartPositionMod(overlay.ruleset.GetBeatMap().Diff.Mods).Copy64(),
+		main:     overlay.judgements[0][number],
+		ghost:    overlay.judgements[1][number],
+		fade:     animation.NewGlider(0),
+	}
+
+	div.divergent = div.main.result != div.ghost.result
+
+	if div.main.headHit && div.ghost.headHit {
+		div.timeDiff = float64(div.main.headTime - div.ghost.headTime)
+		div.posDiff = float64(div.main.headPos.Dst(div.ghost.headPos))
+
+		if math.Abs(div.timeDiff) >= settings.Gameplay.Ghost.TimingThreshold || div.posDiff >= settings.Gameplay.Ghost.PositionThreshold {
+			div.divergent = true
+		}
+	}
+
+	duration := settings.Gameplay.Ghost.IndicatorDuration * 1000
+
+	div.endTime = time + duration
+	div.fade.AddEventS(time, time+100, 0, 1)
+	div.fade.AddEventS(div.endTime-min(200, duration/2), div.endTime, 1, 0)
+
+	overlay.divergences = append(overlay.divergences, div)
+}
+
+func (overlay *GhostOverlay) Update(time float64) {
+	n := 0
+
+	for _, div := range overlay.divergences {
+		if time > div.endTime {
+			continue
+		}
+
+		div.fade.Update(time)
+
+		overlay.divergences[n] = div
+		n++
+	}
+
+	overlay.divergences = overlay.divergences[:n]
+}
+
+// IsGhost checks whether cursor belongs to the reference replay
+func (overlay *GhostOverlay) IsGhost(cursor *graphics.Cursor) bool {
+	return cursor == overlay.ghostCursor
+}
+
+// DrawNormal draws divergence indicators, it has to be called with playfield's camera
+func (overlay *GhostOverlay) DrawNormal(batch *batch.QuadBatch, _ []color2.Color, alpha float64) {
+	if len(overlay.divergences) == 0 {
+		return
+	}
+
+	radius := overlay.ruleset.GetBeatMap().Diff.CircleRadius
+	pixel := graphics.Pixel.GetRegion()
+
+	batch.ResetTransform()
+
+	for _, div := range overlay.divergences {
+		dAlpha := alpha * div.fade.GetValue()
+
+		if dAlpha < 0.001 {
+			continue
+		}
+
+		color := color2.NewRGB(1, 1, 1)
+		if div.divergent {
+			color = divergentColor
+		} else {
+			dAlpha *= 0.6
+		}
+
+		if div.main.headHit && div.ghost.headHit {
+			mainPos := div.main.headPos.Copy64()
+			ghostPos := div.ghost.headPos.Copy64()
+
+			if div.posDiff > 1 {
+				batch.SetColor(float64(color.R), float64(color.G), float64(color.B), dAlpha*0.6)
+				batch.SetTranslation(mainPos.Mid(ghostPos))
+				batch.SetRotation(ghostPos.AngleRV(mainPos))
+				batch.SetSubScale(div.posDiff/2, 0.75)
+				batch.DrawUnit(pixel)
+				batch.ResetTransform()
+			}
+
+			batch.SetColor(float64(ghostColor.R), float64(ghostColor.G), float64(ghostColor.B), dAlpha)
+			batch.SetTranslation(ghostPos)
+			batch.SetSubScale(2, 2)
+			batch.DrawUnit(pixel)
+
+			batch.SetColor(1, 1, 1, dAlpha)
+			batch.SetTranslation(mainPos)
+			batch.DrawUnit(pixel)
+
+			batch.ResetTransform()
+		}
+
+		scl := radius * 0.45
+		y := div.position.Y - radius*1.1
+
+		batch.SetColor(float64(color.R), float64(color.G), float64(color.B), dAlpha)
+
+		if div.main.headHit && div.ghost.headHit {
+			overlay.font.DrawOrigin(batch, div.position.X, y, vector.BottomCentre, scl, false, fmt.Sprintf("%+.0fms", div.timeDiff))
+			y -= scl
+		}
+
+		if div.main.result != div.ghost.result {
+			overlay.font.DrawOrigin(batch, div.position.X, y, vector.BottomCentre, scl, false, fmt.Sprintf("%s / %s", resultText(div.main.result), resultText(div.ghost.result)))
+		}
+	}
+
+	batch.ResetTransform()
+	batch.SetColor(1, 1, 1, 1)
+}
+
+// DrawHUD draws ghost's name and score difference between the plays
+func (overlay *GhostOverlay) DrawHUD(batch *batch.QuadBatch, _ []color2.Color, alpha float64) {
+	if !settings.Gameplay.Ghost.ShowScoreDifference {
+		return
+	}
+
+	prev := batch.Projection
+	batch.SetCamera(overlay.camera.GetProjectionView())
+	batch.ResetTransform()
+
+	diff := overlay.ruleset.GetScore(overlay.mainCursor).Score - overlay.ruleset.GetScore(overlay.ghostCursor).Score
+
+	diffText := "+" + utils.Humanize(diff)
+	if diff < 0 {
+		diffText = "-" + utils.Humanize(-diff)
+	}
+
+	scl := 20.0
+	x := overlay.scoreOverlay.ScaledWidth / 2
+	y := overlay.scoreOverlay.ScaledHeight * 0.12
+
+	batch.SetColor(float64(ghostColor.R), float64(ghostColor.G), float64(ghostColor.B), alpha)
+	overlay.font.DrawOrigin(batch, x, y, vector.BottomCentre, scl, false, "vs "+overlay.ghostName)
+
+	if diff >= 0 {
+		batch.SetColor(0.5, 1, 0.5, alpha)
+	} else {
+		batch.SetColor(1, 0.45, 0.45, alpha)
+	}
+
+	overlay.font.DrawOrigin(batch, x, y, vector.TopCentre, scl, true, diffText)
+
+	batch.ResetTransform()
+	batch.SetColor(1, 1, 1, 1)
+	batch.SetCamera(prev)
+}
+
+func resultText(result osu.HitResult) string {
+	switch result {
+	case osu.Hit300:
+		return "300"
+	case osu.Hit100:
+		return "100"
+	case osu.Hit50:
+		return "50"
+	}
+
+	return "X"
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/wieku/danser-go/framework/math/animation/easing"
 	color2 "github.com/wieku/danser-go/framework/math/color"
 	"github.com/wieku/danser-go/framework/math/vector"
+	"github.com/wieku/danser-go/framework/util"
 	"log"
 	"math"
 	"math/rand"
@@ -64,8 +65,22 @@ type knockoutPlayer struct {
 	name         string
 	oldIndex     int
 	currentIndex int
+
+	team int
 }
 
+type knockoutTeam struct {
+	name  string
+	color color2.Color
+
+	score     int64
+	scoreDisp *animation.TargetGlider
+
+	players []*knockoutPlayer
+}
+
+const maxStandingsRows = 10
+
 type bubble struct {
 	deathFade  *animation.Glider
 	deathSlide *animation.Glider
@@ -79,8 +94,8 @@ type bubble struct {
 }
 
 func newBubble(position vector.Vector2d, time float64, name string, combo int64, lastHit osu.HitResult, lastCombo osu.ComboResult) *bubble {
-	deathShiftX := (rand.Float64() - 0.5) * 10
-	deathShiftY := (rand.Float64() - 0.5) * 10
+	deathShiftX := (util.RandomFloat64() - 0.5) * 10
+	deathShiftY := (util.RandomFloat64() - 0.5) * 10
 	baseY := position.Y + deathShiftY
 
 	bub := new(bubble)
@@ -136,6 +151,15 @@ type KnockoutOverlay struct {
 	fade      *animation.Glider
 
 	alivePlayers int
+
+	teams      []*knockoutTeam
+	winner     *knockoutTeam
+	winnerFade *animation.Glider
+
+	events        []*KnockoutEvent
+	eliminations  map[*knockoutPlayer]*KnockoutEvent
+	standings     []*KnockoutStanding
+	standingsFade *animation.Glider
 }
 
 func NewKnockoutOverlay(replayController *dance.ReplayController) *KnockoutOverlay {
@@ -160,19 +184,26 @@ func NewKnockoutOverlay(replayController *dance.ReplayController) *KnockoutOverl
 	overlay.ScaledWidth = overlay.ScaledHeight * settings.Graphics.GetAspectRatio()
 
 	overlay.fade = animation.NewGlider(1)
+	overlay.standingsFade = animation.NewGlider(0)
+
+	overlay.eliminations = make(map[*knockoutPlayer]*KnockoutEvent)
 
 	for i, r := range replayController.GetReplays() {
 		cursor := replayController.GetCursors()[i]
 		overlay.names[cursor] = r.Name
-		overlay.players[r.Name] = &knockoutPlayer{animation.NewGlider(1), animation.NewGlider(0), animation.NewGlider(overlay.ScaledHeight * 0.9 * 1.04 / (51)), animation.NewGlider(float64(i)), animation.NewTargetGlider(0, 0), animation.NewTargetGlider(0, 2), animation.NewTargetGlider(100, 2), 0, 0, r.MaxCombo, false, 0, 0.0, 0, make([]stats, len(replayController.GetBeatMap().HitObjects)), 0.0, osu.Hit300, animation.NewGlider(0), animation.NewGlider(0), r.Name, i, i}
+		overlay.players[r.Name] = &knockoutPlayer{animation.NewGlider(1), animation.NewGlider(0), animation.NewGlider(overlay.ScaledHeight * 0.9 * 1.04 / (51)), animation.NewGlider(float64(i)), animation.NewTargetGlider(0, 0), animation.NewTargetGlider(0, 2), animation.NewTargetGlider(100, 2), 0, 0, r.MaxCombo, false, 0, 0.0, 0, make([]stats, len(replayController.GetBeatMap().HitObjects)), 0.0, osu.Hit300, animation.NewGlider(0), animation.NewGlider(0), r.Name, i, i, r.Team}
 		overlay.players[r.Name].index.SetEasing(easing.InOutQuad)
 		overlay.playersArray = append(overlay.playersArray, overlay.players[r.Name])
 
 		overlay.alivePlayers++
 	}
 
+	if settings.Knockout.Mode == settings.TeamVsTeam {
+		overlay.initTeams()
+	}
+
 	if settings.Knockout.LiveSort {
-		rand.Shuffle(len(overlay.playersArray), func(i, j int) {
+		util.RandomShuffle(len(overlay.playersArray), func(i, j int) {
 			overlay.playersArray[i], overlay.playersArray[j] = overlay.playersArray[j], overlay.playersArray[i]
 		})
 	}
@@ -233,6 +264,18 @@ func NewKnockoutOverlay(replayController *dance.ReplayController) *KnockoutOverl
 	}
 
 	replayController.GetRuleset().SetEndListener(func(time int64, number int64) {
+		if number == int64(len(replayController.GetBeatMap().HitObjects)-1) {
+			if overlay.teams != nil {
+				overlay.declareWinner()
+			}
+
+			overlay.standings = overlay.computeStandings()
+
+			if settings.Knockout.ShowStandings {
+				overlay.standingsFade.AddEventEase(overlay.normalTime, overlay.normalTime+500, 1, easing.OutQuad)
+			}
+		}
+
 		if number == int64(len(replayController.GetBeatMap().HitObjects)-1) && settings.Knockout.RevivePlayersAtEnd {
 			for _, player := range overlay.players {
 				player.hasBroken = false
@@ -260,6 +303,70 @@ func NewKnockoutOverlay(replayController *dance.ReplayController) *KnockoutOverl
 	return overlay
 }
 
+func (overlay *KnockoutOverlay) initTeams() {
+	overlay.winnerFade = animation.NewGlider(0)
+
+	for i, t := range settings.KNOCKOUTTEAMS {
+		overlay.teams = append(overlay.teams, &knockoutTeam{
+			name:      t.Name,
+			color:     t.GetColor(i),
+			scoreDisp: animation.NewTargetGlider(0, 0),
+		})
+	}
+
+	for _, player := range overlay.playersArray {
+		if player.team >= 0 && player.team < len(overlay.teams) {
+			overlay.teams[player.team].players = append(overlay.teams[player.team].players, player)
+		}
+	}
+
+	for _, team := range overlay.teams {
+		log.Println(fmt.Sprintf("Team \"%s\": %d players", team.name, len(team.players)))
+	}
+}
+
+func (overlay *KnockoutOverlay) declareWinner() {
+	if overlay.winner != nil {
+		return
+	}
+
+	for _, team := range overlay.teams {
+		if len(team.players) > 0 && (overlay.winner == nil || team.score > overlay.winner.score) {
+			overlay.winner = team
+		}
+	}
+
+	if overlay.winner == nil {
+		return
+	}
+
+	if overlay.isTied() {
+		log.Println("Teams are tied with score:", overlay.winner.score)
+	} else {
+		log.Println(fmt.Sprintf("Team \"%s\" wins with score: %d", overlay.winner.name, overlay.winner.score))
+	}
+
+	overlay.winnerFade.AddEventEase(overlay.normalTime, overlay.normalTime+500, 1, easing.OutQuad)
+}
+
+func (overlay *KnockoutOverlay) isTied() bool {
+	for _, team := range overlay.teams {
+		if team != overlay.winner && len(team.players) > 0 && team.score == overlay.winner.score {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (overlay *KnockoutOverlay) getPlayerColor(colors []color2.Color, player *knockoutPlayer) color2.Color {
+	if overlay.teams != nil && player.team >= 0 && player.team < len(overlay.teams) {
+		return overlay.teams[player.team].color
+	}
+
+	return colors[player.oldIndex]
+}
+
 func (overlay *KnockoutOverlay) hitReceived(cursor *graphics.Cursor, time int64, number int64, position vector.Vector2d, result osu.HitResult, comboResult osu.ComboResult, ppResults pp220930.PPv2Results, score int64) {
 	if result == osu.PositionalMiss {
 		return
@@ -277,6 +384,17 @@ func (overlay *KnockoutOverlay) hitReceived(cursor *graphics.Cursor, time int64,
 	player.scoreDisp.SetValue(float64(score), false)
 	player.ppDisp.SetValue(player.pp, false)
 
+	if overlay.teams != nil && player.team >= 0 && player.team < len(overlay.teams) {
+		team := overlay.teams[player.team]
+
+		team.score = 0
+		for _, p := range team.players {
+			team.score += p.score
+		}
+
+		team.scoreDisp.SetValue(float64(team.score), false)
+	}
+
 	sc := overlay.controller.GetRuleset().GetScore(cursor)
 
 	player.perObjectStats[number].score = score
@@ -303,10 +421,12 @@ func (overlay *KnockoutOverlay) hitReceived(cursor *graphics.Cursor, time int64,
 		}
 	}
 
+	wasBroken := player.hasBroken
+
 	comboBreak := comboResult == osu.Reset
 	if (settings.Knockout.Mode == settings.SSOrQuit && (acceptableHits || comboBreak)) || (comboBreak && number != 0) {
 		if !player.hasBroken {
-			if settings.Knockout.Mode == settings.XReplays {
+			if settings.Knockout.Mode == settings.XReplays || settings.Knockout.Mode == settings.TeamVsTeam {
 				if player.sCombo >= int64(settings.Knockout.BubbleMinimumCombo) {
 					overlay.deathBubbles = append(overlay.deathBubbles, newBubble(position, overlay.normalTime, overlay.names[cursor], player.sCombo, resultClean, comboResult))
 					log.Println(overlay.names[cursor], "has broken! Combo:", player.sCombo)
@@ -333,11 +453,131 @@ func (overlay *KnockoutOverlay) hitReceived(cursor *graphics.Cursor, time int64,
 		}
 	}
 
+	if !wasBroken && (player.hasBroken || (comboBreak && number != 0)) {
+		overlay.addEvent(cursor, player, time, player.hasBroken)
+	}
+
 	if comboBreak {
 		player.sCombo = 0
 	}
 }
 
+func (overlay *KnockoutOverlay) addEvent(cursor *graphics.Cursor, player *knockoutPlayer, time int64, eliminated bool) {
+	sc := overlay.controller.GetRuleset().GetScore(cursor)
+
+	event := &KnockoutEvent{
+		Time:       time,
+		Player:     cleanName(player.name),
+		Team:       overlay.getTeamName(player),
+		Combo:      player.sCombo,
+		MaxCombo:   int64(sc.Combo),
+		Score:      player.score,
+		Accuracy:   sc.Accuracy,
+		PP:         player.pp,
+		Eliminated: eliminated,
+		Alive:      overlay.alivePlayers,
+	}
+
+	overlay.events = append(overlay.events, event)
+
+	if eliminated {
+		overlay.eliminations[player] = event
+	}
+}
+
+func (overlay *KnockoutOverlay) getTeamName(player *knockoutPlayer) string {
+	if overlay.teams != nil && player.team >= 0 && player.team < len(overlay.teams) {
+		return overlay.teams[player.team].name
+	}
+
+	return ""
+}
+
+// computeStandings sorts players like the live sort does, knocked out players are placed by their elimination time
+func (overlay *KnockoutOverlay) computeStandings() []*KnockoutStanding {
+	replays := overlay.controller.GetReplays()
+	cursors := overlay.controller.GetCursors()
+	ruleset := overlay.controller.GetRuleset()
+
+	players := make([]*knockoutPlayer, len(overlay.playersArray))
+	copy(players, overlay.playersArray)
+
+	cond := strings.ToLower(settings.Knockout.SortBy)
+
+	value := func(player *knockoutPlayer) float64 {
+		switch cond {
+		case "pp":
+			return player.pp
+		case "acc", "accuracy":
+			return ruleset.GetScore(cursors[player.oldIndex]).Accuracy
+		}
+
+		return float64(player.score)
+	}
+
+	sort.SliceStable(players, func(i, j int) bool {
+		eI, eJ := overlay.eliminations[players[i]], overlay.eliminations[players[j]]
+
+		if eI == nil && eJ == nil {
+			return value(players[i]) > value(players[j])
+		} else if eI != nil && eJ != nil {
+			return eI.Time > eJ.Time
+		}
+
+		return eI == nil
+	})
+
+	standings := make([]*KnockoutStanding, 0, len(players))
+
+	for i, player := range players {
+		sc := ruleset.GetScore(cursors[player.oldIndex])
+
+		standing := &KnockoutStanding{
+			Place:    i + 1,
+			Player:   cleanName(player.name),
+			Team:     overlay.getTeamName(player),
+			Mods:     replays[player.oldIndex].Mods,
+			Score:    player.score,
+			Accuracy: sc.Accuracy,
+			PP:       player.pp,
+			MaxCombo: int64(sc.Combo),
+			player:   player,
+		}
+
+		if event := overlay.eliminations[player]; event != nil {
+			standing.Eliminated = true
+			standing.EliminationTime = event.Time
+		}
+
+		standings = append(standings, standing)
+	}
+
+	return standings
+}
+
+// GetTimeline returns combo breaks and eliminations so far, standings are computed on the spot if the map hasn't ended yet
+func (overlay *KnockoutOverlay) GetTimeline() *KnockoutTimeline {
+	bMap := overlay.controller.GetBeatMap()
+
+	standings := overlay.standings
+	if standings == nil {
+		standings = overlay.computeStandings()
+	}
+
+	events := overlay.events
+	if events == nil {
+		events = make([]*KnockoutEvent, 0)
+	}
+
+	return &KnockoutTimeline{
+		Beatmap:   fmt.Sprintf("%s - %s [%s]", bMap.Artist, bMap.Name, bMap.Difficulty),
+		MD5:       bMap.MD5,
+		Mode:      settings.Knockout.Mode.String(),
+		Events:    events,
+		Standings: standings,
+	}
+}
+
 func (overlay *KnockoutOverlay) Update(time float64) {
 	if overlay.audioTime == 0 {
 		overlay.audioTime = time
@@ -357,6 +597,16 @@ func (overlay *KnockoutOverlay) Update(time float64) {
 	overlay.updateBreaks(overlay.normalTime)
 	overlay.fade.Update(overlay.normalTime)
 
+	overlay.standingsFade.Update(overlay.normalTime)
+
+	if overlay.teams != nil {
+		overlay.winnerFade.Update(overlay.normalTime)
+
+		for _, team := range overlay.teams {
+			team.scoreDisp.Update(overlay.normalTime)
+		}
+	}
+
 	for _, r := range overlay.controller.GetReplays() {
 		player := overlay.players[r.Name]
 		player.height.Update(overlay.normalTime)
@@ -418,12 +668,14 @@ func (overlay *KnockoutOverlay) DrawNormal(batch *batch.QuadBatch, colors []colo
 				}
 
 				rep := overlay.players[bubble.name]
-				batch.SetColor(float64(colors[rep.oldIndex].R), float64(colors[rep.oldIndex].G), float64(colors[rep.oldIndex].B), alpha*bubble.deathFade.GetValue())
+				bColor := overlay.getPlayerColor(colors, rep)
+				batch.SetColor(float64(bColor.R), float64(bColor.G), float64(bColor.B), alpha*bubble.deathFade.GetValue())
 				width := overlay.font.GetWidth(scl*bubble.deathScale.GetValue(), val)
 				overlay.font.Draw(batch, bubble.deathX-width/2, bubble.deathSlide.GetValue()+scl*bubble.deathScale.GetValue()/3, scl*bubble.deathScale.GetValue(), val)
 			} else {
 				rep := overlay.players[bubble.name]
-				batch.SetColor(float64(colors[rep.oldIndex].R), float64(colors[rep.oldIndex].G), float64(colors[rep.oldIndex].B), alpha*bubble.deathFade.GetValue())
+				bColor := overlay.getPlayerColor(colors, rep)
+				batch.SetColor(float64(bColor.R), float64(bColor.G), float64(bColor.B), alpha*bubble.deathFade.GetValue())
 				width := overlay.font.GetWidth(scl, bubble.name)
 				overlay.font.Draw(batch, bubble.deathX-width/2, bubble.deathSlide.GetValue()-scl/2, scl, bubble.name)
 
@@ -530,7 +782,9 @@ func (overlay *KnockoutOverlay) DrawHUD(batch *batch.QuadBatch, colors []color2.
 		//batch.DrawUnit(graphics.Pixel.GetRegion())
 		//batch.SetAdditive(false)
 
-		batch.SetColor(float64(colors[rep.oldIndex].R), float64(colors[rep.oldIndex].G), float64(colors[rep.oldIndex].B), alpha*player.fade.GetValue())
+		pColor := overlay.getPlayerColor(colors, rep)
+
+		batch.SetColor(float64(pColor.R), float64(pColor.G), float64(pColor.B), alpha*player.fade.GetValue())
 
 		for j := 0; j < 2; j++ {
 			batch.SetSubScale(scl*0.8/2, scl*0.8/2)
@@ -626,7 +880,9 @@ func (overlay *KnockoutOverlay) DrawHUD(batch *batch.QuadBatch, colors []color2.
 		overlay.font.DrawOrigin(batch, overlay.ScaledWidth-cS-0.5*scl+xSlideRight, rowBaseY, vector.CentreRight, scl, true, sWC)
 		overlay.font.DrawOrigin(batch, overlay.ScaledWidth-0.5*scl+xSlideRight, rowBaseY, vector.CentreRight, scl, true, scorestr)
 
-		batch.SetColor(float64(colors[rep.oldIndex].R), float64(colors[rep.oldIndex].G), float64(colors[rep.oldIndex].B), alpha*player.fade.GetValue())
+		pColor := overlay.getPlayerColor(colors, rep)
+
+		batch.SetColor(float64(pColor.R), float64(pColor.G), float64(pColor.B), alpha*player.fade.GetValue())
 		overlay.font.DrawOrigin(batch, 3.2*scl+nWidth+xSlideLeft, rowBaseY, vector.CentreLeft, scl, false, r.Name)
 		width := overlay.font.GetWidth(scl, r.Name)
 
@@ -636,6 +892,150 @@ func (overlay *KnockoutOverlay) DrawHUD(batch *batch.QuadBatch, colors []color2.
 			overlay.font.DrawOrigin(batch, 3.2*scl+width+nWidth+xSlideLeft, rowBaseY+ascScl, vector.BottomLeft, scl*0.8, false, "+"+r.Mods)
 		}
 	}
+
+	overlay.drawTeams(batch, alpha)
+	overlay.drawStandings(batch, colors, alpha)
+}
+
+func (overlay *KnockoutOverlay) drawTeams(batch *batch.QuadBatch, alpha float64) {
+	if overlay.teams == nil {
+		return
+	}
+
+	batch.ResetTransform()
+
+	scl := overlay.ScaledHeight * 0.9 / 51 * 1.5
+	gap := scl * 2
+
+	totalWidth := -gap
+
+	for _, team := range overlay.teams {
+		if len(team.players) == 0 {
+			continue
+		}
+
+		totalWidth += overlay.font.GetWidth(scl, team.name+" ") + overlay.font.GetWidthMonospaced(scl, utils.Humanize(team.score)) + gap
+	}
+
+	x := (overlay.ScaledWidth - totalWidth) / 2
+
+	for _, team := range overlay.teams {
+		if len(team.players) == 0 {
+			continue
+		}
+
+		batch.SetColor(float64(team.color.R), float64(team.color.G), float64(team.color.B), alpha)
+		overlay.font.DrawOrigin(batch, x, scl, vector.CentreLeft, scl, false, team.name+" ")
+
+		x += overlay.font.GetWidth(scl, team.name+" ")
+
+		batch.SetColor(1, 1, 1, alpha)
+		overlay.font.DrawOrigin(batch, x, scl, vector.CentreLeft, scl, true, utils.Humanize(int64(team.scoreDisp.GetValue())))
+
+		x += overlay.font.GetWidthMonospaced(scl, utils.Humanize(team.score)) + gap
+	}
+
+	wAlpha := alpha * overlay.winnerFade.GetValue()
+
+	if overlay.winner == nil || wAlpha < 0.001 || overlay.standingsFade.GetValue() > 0.001 { // standings card shows the winner instead
+		return
+	}
+
+	text, wColor := overlay.getWinnerText()
+
+	wSize := scl * 3
+	wY := overlay.ScaledHeight / 2
+
+	batch.SetColor(0, 0, 0, wAlpha*0.6)
+	overlay.font.DrawOrigin(batch, overlay.ScaledWidth/2+wSize/20, wY+wSize/20, vector.Centre, wSize, false, text)
+
+	batch.SetColor(float64(wColor.R), float64(wColor.G), float64(wColor.B), wAlpha)
+	overlay.font.DrawOrigin(batch, overlay.ScaledWidth/2, wY, vector.Centre, wSize, false, text)
+
+	batch.SetColor(1, 1, 1, wAlpha)
+	overlay.font.DrawOrigin(batch, overlay.ScaledWidth/2, wY+wSize, vector.Centre, scl, true, utils.Humanize(overlay.winner.score))
+}
+
+func (overlay *KnockoutOverlay) getWinnerText() (string, color2.Color) {
+	if overlay.isTied() {
+		return "Draw!", color2.NewL(1)
+	}
+
+	return overlay.winner.name + " wins!", overlay.winner.color
+}
+
+func (overlay *KnockoutOverlay) drawStandings(batch *batch.QuadBatch, colors []color2.Color, alpha float64) {
+	sAlpha := alpha * overlay.standingsFade.GetValue()
+
+	if overlay.standings == nil || sAlpha < 0.001 {
+		return
+	}
+
+	batch.ResetTransform()
+
+	scl := overlay.ScaledHeight * 0.9 / 51 * 1.5
+	rowHeight := scl * 1.3
+
+	standings := overlay.standings[:min(len(overlay.standings), maxStandingsRows)]
+
+	title := "Final standings"
+	tColor := color2.NewL(1)
+
+	if overlay.winner != nil {
+		title, tColor = overlay.getWinnerText()
+	}
+
+	maxNameWidth := 0.0
+	maxStatsWidth := 0.0
+	maxScoreWidth := 0.0
+
+	for _, s := range standings {
+		maxNameWidth = max(maxNameWidth, overlay.font.GetWidth(scl, s.Player)+overlay.font.GetWidth(scl*0.8, " +"+s.Mods))
+		maxStatsWidth = max(maxStatsWidth, overlay.font.GetWidthMonospaced(scl, fmt.Sprintf("%.2f%% %.2fpp %dx", s.Accuracy, s.PP, s.MaxCombo)))
+		maxScoreWidth = max(maxScoreWidth, overlay.font.GetWidthMonospaced(scl, utils.Humanize(s.Score)))
+	}
+
+	width := max(scl*4+maxNameWidth+maxStatsWidth+maxScoreWidth+scl*2, overlay.font.GetWidth(scl*1.5, title)+scl*2)
+	height := rowHeight * (float64(len(standings)) + 2.5)
+
+	x := (overlay.ScaledWidth - width) / 2
+	y := (overlay.ScaledHeight - height) / 2
+
+	batch.SetColor(0, 0, 0, sAlpha*0.75)
+	batch.SetSubScale(width/2, height/2)
+	batch.SetTranslation(vector.NewVec2d(overlay.ScaledWidth/2, overlay.ScaledHeight/2))
+	batch.DrawUnit(graphics.Pixel.GetRegion())
+
+	batch.ResetTransform()
+
+	batch.SetColor(float64(tColor.R), float64(tColor.G), float64(tColor.B), sAlpha)
+	overlay.font.DrawOrigin(batch, overlay.ScaledWidth/2, y+rowHeight, vector.Centre, scl*1.5, false, title)
+
+	for i, s := range standings {
+		rowY := y + rowHeight*(float64(i)+2.5)
+
+		rAlpha := sAlpha
+		if s.Eliminated {
+			rAlpha *= 0.6
+		}
+
+		batch.SetColor(1, 1, 1, rAlpha)
+		overlay.font.DrawOrigin(batch, x+scl, rowY, vector.CentreLeft, scl, true, fmt.Sprintf("#%d", s.Place))
+
+		pColor := overlay.getPlayerColor(colors, s.player)
+
+		batch.SetColor(float64(pColor.R), float64(pColor.G), float64(pColor.B), rAlpha)
+		overlay.font.DrawOrigin(batch, x+scl*4, rowY, vector.CentreLeft, scl, false, s.Player)
+
+		batch.SetColor(1, 1, 1, rAlpha)
+
+		if s.Mods != "" {
+			overlay.font.DrawOrigin(batch, x+scl*4+overlay.font.GetWidth(scl, s.Player), rowY, vector.CentreLeft, scl*0.8, false, " +"+s.Mods)
+		}
+
+		overlay.font.DrawOrigin(batch, x+width-scl*2-maxScoreWidth, rowY, vector.CentreRight, scl, true, fmt.Sprintf("%.2f%% %.2fpp %dx", s.Accuracy, s.PP, s.MaxCombo))
+		overlay.font.DrawOrigin(batch, x+width-scl, rowY, vector.CentreRight, scl, true, utils.Humanize(s.Score))
+	}
 }
 
 func (overlay *KnockoutOverlay) IsBroken(cursor *graphics.Cursor) bool {
@@ -0,0 +1,142 @@
+package overlays
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// KnockoutEvent is a combo break of a player, Eliminated is set if the player got knocked out by it
+type KnockoutEvent struct {
+	Time       int64   `json:"time"`
+	Player     string  `json:"player"`
+	Team       string  `json:"team,omitempty"`
+	Combo      int64   `json:"combo"`
+	MaxCombo   int64   `json:"maxCombo"`
+	Score      int64   `json:"score"`
+	Accuracy   float64 `json:"accuracy"`
+	PP         float64 `json:"pp"`
+	Eliminated bool    `json:"eliminated"`
+	Alive      int     `json:"alive"`
+}
+
+type KnockoutStanding struct {
+	Place           int     `json:"place"`
+	Player          string  `json:"player"`
+	Team            string  `json:"team,omitempty"`
+	Mods            string  `json:"mods"`
+	Score           int64   `json:"score"`
+	Accuracy        float64 `json:"accuracy"`
+	PP              float64 `json:"pp"`
+	MaxCombo        int64   `json:"maxCombo"`
+	Eliminated      bool    `json:"eliminated"`
+	EliminationTime int64   `json:"eliminationTime,omitempty"`
+
+	player *knockoutPlayer
+}
+
+type KnockoutTimeline struct {
+	Beatmap   string              `json:"beatmap"`
+	MD5       string              `json:"md5"`
+	Mode      string              `json:"mode"`
+	Events    []*KnockoutEvent    `json:"events"`
+	Standings []*KnockoutStanding `json:"standings"`
+}
+
+// Save writes the timeline to path + ".knockout.json" or, in case of CSV, path + ".events.csv" and path + ".standings.csv"
+func (timeline *KnockoutTimeline) Save(path, format string) ([]string, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	if strings.ToLower(format) == "csv" {
+		return timeline.saveCSV(path)
+	}
+
+	data, err := json.MarshalIndent(timeline, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+
+	jsonPath := path + ".knockout.json"
+
+	if err = os.WriteFile(jsonPath, data, 0644); err != nil {
+		return nil, err
+	}
+
+	return []string{jsonPath}, nil
+}
+
+func (timeline *KnockoutTimeline) saveCSV(path string) ([]string, error) {
+	eventsPath := path + ".events.csv"
+	standingsPath := path + ".standings.csv"
+
+	events := [][]string{{"time", "player", "team", "combo", "max_combo", "score", "accuracy", "pp", "eliminated", "alive"}}
+
+	for _, e := range timeline.Events {
+		events = append(events, []string{
+			strconv.FormatInt(e.Time, 10),
+			e.Player,
+			e.Team,
+			strconv.FormatInt(e.Combo, 10),
+			strconv.FormatInt(e.MaxCombo, 10),
+			strconv.FormatInt(e.Score, 10),
+			fmt.Sprintf("%.2f", e.Accuracy),
+			fmt.Sprintf("%.2f", e.PP),
+			strconv.FormatBool(e.Eliminated),
+			strconv.Itoa(e.Alive),
+		})
+	}
+
+	standings := [][]string{{"place", "player", "team", "mods", "score", "accuracy", "pp", "max_combo", "eliminated", "elimination_time"}}
+
+	for _, s := range timeline.Standings {
+		standings = append(standings, []string{
+			strconv.Itoa(s.Place),
+			s.Player,
+			s.Team,
+			s.Mods,
+			strconv.FormatInt(s.Score, 10),
+			fmt.Sprintf("%.2f", s.Accuracy),
+			fmt.Sprintf("%.2f", s.PP),
+			strconv.FormatInt(s.MaxCombo, 10),
+			strconv.FormatBool(s.Eliminated),
+			strconv.FormatInt(s.EliminationTime, 10),
+		})
+	}
+
+	if err := writeCSV(eventsPath, events); err != nil {
+		return nil, err
+	}
+
+	if err := writeCSV(standingsPath, standings); err != nil {
+		return nil, err
+	}
+
+	return []string{eventsPath, standingsPath}, nil
+}
+
+func writeCSV(path string, records [][]string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	return csv.NewWriter(file).WriteAll(records)
+}
+
+// cleanName strips the invisible rune replay controller appends to names to keep them unique
+func cleanName(name string) string {
+	if r, size := utf8.DecodeLastRuneInString(name); r >= 0x100000 {
+		return name[:len(name)-size]
+	}
+
+	return name
+}
@@ -12,8 +12,8 @@ import (
 	"github.com/wieku/danser-go/framework/math/animation/easing"
 	color2 "github.com/wieku/danser-go/framework/math/color"
 	"github.com/wieku/danser-go/framework/math/vector"
+	"github.com/wieku/danser-go/framework/util"
 	"math"
-	"math/rand"
 )
 
 type HitResults struct {
@@ -82,8 +82,8 @@ func (results *HitResults) AddResult(time int64, result osu.HitResult, position
 			particles = true
 
 			for i := 0; i < 150; i++ {
-				fadeOut := 500 + 700*rand.Float64()
-				direction := vector.NewVec2dRad(rand.Float64()*2*math.Pi, rand.Float64()*35)
+				fadeOut := 500 + 700*util.RandomFloat64()
+				direction := vector.NewVec2dRad(util.RandomFloat64()*2*math.Pi, util.RandomFloat64()*35)
 
 				sp := sprite.NewSpriteSingle(particleTex, float64(time)+0.5, position, vector.Centre)
 				sp.SetAdditive(true)
@@ -121,7 +121,7 @@ func (results *HitResults) AddResult(time int64, result osu.HitResult, position
 		}
 
 		if result == osu.Miss {
-			rotation := rand.Float64()*0.3 - 0.15
+			rotation := util.RandomFloat64()*0.3 - 0.15
 
 			hit.AddTransformUnordered(animation.NewSingleTransform(animation.Rotate, easing.Linear, float64(time), fadeIn, 0.0, rotation))
 			hit.AddTransformUnordered(animation.NewSingleTransform(animation.Rotate, easing.Linear, fadeIn, fadeOut, rotation, rotation*2))
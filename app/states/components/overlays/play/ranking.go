@@ -3,6 +3,7 @@ package play
 import (
 	"fmt"
 	"github.com/faiface/mainthread"
+	"github.com/wieku/danser-go/app/beatmap"
 	"github.com/wieku/danser-go/app/graphics"
 	"github.com/wieku/danser-go/app/rulesets/osu"
 	"github.com/wieku/danser-go/app/settings"
@@ -63,44 +64,19 @@ type RankingPanel struct {
 }
 
 func NewRankingPanel(cursor *graphics.Cursor, ruleset *osu.OsuRuleSet, hitError *HitErrorMeter, hpGraph []vector.Vector2d) *RankingPanel {
+	return NewRankingPanelWidth(cursor, ruleset, hitError, hpGraph, settings.Graphics.GetAspectRatio()*768)
+}
+
+// NewRankingPanelWidth creates ranking panel with custom virtual width, height is always 768
+func NewRankingPanelWidth(cursor *graphics.Cursor, ruleset *osu.OsuRuleSet, hitError *HitErrorMeter, hpGraph []vector.Vector2d, width float64) *RankingPanel {
 	panel := &RankingPanel{
 		manager:     sprite.NewManager(),
-		ScaledWidth: settings.Graphics.GetAspectRatio() * 768,
+		ScaledWidth: width,
 		cursor:      cursor,
 		ruleset:     ruleset,
 	}
 
-	bg := sprite.NewSpriteSingle(nil, -1, vector.NewVec2d(panel.ScaledWidth, 768).Scl(0.5), vector.Centre)
-	bg.SetColor(color.NewL(0.75))
-
-	bgLoadFunc := func() {
-		image, err := texture.NewPixmapFileString(filepath.Join(settings.General.GetSongsDir(), ruleset.GetBeatMap().Dir, ruleset.GetBeatMap().Bg))
-		if err != nil {
-			image, err = assets.GetPixmap("assets/textures/background-1.png")
-			if err != nil {
-				panic(err)
-			}
-		}
-
-		if image != nil {
-			mainthread.CallNonBlock(func() {
-				region := texture.LoadTextureSingle(image.RGBA(), 0).GetRegion()
-				bg.Texture = &region
-
-				result := scaling.Fill.Apply(region.Width, region.Height, float32(panel.ScaledWidth), 768)
-
-				bg.SetScaleV(result.Mult(vector.NewVec2f(1/region.Width, 1/region.Height)).Copy64())
-
-				image.Dispose()
-			})
-		}
-	}
-
-	if settings.RECORD {
-		bgLoadFunc()
-	} else {
-		go bgLoadFunc()
-	}
+	panel.manager.Add(newBackground(ruleset.GetBeatMap(), panel.ScaledWidth, 768, 0.75))
 
 	panel.loadMods()
 
@@ -147,7 +123,6 @@ func NewRankingPanel(cursor *graphics.Cursor, ruleset *osu.OsuRuleSet, hitError
 
 	rTitle := sprite.NewSpriteSingle(skin.GetTexture("ranking-title"), 1000, vector.NewVec2d(panel.ScaledWidth-32, 0), vector.TopRight)
 
-	panel.manager.Add(bg)
 	panel.manager.Add(rPanel)
 	panel.manager.Add(rGraph)
 	panel.manager.Add(rAcc)
@@ -176,7 +151,7 @@ func NewRankingPanel(cursor *graphics.Cursor, ruleset *osu.OsuRuleSet, hitError
 	panel.beatmapCreator = fmt.Sprintf("Beatmap by %s", bMap.Creator)
 
 	scoreTime := panel.cursor.ScoreTime
-	if settings.Gameplay.ResultsUseLocalTimeZone {
+	if settings.Gameplay.ResultsUseLocalTimeZone && !settings.DETERMINISTIC {
 		scoreTime = scoreTime.Local()
 	}
 
@@ -225,27 +200,68 @@ func NewRankingPanel(cursor *graphics.Cursor, ruleset *osu.OsuRuleSet, hitError
 }
 
 func (panel *RankingPanel) loadMods() {
-	mods := panel.ruleset.GetBeatMap().Diff.GetModStringFull()
+	loadModSprites(panel.manager, panel.ruleset.GetBeatMap().Diff.GetModStringFull(), vector.NewVec2d(panel.ScaledWidth-64, 416), -32, 1)
+}
+
+// newBackground creates a sprite filling given area with beatmap's background, falls back to danser's default background
+func newBackground(bMap *beatmap.BeatMap, width, height, brightness float64) *sprite.Sprite {
+	bg := sprite.NewSpriteSingle(nil, -1, vector.NewVec2d(width, height).Scl(0.5), vector.Centre)
+	bg.SetColor(color.NewL(float32(brightness)))
 
-	offset := -64.0
+	bgLoadFunc := func() {
+		image, err := texture.NewPixmapFileString(filepath.Join(settings.General.GetSongsDir(), bMap.Dir, bMap.Bg))
+		if err != nil {
+			image, err = assets.GetPixmap("assets/textures/background-1.png")
+			if err != nil {
+				panic(err)
+			}
+		}
+
+		if image != nil {
+			mainthread.CallNonBlock(func() {
+				region := texture.LoadTextureSingle(image.RGBA(), 0).GetRegion()
+				bg.Texture = &region
+
+				result := scaling.Fill.Apply(region.Width, region.Height, float32(width), float32(height))
+
+				bg.SetScaleV(result.Mult(vector.NewVec2f(1/region.Width, 1/region.Height)).Copy64())
+
+				image.Dispose()
+			})
+		}
+	}
+
+	if settings.RECORD {
+		bgLoadFunc()
+	} else {
+		go bgLoadFunc()
+	}
+
+	return bg
+}
+
+// loadModSprites adds mod icons to the manager, starting at pos and moving by step horizontally for each next mod
+func loadModSprites(manager *sprite.Manager, mods []string, pos vector.Vector2d, step, scale float64) {
 	for i, s := range mods {
+		mPos := vector.NewVec2d(pos.X+step*float64(i), pos.Y)
+
 		if strings.HasPrefix(s, "DA:") {
 			bgTex := skin.GetTexture("selection-mod-base")
 
-			modBg := sprite.NewSpriteSingle(bgTex, 6+float64(i), vector.NewVec2d(panel.ScaledWidth+offset, 416), vector.Centre)
-			panel.manager.Add(modBg)
+			modBg := sprite.NewSpriteSingle(bgTex, 6+float64(i), mPos, vector.Centre)
+			modBg.SetScale(scale)
+			manager.Add(modBg)
 
-			mod := sprite.NewTextSpriteSize(strings.TrimPrefix(s, "DA:"), font.GetFont("Quicksand Bold"), float64(bgTex.Height)/4, 6+float64(i)+0.5, vector.NewVec2d(panel.ScaledWidth+offset, 416), vector.Centre)
-			panel.manager.Add(mod)
+			mod := sprite.NewTextSpriteSize(strings.TrimPrefix(s, "DA:"), font.GetFont("Quicksand Bold"), float64(bgTex.Height)/4*scale, 6+float64(i)+0.5, mPos, vector.Centre)
+			manager.Add(mod)
 		} else {
 			modSpriteName := "selection-mod-" + strings.ToLower(s)
 
-			mod := sprite.NewSpriteSingle(skin.GetTexture(modSpriteName), 6+float64(i), vector.NewVec2d(panel.ScaledWidth+offset, 416), vector.Centre)
+			mod := sprite.NewSpriteSingle(skin.GetTexture(modSpriteName), 6+float64(i), mPos, vector.Centre)
+			mod.SetScale(scale)
 
-			panel.manager.Add(mod)
+			manager.Add(mod)
 		}
-
-		offset -= 32
 	}
 }
 
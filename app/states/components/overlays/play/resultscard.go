@@ -0,0 +1,120 @@
+package play
+
+import (
+	"fmt"
+	"github.com/wieku/danser-go/app/graphics"
+	"github.com/wieku/danser-go/app/rulesets/osu"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/app/skin"
+	"github.com/wieku/danser-go/framework/graphics/batch"
+	"github.com/wieku/danser-go/framework/graphics/font"
+	"github.com/wieku/danser-go/framework/graphics/sprite"
+	"github.com/wieku/danser-go/framework/math/vector"
+	"strconv"
+)
+
+const (
+	cardMargin = 48.0
+	cardHeight = 768.0
+)
+
+// ResultsCard is a summary of the play meant to be used as a video thumbnail.
+// "card" layout is a compact summary, "ranking" layout reuses RankingPanel.
+type ResultsCard struct {
+	manager *sprite.Manager
+	panel   *RankingPanel
+
+	ScaledWidth float64
+
+	title      string
+	difficulty string
+	creator    string
+	player     string
+
+	accuracy string
+	pp       string
+	combo    string
+}
+
+func NewResultsCard(cursor *graphics.Cursor, ruleset *osu.OsuRuleSet, hitError *HitErrorMeter, hpGraph []vector.Vector2d, width float64, layout string) *ResultsCard {
+	card := &ResultsCard{
+		manager:     sprite.NewManager(),
+		ScaledWidth: width,
+	}
+
+	if layout == "ranking" && len(hpGraph) > 1 {
+		card.panel = NewRankingPanelWidth(cursor, ruleset, hitError, hpGraph, width)
+		card.panel.Update(0)
+
+		return card
+	}
+
+	bMap := ruleset.GetBeatMap()
+	score := ruleset.GetScore(cursor)
+
+	card.manager.Add(newBackground(bMap, card.ScaledWidth, cardHeight, 0.45))
+
+	grade := sprite.NewSpriteSingle(skin.GetTexture("ranking-"+score.Grade.TextureName()), 5, vector.NewVec2d(card.ScaledWidth-cardMargin-160, cardHeight/2-40), vector.Centre)
+	grade.SetScale(1.3)
+
+	card.manager.Add(grade)
+
+	loadModSprites(card.manager, bMap.Diff.GetModStringFull(), vector.NewVec2d(cardMargin+40, 300), 90, 1.6)
+
+	card.title = fmt.Sprintf("%s - %s", bMap.Artist, bMap.Name)
+	card.difficulty = fmt.Sprintf("[%s]", bMap.Difficulty)
+	card.creator = fmt.Sprintf("Beatmap by %s", bMap.Creator)
+	card.player = cursor.Name
+
+	card.accuracy = fmt.Sprintf("%.2f%%", score.Accuracy)
+	card.pp = fmt.Sprintf("%."+strconv.Itoa(settings.Gameplay.PPCounter.Decimals)+"fpp", score.PP.Total)
+	card.combo = fmt.Sprintf("%dx", score.Combo)
+
+	card.manager.Update(0)
+
+	return card
+}
+
+func (card *ResultsCard) Draw(batch *batch.QuadBatch) {
+	batch.ResetTransform()
+	batch.SetColor(1, 1, 1, 1)
+
+	if card.panel != nil {
+		card.panel.Draw(batch, 1)
+		return
+	}
+
+	card.manager.Draw(0, batch)
+
+	fnt := font.GetFont("Quicksand Bold")
+	fntHUD := font.GetFont("HUDFont")
+
+	textWidth := card.ScaledWidth - 2*cardMargin - 360
+
+	card.drawText(batch, fnt, cardMargin, 90, 60, textWidth, card.title)
+	card.drawText(batch, fnt, cardMargin, 160, 44, textWidth, card.difficulty)
+	card.drawText(batch, fnt, cardMargin, 210, 30, textWidth, card.creator)
+
+	card.drawText(batch, fnt, cardMargin, cardHeight-240, 80, card.ScaledWidth-2*cardMargin, card.player)
+
+	statWidth := (card.ScaledWidth - 2*cardMargin) / 3
+
+	for i, stat := range []string{card.accuracy, card.pp, card.combo} {
+		card.drawText(batch, fntHUD, cardMargin+statWidth*float64(i), cardHeight-cardMargin-50, 96, statWidth-cardMargin, stat)
+	}
+}
+
+// drawText draws shadowed text, shrinking it if it doesn't fit in maxWidth
+func (card *ResultsCard) drawText(batch *batch.QuadBatch, fnt *font.Font, x, y, size, maxWidth float64, text string) {
+	if width := fnt.GetWidth(size, text); width > maxWidth {
+		size *= maxWidth / width
+	}
+
+	shadow := size / 20
+
+	batch.SetColor(0, 0, 0, 0.6)
+	fnt.DrawOrigin(batch, x+shadow, y+shadow, vector.CentreLeft, size, false, text)
+
+	batch.SetColor(1, 1, 1, 1)
+	fnt.DrawOrigin(batch, x, y, vector.CentreLeft, size, false, text)
+}
@@ -671,6 +671,11 @@ func (overlay *ScoreOverlay) DrawHUD(batch *batch.QuadBatch, _ []color2.Color, a
 	batch.SetCamera(prev)
 }
 
+// NewResultsCard creates results card of the play, it has to be drawn with a camera 768 units high and width units wide
+func (overlay *ScoreOverlay) NewResultsCard(width float64, layout string) *play.ResultsCard {
+	return play.NewResultsCard(overlay.cursor, overlay.ruleset, overlay.hitErrorMeter, overlay.hpSections, width, layout)
+}
+
 func (overlay *ScoreOverlay) drawScore(batch *batch.QuadBatch, alpha float64) {
 	scoreAlpha := settings.Gameplay.Score.Opacity * alpha
 
@@ -0,0 +1,151 @@
+package overlays
+
+import (
+	"github.com/wieku/danser-go/app/beatmap"
+	camera2 "github.com/wieku/danser-go/app/bmath/camera"
+	"github.com/wieku/danser-go/app/dance"
+	"github.com/wieku/danser-go/app/graphics"
+	"github.com/wieku/danser-go/app/rulesets/osu"
+	"github.com/wieku/danser-go/app/rulesets/osu/performance/pp220930"
+	"github.com/wieku/danser-go/app/states/components/containers"
+	"github.com/wieku/danser-go/framework/bass"
+	"github.com/wieku/danser-go/framework/graphics/batch"
+	"github.com/wieku/danser-go/framework/graphics/font"
+	color2 "github.com/wieku/danser-go/framework/math/color"
+	"github.com/wieku/danser-go/framework/math/vector"
+)
+
+// SplitOverlay holds a separate ScoreOverlay and a copy of hit objects for every replay shown in split screen mode.
+// Objects of each view are animated by its player's hits, views share only the audio track.
+type SplitOverlay struct {
+	overlays   []*ScoreOverlay
+	cursors    []*graphics.Cursor
+	names      []string
+	beatMaps   []*beatmap.BeatMap
+	containers []*containers.HitObjectContainer
+
+	font   *font.Font
+	camera *camera2.Camera
+}
+
+func NewSplitOverlay(replayController *dance.ReplayController) *SplitOverlay {
+	overlay := new(SplitOverlay)
+
+	ruleset := replayController.GetRuleset()
+	replays := replayController.GetReplays()
+
+	for i, cursor := range replayController.GetCursors() {
+		if i >= dance.SplitScreenViews {
+			break
+		}
+
+		overlay.overlays = append(overlay.overlays, NewScoreOverlay(ruleset, cursor))
+		overlay.cursors = append(overlay.cursors, cursor)
+		overlay.names = append(overlay.names, cleanName(replays[i].Name))
+
+		bMap := replayController.GetBeatMap().CloneObjects()
+		ruleset.SetViewObjects(cursor, bMap.HitObjects)
+
+		overlay.beatMaps = append(overlay.beatMaps, bMap)
+		overlay.containers = append(overlay.containers, containers.NewHitObjectContainer(bMap))
+	}
+
+	// Every ScoreOverlay registers itself as the only listener, so hits have to be routed to the right view manually
+	ruleset.SetListener(overlay.hitReceived)
+
+	overlay.DisableAudioSubmission(false)
+
+	overlay.font = font.GetFont("Quicksand Bold")
+
+	sO := overlay.overlays[0]
+
+	overlay.camera = camera2.NewCamera()
+	overlay.camera.SetViewportF(0, int(sO.ScaledHeight), int(sO.ScaledWidth), 0)
+	overlay.camera.Update()
+
+	return overlay
+}
+
+func (overlay *SplitOverlay) hitReceived(c *graphics.Cursor, time int64, number int64, position vector.Vector2d, result osu.HitResult, comboResult osu.ComboResult, ppResults pp220930.PPv2Results, score int64) {
+	for i, cursor := range overlay.cursors {
+		if cursor == c {
+			overlay.overlays[i].hitReceived(c, time, number, position, result, comboResult, ppResults, score)
+			return
+		}
+	}
+}
+
+// GetViews returns the number of playfields to draw
+func (overlay *SplitOverlay) GetViews() int {
+	return len(overlay.overlays)
+}
+
+func (overlay *SplitOverlay) GetOverlay(view int) *ScoreOverlay {
+	return overlay.overlays[view]
+}
+
+func (overlay *SplitOverlay) GetCursor(view int) *graphics.Cursor {
+	return overlay.cursors[view]
+}
+
+// GetObjects returns hit objects animated by view's player
+func (overlay *SplitOverlay) GetObjects(view int) *containers.HitObjectContainer {
+	return overlay.containers[view]
+}
+
+// DrawName draws player's name at the top of the view
+func (overlay *SplitOverlay) DrawName(batch *batch.QuadBatch, view int, alpha float64) {
+	prev := batch.Projection
+	batch.SetCamera(overlay.camera.GetProjectionView())
+	batch.ResetTransform()
+
+	scl := overlay.overlays[view].ScaledHeight * 0.05
+
+	batch.SetColor(0, 0, 0, alpha*0.8)
+	overlay.font.DrawOrigin(batch, overlay.overlays[view].ScaledWidth/2+2, scl+2, vector.TopCentre, scl, false, overlay.names[view])
+
+	batch.SetColor(1, 1, 1, alpha)
+	overlay.font.DrawOrigin(batch, overlay.overlays[view].ScaledWidth/2, scl, vector.TopCentre, scl, false, overlay.names[view])
+
+	batch.SetCamera(prev)
+}
+
+func (overlay *SplitOverlay) Update(time float64) {
+	for i, o := range overlay.overlays {
+		overlay.beatMaps[i].Update(time)
+		overlay.containers[i].UpdateFollowPoints(time)
+
+		o.Update(time)
+	}
+}
+
+func (overlay *SplitOverlay) SetMusic(music bass.ITrack) {
+	for _, o := range overlay.overlays {
+		o.SetMusic(music)
+	}
+}
+
+// Views are drawn by Player separately through GetOverlay, so SplitOverlay doesn't draw anything by itself
+
+func (overlay *SplitOverlay) DrawBackground(_ *batch.QuadBatch, _ []color2.Color, _ float64) {}
+
+func (overlay *SplitOverlay) DrawBeforeObjects(_ *batch.QuadBatch, _ []color2.Color, _ float64) {}
+
+func (overlay *SplitOverlay) DrawNormal(_ *batch.QuadBatch, _ []color2.Color, _ float64) {}
+
+func (overlay *SplitOverlay) DrawHUD(_ *batch.QuadBatch, _ []color2.Color, _ float64) {}
+
+func (overlay *SplitOverlay) IsBroken(_ *graphics.Cursor) bool {
+	return false
+}
+
+// DisableAudioSubmission keeps audio (combo break sounds etc.) only for the first view so sounds are not doubled
+func (overlay *SplitOverlay) DisableAudioSubmission(b bool) {
+	for i, o := range overlay.overlays {
+		o.DisableAudioSubmission(b || i > 0)
+	}
+}
+
+func (overlay *SplitOverlay) ShouldDrawHUDBeforeCursor() bool {
+	return true
+}
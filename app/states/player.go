@@ -17,6 +17,7 @@ import (
 	"github.com/wieku/danser-go/app/states/components/common"
 	"github.com/wieku/danser-go/app/states/components/containers"
 	"github.com/wieku/danser-go/app/states/components/overlays"
+	"github.com/wieku/danser-go/app/states/components/overlays/play"
 	"github.com/wieku/danser-go/app/utils"
 	"github.com/wieku/danser-go/framework/bass"
 	"github.com/wieku/danser-go/framework/frame"
@@ -25,6 +26,7 @@ import (
 	"github.com/wieku/danser-go/framework/graphics/effects"
 	"github.com/wieku/danser-go/framework/graphics/font"
 	"github.com/wieku/danser-go/framework/graphics/texture"
+	"github.com/wieku/danser-go/framework/graphics/viewport"
 	"github.com/wieku/danser-go/framework/math/animation"
 	"github.com/wieku/danser-go/framework/math/animation/easing"
 	color2 "github.com/wieku/danser-go/framework/math/color"
@@ -33,9 +35,9 @@ import (
 	"github.com/wieku/danser-go/framework/math/vector"
 	"github.com/wieku/danser-go/framework/qpc"
 	"github.com/wieku/danser-go/framework/statistic"
+	"github.com/wieku/danser-go/framework/util"
 	"log"
 	"math"
-	"math/rand"
 	"path/filepath"
 	"runtime"
 	"strconv"
@@ -84,6 +86,7 @@ type Player struct {
 	Epi             *texture.TextureRegion
 	epiGlider       *animation.Glider
 	overlay         overlays.Overlay
+	ghost           *overlays.GhostOverlay
 	blur            *effects.BlurEffect
 
 	coin *common.DanserCoin
@@ -254,6 +257,12 @@ func NewPlayer(beatMap *beatmap.BeatMap) *Player {
 		player.controller.SetBeatMap(player.bMap)
 		player.controller.InitCursors()
 		player.overlay = overlays.NewScoreOverlay(player.controller.(*dance.PlayerController).GetRuleset(), player.controller.GetCursors()[0])
+	} else if settings.SPECTATE != "" {
+		player.controller = dance.NewSpectatorController()
+
+		player.controller.SetBeatMap(player.bMap)
+		player.controller.InitCursors()
+		player.overlay = overlays.NewScoreOverlay(player.controller.(*dance.SpectatorController).GetRuleset(), player.controller.GetCursors()[0])
 	} else if settings.KNOCKOUT {
 		controller := dance.NewReplayController()
 		player.controller = controller
@@ -263,6 +272,16 @@ func NewPlayer(beatMap *beatmap.BeatMap) *Player {
 
 		if settings.PLAYERS == 1 {
 			player.overlay = overlays.NewScoreOverlay(player.controller.(*dance.ReplayController).GetRuleset(), player.controller.GetCursors()[0])
+		} else if settings.GHOST != "" && settings.PLAYERS == 2 {
+			ruleset := controller.(*dance.ReplayController).GetRuleset()
+			cursors := player.controller.GetCursors()
+
+			scoreOverlay := overlays.NewScoreOverlay(ruleset, cursors[0])
+
+			player.overlay = scoreOverlay
+			player.ghost = overlays.NewGhostOverlay(scoreOverlay, ruleset, cursors[0], cursors[1], controller.(*dance.ReplayController).GetReplays()[1].Name)
+		} else if settings.Knockout.Mode == settings.SplitScreen {
+			player.overlay = overlays.NewSplitOverlay(controller.(*dance.ReplayController))
 		} else {
 			player.overlay = overlays.NewKnockoutOverlay(controller.(*dance.ReplayController))
 		}
@@ -539,12 +558,7 @@ func NewPlayer(beatMap *beatmap.BeatMap) *Player {
 				platformOffset = windowsOffset
 			}
 
-			oldOffset := 0.0
-			if player.bMap.Version < 5 {
-				oldOffset = -24
-			}
-
-			player.progressMsF = player.rawPositionF + (platformOffset+float64(settings.Audio.Offset)+float64(settings.LOCALOFFSET))*speed + oldOffset
+			player.progressMsF = player.rawPositionF + (platformOffset+float64(settings.Audio.Offset)+float64(settings.LOCALOFFSET))*speed + player.getMapOffset()
 
 			player.updateMain(delta)
 
@@ -568,10 +582,16 @@ func (player *Player) trySetupFail() {
 			ruleset = rC.GetRuleset()
 		} else if rP, ok2 := player.controller.(*dance.PlayerController); ok2 {
 			ruleset = rP.GetRuleset()
+		} else if rS, ok3 := player.controller.(*dance.SpectatorController); ok3 {
+			ruleset = rS.GetRuleset()
 		}
 
 		if ruleset != nil {
 			ruleset.SetFailListener(func(cursor *graphics.Cursor) {
+				if player.ghost != nil && player.ghost.IsGhost(cursor) {
+					return
+				}
+
 				if !settings.RECORD {
 					audio.PlayFailSound()
 				}
@@ -583,10 +603,10 @@ func (player *Player) trySetupFail() {
 				player.frequencyGlider.AddEvent(player.realTime, player.realTime+2400, 0.0)
 				player.objectsAlphaFail.AddEvent(player.realTime, player.realTime+2400, 0.0)
 
-				player.failOX.AddEvent(player.realTime, player.realTime+2400, camera2.OsuWidth*(rand.Float64()-0.5)/2)
-				player.failOY.AddEvent(player.realTime, player.realTime+2400, -camera2.OsuHeight*(1+rand.Float64()*0.2))
+				player.failOX.AddEvent(player.realTime, player.realTime+2400, camera2.OsuWidth*(util.RandomFloat64()-0.5)/2)
+				player.failOY.AddEvent(player.realTime, player.realTime+2400, -camera2.OsuHeight*(1+util.RandomFloat64()*0.2))
 
-				rotBase := rand.Float64()
+				rotBase := util.RandomFloat64()
 
 				player.failRotation.AddEvent(player.realTime, player.realTime+2400, math.Copysign((math.Abs(rotBase)*0.5+0.5)/6*math.Pi, rotBase))
 
@@ -619,12 +639,7 @@ func (player *Player) Update(delta float64) bool {
 
 	player.rawPositionF += delta * speed
 
-	oldOffset := 0.0
-	if player.bMap.Version < 5 {
-		oldOffset = -24
-	}
-
-	player.progressMsF = player.rawPositionF + float64(settings.LOCALOFFSET)*speed + oldOffset
+	player.progressMsF = player.rawPositionF + float64(settings.LOCALOFFSET)*speed + player.getMapOffset()
 
 	player.updateMain(delta)
 
@@ -646,6 +661,43 @@ func (player *Player) GetTimeOffset() float64 {
 	return player.progressMsF - player.startOffset
 }
 
+func (player *Player) GetController() dance.Controller {
+	return player.controller
+}
+
+// GetKnockoutTimeline returns eliminations and standings of current play, returns nil if it's not a knockout play
+func (player *Player) GetKnockoutTimeline() *overlays.KnockoutTimeline {
+	if knockoutOverlay, ok := player.overlay.(*overlays.KnockoutOverlay); ok {
+		return knockoutOverlay.GetTimeline()
+	}
+
+	return nil
+}
+
+// NewResultsCard creates results card of current play, returns nil if it's not a single player play
+func (player *Player) NewResultsCard(width float64, layout string) *play.ResultsCard {
+	if scoreOverlay, ok := player.overlay.(*overlays.ScoreOverlay); ok {
+		return scoreOverlay.NewResultsCard(width, layout)
+	}
+
+	return nil
+}
+
+// getMapOffset returns beatmap specific offset added to music position. osu! subtracts beatmap offsets from music position,
+// so positive offsets delay objects. Online offset is summed with the 24ms offset of old (v4 and older) beatmaps, so both use the same sign.
+func (player *Player) getMapOffset() float64 {
+	mapOffset := 0.0
+	if player.bMap.Version < 5 {
+		mapOffset = -24
+	}
+
+	if settings.Audio.OnlineOffset {
+		mapOffset -= float64(player.bMap.OnlineOffset)
+	}
+
+	return mapOffset
+}
+
 func (player *Player) updateMain(delta float64) {
 	player.realTime += delta
 
@@ -726,6 +778,10 @@ func (player *Player) updateMain(delta float64) {
 		player.overlay.Update(player.progressMsF)
 	}
 
+	if player.ghost != nil {
+		player.ghost.Update(player.progressMsF)
+	}
+
 	player.updateMusic(delta)
 
 	player.coin.Update(player.progressMsF)
@@ -849,6 +905,20 @@ func (player *Player) Draw(float64) {
 		player.bloomEffect.Begin()
 	}
 
+	if split, ok := player.overlay.(*overlays.SplitOverlay); ok {
+		player.drawSplit(split, cursorColors, objectCameras, cursorCameras, scale2)
+
+		player.background.DrawOverlay(player.progressMsF, player.batch, bgAlpha, player.bgCamera.GetProjectionView())
+
+		if bloomEnabled {
+			player.bloomEffect.EndAndRender()
+		}
+
+		player.drawDebug()
+
+		return
+	}
+
 	if player.overlay != nil {
 		player.drawOverlayPart(player.overlay.DrawBeforeObjects, cursorColors, objectCameras[0], player.objectsAlphaFail.GetValue())
 	}
@@ -859,12 +929,20 @@ func (player *Player) Draw(float64) {
 		player.drawOverlayPart(player.overlay.DrawNormal, cursorColors, objectCameras[0], 1)
 	}
 
+	if player.ghost != nil {
+		player.drawOverlayPart(player.ghost.DrawNormal, cursorColors, objectCameras[0], 1)
+	}
+
 	player.background.DrawOverlay(player.progressMsF, player.batch, bgAlpha, player.bgCamera.GetProjectionView())
 
 	if player.overlay != nil && player.overlay.ShouldDrawHUDBeforeCursor() {
 		player.drawOverlayPart(player.overlay.DrawHUD, cursorColors, player.uiCamera.GetProjectionView(), 1)
 	}
 
+	if player.ghost != nil {
+		player.drawOverlayPart(player.ghost.DrawHUD, cursorColors, player.uiCamera.GetProjectionView(), 1)
+	}
+
 	if settings.Playfield.DrawCursors {
 		for _, g := range player.controller.GetCursors() {
 			g.UpdateRenderer()
@@ -892,6 +970,11 @@ func (player *Player) Draw(float64) {
 				col1 := cursorColors[baseIndex]
 				col2 := cursorColors[ind]
 
+				if player.ghost != nil && player.ghost.IsGhost(g) {
+					col1.A *= float32(settings.Gameplay.Ghost.CursorOpacity)
+					col2.A *= float32(settings.Gameplay.Ghost.CursorOpacity)
+				}
+
 				g.DrawM(scale2, player.batch, col1, col2)
 			}
 		}
@@ -912,6 +995,70 @@ func (player *Player) Draw(float64) {
 	player.drawDebug()
 }
 
+// drawSplit draws every replay of SplitOverlay on its own playfield. Two views are placed side by side, more are laid out in a 2x2 grid.
+func (player *Player) drawSplit(split *overlays.SplitOverlay, cursorColors []color2.Color, objectCameras, cursorCameras []mgl32.Mat4, cursorScale float64) {
+	width := int(settings.Graphics.GetWidth())
+	height := int(settings.Graphics.GetHeight())
+
+	views := split.GetViews()
+
+	for i := 0; i < views; i++ {
+		x := (i % 2) * width / 2
+		y := height / 4
+
+		if views > 2 {
+			y = (1 - i/2) * height / 2 // OpenGL's origin is in the bottom-left corner
+		}
+
+		viewport.PushPos(x, y, width/2, height/2)
+
+		sO := split.GetOverlay(i)
+
+		player.drawOverlayPart(sO.DrawBackground, cursorColors, cursorCameras[0], 1)
+		player.drawOverlayPart(sO.DrawBeforeObjects, cursorColors, objectCameras[0], player.objectsAlphaFail.GetValue())
+
+		split.GetObjects(i).Draw(player.batch, player.mainCamera.GetProjectionView(), objectCameras, player.progressMsF, float32(player.Scl), float32(player.objectsAlpha.GetValue()*player.objectsAlphaFail.GetValue()))
+
+		player.drawOverlayPart(sO.DrawNormal, cursorColors, objectCameras[0], 1)
+		player.drawOverlayPart(sO.DrawHUD, cursorColors, player.uiCamera.GetProjectionView(), 1)
+
+		if settings.Playfield.DrawCursors {
+			cursor := split.GetCursor(i)
+			cursor.UpdateRenderer()
+
+			player.batch.SetAdditive(false)
+
+			graphics.BeginCursorRender()
+
+			for j := 0; j < settings.DIVIDES; j++ {
+				player.batch.SetCamera(cursorCameras[j])
+
+				baseIndex := j*len(player.controller.GetCursors()) + i
+
+				ind := baseIndex - 1
+				if ind < 0 {
+					ind = settings.DIVIDES*len(player.controller.GetCursors()) - 1
+				}
+
+				cursor.DrawM(cursorScale, player.batch, cursorColors[baseIndex], cursorColors[ind])
+			}
+
+			graphics.EndCursorRender()
+		}
+
+		player.batch.SetAdditive(false)
+
+		player.batch.Begin()
+		split.DrawName(player.batch, i, player.hudGlider.GetValue())
+		player.batch.End()
+
+		player.batch.ResetTransform()
+		player.batch.SetColor(1, 1, 1, 1)
+
+		viewport.Pop()
+	}
+}
+
 func (player *Player) drawEpilepsyWarning() {
 	if player.epiGlider.GetValue() < 0.01 {
 		return
@@ -1129,4 +1276,13 @@ func (player *Player) Show() {}
 
 func (player *Player) Hide() {}
 
-func (player *Player) Dispose() {}
+func (player *Player) Dispose() {
+	if sC, ok := player.controller.(*dance.SpectatorController); ok {
+		sC.Dispose()
+	}
+
+	player.background.Dispose()
+	player.bloomEffect.Dispose()
+	player.blur.Dispose()
+	player.batch.Dispose()
+}
@@ -27,6 +27,7 @@ import (
 type Storyboard struct {
 	textures map[string]*texture.TextureRegion
 	atlas    *texture.TextureAtlas
+	singles  []*texture.TextureSingle
 
 	samples map[string]*bass.Sample
 
@@ -323,6 +324,8 @@ func (storyboard *Storyboard) getTexture(image string) *texture.TextureRegion {
 					tex.SetData(0, 0, img.Width, img.Height, img.Data)
 					rg := tex.GetRegion()
 					texture1 = &rg
+
+					storyboard.singles = append(storyboard.singles, tex)
 				} else {
 					if storyboard.atlas == nil {
 						storyboard.atlas = texture.NewTextureAtlas(4096, 0)
@@ -353,6 +356,9 @@ func (storyboard *Storyboard) getSample(sample string) (bassSample *bass.Sample)
 		}
 
 		bassSample = bass.NewSample(path)
+		if bassSample != nil {
+			bassSample.SetStem(bass.StemStoryboard)
+		}
 	}
 
 	return
@@ -384,6 +390,22 @@ func (storyboard *Storyboard) StopThread() {
 	storyboard.shouldRun = false
 }
 
+// Dispose stops the update thread and frees textures loaded from beatmap's directory
+func (storyboard *Storyboard) Dispose() {
+	storyboard.StopThread()
+
+	if storyboard.atlas != nil {
+		storyboard.atlas.Dispose()
+		storyboard.atlas = nil
+	}
+
+	for _, tex := range storyboard.singles {
+		tex.Dispose()
+	}
+
+	storyboard.singles = nil
+}
+
 func (storyboard *Storyboard) IsThreadRunning() bool {
 	return storyboard.shouldRun
 }
@@ -0,0 +1,109 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/wieku/danser-go/app/settings"
+	"os"
+	"strings"
+)
+
+type knockoutEntry struct {
+	Path string `json:"path"`
+	Team string `json:"team"`
+}
+
+// parseKnockoutReplays parses -knockout2 list. Entries can be plain replay paths or {"path": "...", "team": "..."} objects, the latter are added to given teams.
+func parseKnockoutReplays(data string, teams []*settings.KnockoutTeam) ([]string, []*settings.KnockoutTeam, error) {
+	var entries []json.RawMessage
+
+	if err := json.Unmarshal([]byte(data), &entries); err != nil {
+		return nil, nil, err
+	}
+
+	replays := make([]string, 0, len(entries))
+
+	for i, rawEntry := range entries {
+		var path string
+		if err := json.Unmarshal(rawEntry, &path); err == nil {
+			replays = append(replays, path)
+			continue
+		}
+
+		var entry knockoutEntry
+		if err := json.Unmarshal(rawEntry, &entry); err != nil {
+			return nil, nil, fmt.Errorf("entry %d: %s", i, err)
+		}
+
+		if strings.TrimSpace(entry.Path) == "" {
+			return nil, nil, fmt.Errorf("entry %d: replay path is missing", i)
+		}
+
+		replays = append(replays, entry.Path)
+
+		if strings.TrimSpace(entry.Team) != "" {
+			var team *settings.KnockoutTeam
+			team, teams = getOrAddTeam(teams, entry.Team)
+
+			team.Players = append(team.Players, entry.Path)
+		}
+	}
+
+	return replays, teams, nil
+}
+
+// parseTeams parses -teams flag, it accepts JSON list of teams or a path to file containing it
+func parseTeams(data string) ([]*settings.KnockoutTeam, error) {
+	data = strings.TrimSpace(data)
+
+	if !strings.HasPrefix(data, "[") {
+		fileData, err := os.ReadFile(data)
+		if err != nil {
+			return nil, err
+		}
+
+		data = string(fileData)
+	}
+
+	var parsed []*settings.KnockoutTeam
+
+	if err := json.Unmarshal([]byte(data), &parsed); err != nil {
+		return nil, err
+	}
+
+	var teams []*settings.KnockoutTeam
+
+	for i, t := range parsed {
+		if t == nil || strings.TrimSpace(t.Name) == "" {
+			return nil, fmt.Errorf("team %d: name is missing", i)
+		}
+
+		var team *settings.KnockoutTeam
+		team, teams = getOrAddTeam(teams, t.Name)
+
+		if t.Color != "" {
+			team.Color = t.Color
+		}
+
+		team.Players = append(team.Players, t.Players...)
+	}
+
+	if len(teams) == 0 {
+		return nil, errors.New("no teams specified")
+	}
+
+	return teams, nil
+}
+
+func getOrAddTeam(teams []*settings.KnockoutTeam, name string) (*settings.KnockoutTeam, []*settings.KnockoutTeam) {
+	for _, team := range teams {
+		if strings.EqualFold(team.Name, name) {
+			return team, teams
+		}
+	}
+
+	team := &settings.KnockoutTeam{Name: name}
+
+	return team, append(teams, team)
+}
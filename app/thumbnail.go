@@ -0,0 +1,100 @@
+package app
+
+import (
+	"github.com/faiface/mainthread"
+	"github.com/go-gl/gl/v3.3-core/gl"
+	camera2 "github.com/wieku/danser-go/app/bmath/camera"
+	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/app/states"
+	"github.com/wieku/danser-go/framework/graphics/blend"
+	"github.com/wieku/danser-go/framework/graphics/buffer"
+	"github.com/wieku/danser-go/framework/graphics/texture"
+	"github.com/wieku/danser-go/framework/graphics/viewport"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func mainLoopThumbnail() {
+	p, _ := player.(*states.Player)
+
+	for !p.Update(1) {
+	}
+
+	name := output
+	if strings.TrimSpace(name) == "" {
+		name = "danser_" + time.Now().Format("2006-01-02_15-04-05")
+	}
+
+	makeThumbnail(filepath.Join(settings.Recording.GetOutputDir(), name+".png"))
+}
+
+// makeThumbnail renders the results card of finished play to a PNG file, has to be called outside the main thread
+func makeThumbnail(path string) {
+	p, _ := player.(*states.Player)
+
+	w, h := settings.Recording.Thumbnail.Width, settings.Recording.Thumbnail.Height
+
+	scaledWidth := 768 * float64(w) / float64(h)
+
+	card := p.NewResultsCard(scaledWidth, settings.Recording.Thumbnail.Layout)
+	if card == nil {
+		log.Println("Thumbnails are supported only in single player renders, skipping...")
+		return
+	}
+
+	log.Println("Creating thumbnail...")
+
+	mainthread.Call(func() {
+		fbo := buffer.NewFrameMultisampleScreen(w, h, false, 0)
+		defer fbo.Dispose()
+
+		fbo.Bind()
+		viewport.Push(w, h)
+
+		gl.ClearColor(0, 0, 0, 1)
+		gl.Clear(gl.COLOR_BUFFER_BIT)
+
+		blend.Enable()
+		blend.SetFunction(blend.One, blend.OneMinusSrcAlpha)
+
+		camera := camera2.NewCamera()
+		camera.SetViewportF(0, 768, int(scaledWidth), 0)
+		camera.Update()
+
+		prev := batch.Projection
+
+		batch.Begin()
+		batch.SetCamera(camera.GetProjectionView())
+
+		card.Draw(batch)
+
+		batch.End()
+		batch.SetCamera(prev)
+
+		blend.ClearStack()
+
+		pixmap := texture.NewPixMapC(w, h, 3)
+		defer pixmap.Dispose()
+
+		gl.PixelStorei(gl.PACK_ALIGNMENT, int32(1))
+		gl.ReadPixels(0, 0, int32(w), int32(h), gl.RGB, gl.UNSIGNED_BYTE, pixmap.RawPointer)
+
+		viewport.Pop()
+		fbo.Unbind()
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			log.Println("Failed to save the thumbnail! Error:", err)
+			return
+		}
+
+		if err := pixmap.WritePng(path, true); err != nil {
+			log.Println("Failed to save the thumbnail! Error:", err)
+			return
+		}
+
+		log.Println("Thumbnail saved to:", path)
+	})
+}
@@ -10,6 +10,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
@@ -112,3 +113,71 @@ func Unzip(src string, dest string) ([]string, error) {
 	}
 	return filenames, nil
 }
+
+// Zip creates a zip archive at dest. Keys of files are names inside the archive, values are paths of files on disk.
+func Zip(dest string, files map[string]string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+
+	outFile, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+
+	w := zip.NewWriter(outFile)
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err = addToZip(w, name, files[name]); err != nil {
+			w.Close()
+			outFile.Close()
+
+			return err
+		}
+	}
+
+	if err = w.Close(); err != nil {
+		outFile.Close()
+		return err
+	}
+
+	return outFile.Close()
+}
+
+func addToZip(w *zip.Writer, name, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+
+	header.Name = filepath.ToSlash(name)
+	header.Method = zip.Deflate
+
+	entry, err := w.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(entry, file)
+
+	return err
+}
@@ -10,10 +10,110 @@ import (
 	"unsafe"
 )
 
+type Stem int
+
+const (
+	StemMusic = Stem(iota)
+	StemHitsounds
+	StemStoryboard
+
+	StemCount
+)
+
+func (stem Stem) String() string {
+	switch stem {
+	case StemMusic:
+		return "music"
+	case StemHitsounds:
+		return "hitsounds"
+	case StemStoryboard:
+		return "storyboard"
+	}
+
+	return "unknown"
+}
+
+var stemMixers [StemCount]C.HSTREAM
+
+var stemsEnabled bool
+
+// initStems creates decoding mixers for each stem. Channels added afterwards are routed to them instead of the master mixer.
+func initStems(mixerFlags C.DWORD) {
+	for i := range stemMixers {
+		stemMixers[i] = C.BASS_Mixer_StreamCreate(C.DWORD(sampleRate), 2, mixerFlags)
+		C.BASS_ChannelSetAttribute(stemMixers[i], C.BASS_ATTRIB_BUFFER, 0)
+	}
+
+	stemsEnabled = true
+}
+
+func getMixer(stem Stem) C.HSTREAM {
+	if stemsEnabled {
+		return stemMixers[stem]
+	}
+
+	return masterMixer
+}
+
+func StemsEnabled() bool {
+	return stemsEnabled
+}
+
 func GetMixerRequiredBufferSize(seconds float64) int {
 	return int(C.BASS_ChannelSeconds2Bytes(masterMixer, C.double(seconds)))
 }
 
 func ProcessMixer(buffer []byte) {
+	if stemsEnabled {
+		ProcessStems(buffer, nil)
+		return
+	}
+
 	C.BASS_ChannelGetData(masterMixer, unsafe.Pointer(&buffer[0]), C.DWORD(len(buffer)))
 }
+
+// ProcessStems renders each stem into its own buffer and sums them into buffer, producing the same output as the master mixer.
+// Stem buffers that are nil are rendered into a scratch buffer. Stems have to be enabled.
+func ProcessStems(buffer []byte, stems [][]byte) {
+	mix := unsafe.Slice((*float32)(unsafe.Pointer(&buffer[0])), len(buffer)/4)
+
+	for i := range mix {
+		mix[i] = 0
+	}
+
+	for i, mixer := range stemMixers {
+		var stemBuffer []byte
+		if i < len(stems) {
+			stemBuffer = stems[i]
+		}
+
+		if stemBuffer == nil {
+			stemBuffer = getScratchBuffer(len(buffer))
+		}
+
+		read := int(C.BASS_ChannelGetData(mixer, unsafe.Pointer(&stemBuffer[0]), C.DWORD(len(stemBuffer))))
+		if read < 0 || read > len(stemBuffer) {
+			read = 0
+		}
+
+		for j := read; j < len(stemBuffer); j++ {
+			stemBuffer[j] = 0
+		}
+
+		stemData := unsafe.Slice((*float32)(unsafe.Pointer(&stemBuffer[0])), len(stemBuffer)/4)
+
+		for j := range mix {
+			mix[j] += stemData[j]
+		}
+	}
+}
+
+var scratchBuffer []byte
+
+func getScratchBuffer(size int) []byte {
+	if len(scratchBuffer) != size {
+		scratchBuffer = make([]byte, size)
+	}
+
+	return scratchBuffer
+}
@@ -81,6 +81,10 @@ func Init(offscreen bool) {
 		C.BASS_ChannelSetAttribute(masterMixer, C.BASS_ATTRIB_BUFFER, 0)
 		C.BASS_ChannelSetDevice(masterMixer, C.BASS_GetDevice())
 
+		if offscreen && settings.Recording.AudioStems.Enabled {
+			initStems(C.DWORD(mixerFlags))
+		}
+
 		if !offscreen {
 			C.BASS_ChannelPlay(masterMixer, 0)
 		}
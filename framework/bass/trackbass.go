@@ -87,7 +87,7 @@ func (track *TrackBass) AddSilence(seconds float64) {
 func (track *TrackBass) Play() {
 	track.SetVolume(settings.Audio.GeneralVolume * settings.Audio.MusicVolume)
 
-	C.BASS_Mixer_StreamAddChannel(masterMixer, track.channel, C.BASS_MIXER_CHAN_NORAMPIN|C.BASS_MIXER_CHAN_BUFFER)
+	C.BASS_Mixer_StreamAddChannel(getMixer(StemMusic), track.channel, C.BASS_MIXER_CHAN_NORAMPIN|C.BASS_MIXER_CHAN_BUFFER)
 
 	track.playing = true
 	track.addedToMixer = true
@@ -98,7 +98,7 @@ func (track *TrackBass) PlayV(volume float64) {
 
 	track.playing = true
 
-	C.BASS_Mixer_StreamAddChannel(masterMixer, track.channel, C.BASS_MIXER_CHAN_NORAMPIN|C.BASS_MIXER_CHAN_BUFFER)
+	C.BASS_Mixer_StreamAddChannel(getMixer(StemMusic), track.channel, C.BASS_MIXER_CHAN_NORAMPIN|C.BASS_MIXER_CHAN_BUFFER)
 	track.addedToMixer = true
 }
 
@@ -49,7 +49,7 @@ func (track *TrackVirtual) PlayV(_ float64) {
 func (track *TrackVirtual) playInternal() {
 	track.playing = true
 
-	track.startTime = float64(C.BASS_ChannelBytes2Seconds(masterMixer, C.BASS_ChannelGetPosition(masterMixer, C.BASS_POS_BYTE)))
+	track.startTime = getClockTime()
 	track.previousPosition = 0
 }
 
@@ -83,7 +83,7 @@ func (track *TrackVirtual) GetLength() float64 {
 
 func (track *TrackVirtual) SetPosition(pos float64) {
 	track.previousPosition = pos
-	track.startTime = float64(C.BASS_ChannelBytes2Seconds(masterMixer, C.BASS_ChannelGetPosition(masterMixer, C.BASS_POS_BYTE)))
+	track.startTime = getClockTime()
 }
 
 func (track *TrackVirtual) GetPosition() float64 {
@@ -91,7 +91,7 @@ func (track *TrackVirtual) GetPosition() float64 {
 		return track.previousPosition
 	}
 
-	currentPos := float64(C.BASS_ChannelBytes2Seconds(masterMixer, C.BASS_ChannelGetPosition(masterMixer, C.BASS_POS_BYTE)))
+	currentPos := getClockTime()
 
 	pos := track.previousPosition + (currentPos-track.startTime)*track.speed*track.rFreq
 
@@ -104,7 +104,7 @@ func (track *TrackVirtual) SetTempo(tempo float64) {
 	}
 
 	track.previousPosition = track.GetPosition()
-	track.startTime = float64(C.BASS_ChannelBytes2Seconds(masterMixer, C.BASS_ChannelGetPosition(masterMixer, C.BASS_POS_BYTE)))
+	track.startTime = getClockTime()
 
 	track.speed = tempo
 }
@@ -127,7 +127,7 @@ func (track *TrackVirtual) SetRelativeFrequency(rFreq float64) {
 	}
 
 	track.previousPosition = track.GetPosition()
-	track.startTime = float64(C.BASS_ChannelBytes2Seconds(masterMixer, C.BASS_ChannelGetPosition(masterMixer, C.BASS_POS_BYTE)))
+	track.startTime = getClockTime()
 
 	track.rFreq = rFreq
 }
@@ -183,3 +183,11 @@ func (track *TrackVirtual) GetBoost() float64 {
 func (track *TrackVirtual) GetBeat() float64 {
 	return 0
 }
+
+// getClockTime returns processed time of the mixer that drives virtual tracks. In stems mode master mixer isn't read,
+// so music stem's mixer is used instead.
+func getClockTime() float64 {
+	mixer := getMixer(StemMusic)
+
+	return float64(C.BASS_ChannelBytes2Seconds(mixer, C.BASS_ChannelGetPosition(mixer, C.BASS_POS_BYTE)))
+}
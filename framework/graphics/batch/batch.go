@@ -134,6 +134,11 @@ func newQuadBatchSize(maxSprites int, persistent bool) *QuadBatch {
 	}
 }
 
+func (batch *QuadBatch) Dispose() {
+	batch.shader.Dispose()
+	batch.vao.Dispose()
+}
+
 func (batch *QuadBatch) Begin() {
 	if batch.drawing {
 		panic("Batching has already begun")
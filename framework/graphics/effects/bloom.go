@@ -73,6 +73,14 @@ func NewBloomEffect(width, height int) *BloomEffect {
 	return effect
 }
 
+func (effect *BloomEffect) Dispose() {
+	effect.filterShader.Dispose()
+	effect.combineShader.Dispose()
+	effect.fbo.Dispose()
+	effect.blurEffect.Dispose()
+	effect.vao.Dispose()
+}
+
 func (effect *BloomEffect) SetThreshold(threshold float64) {
 	effect.threshold = threshold
 }
@@ -62,6 +62,13 @@ func NewBlurEffect(width, height int) *BlurEffect {
 	return effect
 }
 
+func (effect *BlurEffect) Dispose() {
+	effect.blurShader.Dispose()
+	effect.fbo1.Dispose()
+	effect.fbo2.Dispose()
+	effect.vao.Dispose()
+}
+
 func (effect *BlurEffect) SetBlur(blurX, blurY float64) {
 	sigmaX, sigmaY := float32(blurX)*25, float32(blurY)*25
 
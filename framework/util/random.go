@@ -3,8 +3,60 @@ package util
 import (
 	"encoding/hex"
 	"math/rand"
+	"sync"
+	"time"
 )
 
+var randMutex = &sync.Mutex{}
+var random = rand.New(rand.NewSource(time.Now().UnixNano()))
+
+// SetRandomSeed reseeds the shared generator used by visual effects, used to make renders reproducible
+func SetRandomSeed(seed int64) {
+	randMutex.Lock()
+	random.Seed(seed)
+	randMutex.Unlock()
+}
+
+// RandomFloat64 returns a pseudo-random number in [0.0,1.0) from the shared generator
+func RandomFloat64() float64 {
+	randMutex.Lock()
+	defer randMutex.Unlock()
+
+	return random.Float64()
+}
+
+// RandomFloat32 returns a pseudo-random number in [0.0,1.0) from the shared generator
+func RandomFloat32() float32 {
+	randMutex.Lock()
+	defer randMutex.Unlock()
+
+	return random.Float32()
+}
+
+// RandomInt returns a non-negative pseudo-random int from the shared generator
+func RandomInt() int {
+	randMutex.Lock()
+	defer randMutex.Unlock()
+
+	return random.Int()
+}
+
+// RandomIntn returns a non-negative pseudo-random number in [0,n) from the shared generator
+func RandomIntn(n int) int {
+	randMutex.Lock()
+	defer randMutex.Unlock()
+
+	return random.Intn(n)
+}
+
+// RandomShuffle pseudo-randomizes the order of elements using the shared generator
+func RandomShuffle(n int, swap func(i, j int)) {
+	randMutex.Lock()
+	defer randMutex.Unlock()
+
+	random.Shuffle(n, swap)
+}
+
 // RandomHexString creates a base16 random text with given length
 func RandomHexString(length int) string {
 	b := make([]byte, length/2+length%2)
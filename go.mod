@@ -25,6 +25,7 @@ require (
 	golang.org/x/image v0.10.0
 	golang.org/x/sys v0.10.0
 	golang.org/x/text v0.11.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -2,6 +2,7 @@ package launcher
 
 import (
 	"github.com/fsnotify/fsnotify"
+	"github.com/wieku/danser-go/app/database"
 	"github.com/wieku/danser-go/framework/goroutines"
 	"log"
 	"os"
@@ -55,6 +56,8 @@ func setupWatcher(file string, callback func(event fsnotify.Event)) {
 }
 
 func closeWatcher() {
+	database.StopWatching()
+
 	if watcher != nil {
 		err := watcher.Close()
 		if err != nil {
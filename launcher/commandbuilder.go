@@ -47,8 +47,8 @@ type builder struct {
 	currentReplay *rplpa.Replay
 
 	offset intParam
-	start  intParam
-	end    intParam
+	start  floatParam // in seconds
+	end    floatParam
 	skip   bool
 
 	mirrors int32
@@ -106,11 +106,11 @@ func (b *builder) setMap(bMap *beatmap.BeatMap) {
 		value:   float32(bMap.Diff.GetHP()),
 	}
 
-	b.start = intParam{}
+	b.start = floatParam{}
 
-	mEnd := int32(math32.Ceil(float32(b.currentMap.Length) / 1000))
+	mEnd := math32.Ceil(float32(b.currentMap.Length) / 1000)
 
-	b.end = intParam{
+	b.end = floatParam{
 		ogValue: mEnd,
 		value:   mEnd,
 		changed: false,
@@ -220,11 +220,11 @@ func (b *builder) getArguments() (args []string) {
 	}
 
 	if b.start.changed {
-		args = append(args, "-start", strconv.FormatFloat(float64(b.start.value), 'f', 1, 32))
+		args = append(args, "-start", strconv.FormatFloat(float64(b.start.value), 'f', 3, 32))
 	}
 
 	if b.end.changed {
-		args = append(args, "-end", strconv.FormatFloat(float64(b.end.value), 'f', 1, 32))
+		args = append(args, "-end", strconv.FormatFloat(float64(b.end.value), 'f', 3, 32))
 	}
 
 	if b.speed.changed {
@@ -3,8 +3,12 @@ package launcher
 import (
 	"fmt"
 	"github.com/inkyblackness/imgui-go/v4"
+	"github.com/wieku/danser-go/app/beatmap"
 	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/knockout"
 	"github.com/wieku/danser-go/app/utils"
+	"github.com/wieku/danser-go/framework/goroutines"
+	"slices"
 	"strconv"
 )
 
@@ -18,6 +22,26 @@ type knockoutManagerPopup struct {
 	lastSelected int
 
 	countEnabled int
+
+	sortBy        string
+	topN          int32
+	bestPerPlayer bool
+	includeMods   string
+	excludeMods   string
+	from          string
+	to            string
+	minCombo      int32
+
+	pp          map[*knockoutReplay]float64
+	rulesStatus string
+	ppResults   chan ppResult
+}
+
+// ppResult is handed from pp calculation goroutine to the draw loop, replays can't be modified while they're drawn
+type ppResult struct {
+	pp    map[*knockoutReplay]float64
+	rules knockout.Rules
+	err   any
 }
 
 func newKnockoutManagerPopup(bld *builder) *knockoutManagerPopup {
@@ -26,6 +50,8 @@ func newKnockoutManagerPopup(bld *builder) *knockoutManagerPopup {
 		bld:           bld,
 		includeSwitch: true,
 		lastSelected:  -1,
+		sortBy:        "Score",
+		ppResults:     make(chan ppResult, 1),
 	}
 
 	rm.internalDraw = rm.drawManager
@@ -54,6 +80,19 @@ func (km *knockoutManagerPopup) refreshCount() {
 }
 
 func (km *knockoutManagerPopup) drawManager() {
+	select {
+	case result := <-km.ppResults:
+		km.rulesStatus = ""
+
+		if result.err != nil {
+			showMessage(mError, "Failed to calculate pp: %s", result.err)
+		} else {
+			km.pp = result.pp
+			km.selectReplays(result.rules)
+		}
+	default:
+	}
+
 	imgui.PushFont(Font20)
 
 	numText := "No replays"
@@ -67,7 +106,9 @@ func (km *knockoutManagerPopup) drawManager() {
 
 	imgui.PopFont()
 
-	if imgui.BeginTableV("replay table", 9, imgui.TableFlagsBorders|imgui.TableFlagsScrollY, vec2(-1, imgui.ContentRegionAvail().Y), -1) {
+	km.drawRules()
+
+	if imgui.BeginTableV("replay table", 10, imgui.TableFlagsBorders|imgui.TableFlagsScrollY, vec2(-1, imgui.ContentRegionAvail().Y), -1) {
 		imgui.TableSetupScrollFreeze(0, 1)
 
 		imgui.TableSetupColumnV("", imgui.TableColumnFlagsWidthFixed|imgui.TableColumnFlagsNoSort, 0, uint(0))
@@ -79,6 +120,7 @@ func (km *knockoutManagerPopup) drawManager() {
 		imgui.TableSetupColumnV("50", imgui.TableColumnFlagsWidthFixed|imgui.TableColumnFlagsNoSort, 0, uint(6))
 		imgui.TableSetupColumnV("Miss", imgui.TableColumnFlagsWidthFixed|imgui.TableColumnFlagsNoSort, 0, uint(7))
 		imgui.TableSetupColumnV("Combo", imgui.TableColumnFlagsWidthFixed|imgui.TableColumnFlagsNoSort, 0, uint(8))
+		imgui.TableSetupColumnV("Date", imgui.TableColumnFlagsWidthFixed|imgui.TableColumnFlagsNoSort, 0, uint(9))
 
 		imgui.TableHeadersRow()
 
@@ -122,6 +164,8 @@ func (km *knockoutManagerPopup) drawManager() {
 			textColumn(utils.Humanize(pReplay.CountMiss))
 
 			textColumn(utils.Humanize(pReplay.MaxCombo))
+
+			textColumn(pReplay.Timestamp.Format("2006-01-02"))
 		}
 
 		if changed > -1 {
@@ -146,3 +190,189 @@ func (km *knockoutManagerPopup) drawManager() {
 		imgui.EndTable()
 	}
 }
+
+func (km *knockoutManagerPopup) drawRules() {
+	if !imgui.CollapsingHeader("Selection rules") {
+		return
+	}
+
+	if imgui.BeginTableV("rules table", 4, imgui.TableFlagsSizingStretchSame, vec2(-1, 0), -1) {
+		ruleCell("Rank by", func() {
+			if imgui.BeginCombo("##rankby", km.sortBy) {
+				justOpened := imgui.IsWindowAppearing()
+
+				for _, sort := range []string{"Score", "PP", "Accuracy"} {
+					if selectableFocus(sort, sort == km.sortBy, justOpened) {
+						km.sortBy = sort
+					}
+				}
+
+				imgui.EndCombo()
+			}
+		})
+
+		ruleCell("Top N (0 = all)", func() {
+			if imgui.InputInt("##topn", &km.topN) {
+				km.topN = max(km.topN, 0)
+			}
+		})
+
+		ruleCell("Required mods", func() {
+			imgui.InputTextWithHint("##includemods", "e.g. HDDT", &km.includeMods)
+		})
+
+		ruleCell("Excluded mods", func() {
+			imgui.InputTextWithHint("##excludemods", "e.g. NFEZ", &km.excludeMods)
+		})
+
+		ruleCell("Played after", func() {
+			imgui.InputTextWithHint("##from", "YYYY-MM-DD", &km.from)
+		})
+
+		ruleCell("Played before", func() {
+			imgui.InputTextWithHint("##to", "YYYY-MM-DD", &km.to)
+		})
+
+		ruleCell("Minimum combo", func() {
+			if imgui.InputInt("##mincombo", &km.minCombo) {
+				km.minCombo = max(km.minCombo, 0)
+			}
+		})
+
+		ruleCell("Best per player", func() {
+			imgui.Checkbox("##bestperplayer", &km.bestPerPlayer)
+		})
+
+		imgui.EndTable()
+	}
+
+	if km.rulesStatus != "" {
+		imgui.PushItemFlag(imgui.ItemFlagsDisabled, true)
+		imgui.Button("Apply rules")
+		imgui.PopItemFlag()
+
+		imgui.SameLine()
+		imgui.Text(km.rulesStatus)
+	} else if imgui.Button("Apply rules") {
+		km.applyRules()
+	}
+}
+
+func ruleCell(label string, draw func()) {
+	imgui.TableNextColumn()
+	imgui.AlignTextToFramePadding()
+	imgui.Text(label)
+
+	imgui.TableNextColumn()
+	imgui.SetNextItemWidth(-1)
+	draw()
+}
+
+// applyRules includes only replays passing the rules and moves them to the top in ranked order
+func (km *knockoutManagerPopup) applyRules() {
+	rules := knockout.Rules{
+		SortBy:        km.sortBy,
+		TopN:          int(km.topN),
+		BestPerPlayer: km.bestPerPlayer,
+		IncludeMods:   difficulty.ParseMods(km.includeMods),
+		ExcludeMods:   difficulty.ParseMods(km.excludeMods),
+		MinCombo:      int64(km.minCombo),
+	}
+
+	var err error
+
+	if rules.From, err = knockout.ParseDate(km.from, false); err == nil {
+		rules.To, err = knockout.ParseDate(km.to, true)
+	}
+
+	if err != nil {
+		showMessage(mError, "Invalid selection rules: %s", err)
+		return
+	}
+
+	if rules.SortBy == "PP" && km.pp == nil {
+		if km.rulesStatus != "" {
+			return
+		}
+
+		km.rulesStatus = "Calculating pp..."
+
+		// Goroutine works only on copies, results are applied in the draw loop
+		bMap := km.bld.currentMap.Clone()
+		replays := slices.Clone(km.bld.knockoutReplays)
+		candidates := km.getCandidates()
+
+		goroutines.Run(func() {
+			result := ppResult{rules: rules}
+
+			defer func() {
+				if err := recover(); err != nil { //TODO: Technically should be fixed but unexpected parsing problem won't crash whole process
+					result.err = err
+				}
+
+				km.ppResults <- result
+			}()
+
+			beatmap.ParseTimingPointsAndPauses(bMap)
+			beatmap.ParseObjects(bMap, true, false)
+
+			knockout.CalculatePP(bMap, candidates)
+
+			result.pp = make(map[*knockoutReplay]float64)
+			for i, r := range replays {
+				result.pp[r] = candidates[i].PP
+			}
+		})
+
+		return
+	}
+
+	km.selectReplays(rules)
+}
+
+func (km *knockoutManagerPopup) getCandidates() []*knockout.Candidate {
+	candidates := make([]*knockout.Candidate, 0, len(km.bld.knockoutReplays))
+
+	for _, r := range km.bld.knockoutReplays {
+		candidate := knockout.NewCandidate(r.path, r.parsedReplay)
+		candidate.PP = km.pp[r]
+
+		candidates = append(candidates, candidate)
+	}
+
+	return candidates
+}
+
+func (km *knockoutManagerPopup) selectReplays(rules knockout.Rules) {
+	replays := km.bld.knockoutReplays
+
+	candidates := km.getCandidates()
+
+	byCandidate := make(map[*knockout.Candidate]*knockoutReplay)
+	for i, c := range candidates {
+		byCandidate[c] = replays[i]
+	}
+
+	sorted := make([]*knockoutReplay, 0, len(replays))
+
+	for _, c := range knockout.Select(candidates, rules) {
+		replay := byCandidate[c]
+		replay.included = true
+
+		sorted = append(sorted, replay)
+
+		delete(byCandidate, c)
+	}
+
+	for i, c := range candidates {
+		if _, ok := byCandidate[c]; ok {
+			replays[i].included = false
+			sorted = append(sorted, replays[i])
+		}
+	}
+
+	km.bld.knockoutReplays = sorted
+	km.lastSelected = -1
+
+	km.refreshCount()
+}
@@ -176,6 +176,7 @@ type launcher struct {
 	snow *drawables.Snow
 
 	timeMenu *timePopup
+	timeline *timelinePopup
 }
 
 func StartLauncher() {
@@ -467,6 +468,8 @@ func (l *launcher) loadBeatmaps() {
 			l.splashText = bSplash + fmt.Sprintf("%d / %d\n%.0f%%", processed, target, percent)
 		})
 
+		database.StartModStarRating(beatmaps)
+
 		for _, bMap := range beatmaps {
 			l.beatmaps = append(l.beatmaps, bMap)
 		}
@@ -863,6 +866,14 @@ func (l *launcher) drawControls() {
 						database.UpdateLocalOffset(l.bld.currentMap)
 					}
 				})
+
+				l.timeMenu.openTimeline = func() {
+					if l.timeline == nil {
+						l.timeline = newTimelinePopup(l.bld)
+					}
+
+					l.openPopup(l.timeline)
+				}
 			}
 
 			l.openPopup(l.timeMenu)
@@ -985,6 +996,55 @@ func (l *launcher) trySelectReplaysFromPaths(p []string) {
 	}
 }
 
+// trySelectReplaysFromLibrary loads indexed replays of currently selected map from danser's replay library
+func (l *launcher) trySelectReplaysFromLibrary() {
+	if l.bld.currentMap == nil {
+		showMessage(mError, "Please select a map first.")
+		return
+	}
+
+	if err := database.IndexReplays(filepath.Join(env.DataDir(), "replays")); err != nil {
+		showMessage(mError, "Failed to index replays! Error: %s", err)
+		return
+	}
+
+	infos, err := database.GetReplays(database.ReplayFilter{BeatmapMD5: l.bld.currentMap.MD5})
+	if err != nil {
+		showMessage(mError, "Failed to load replays from the library! Error: %s", err)
+		return
+	}
+
+	if len(infos) == 0 {
+		showMessage(mInfo, "There are no replays of this map in the library.\nPlace them in \"%s\" folder.", filepath.Join(env.DataDir(), "replays"))
+		return
+	}
+
+	replays := make([]*knockoutReplay, 0, len(infos))
+
+	for _, info := range infos {
+		replays = append(replays, &knockoutReplay{
+			path: info.Path,
+			parsedReplay: &rplpa.Replay{
+				BeatmapMD5: info.BeatmapMD5,
+				Username:   info.Player,
+				Count300:   uint16(info.Count300),
+				Count100:   uint16(info.Count100),
+				Count50:    uint16(info.Count50),
+				CountMiss:  uint16(info.CountMiss),
+				Score:      int32(info.Score),
+				MaxCombo:   uint16(info.MaxCombo),
+				Mods:       uint32(info.Mods),
+				Timestamp:  info.Date,
+			},
+			included: true,
+		})
+	}
+
+	l.bld.currentMode = Knockout
+	l.bld.knockoutReplays = replays
+	l.knockoutManager = newKnockoutManagerPopup(l.bld)
+}
+
 func (l *launcher) trySelectReplay(replay *knockoutReplay) {
 	for _, bMap := range l.beatmaps {
 		if strings.ToLower(bMap.MD5) == strings.ToLower(replay.parsedReplay.BeatmapMD5) {
@@ -1021,6 +1081,12 @@ func (l *launcher) newKnockout() {
 		}
 	}
 
+	imgui.SameLine()
+
+	if imgui.ButtonV("From library", bSize) {
+		l.trySelectReplaysFromLibrary()
+	}
+
 	imgui.PopFont()
 
 	imgui.PushFont(Font20)
@@ -1847,18 +1913,7 @@ func (l *launcher) reloadMaps(after func()) {
 
 		// Add to main thread scheduler to avoid race conditions
 		mainthread.CallNonBlock(func() {
-			if l.bld.currentMap != nil {
-				for _, b := range l.beatmaps {
-					if b.MD5 == l.bld.currentMap.MD5 {
-						l.bld.currentMap = b
-						break
-					}
-				}
-			}
-
-			if l.selectWindow != nil {
-				l.selectWindow.setBeatmaps(l.beatmaps)
-			}
+			l.refreshBeatmaps()
 
 			if after != nil {
 				after()
@@ -1867,7 +1922,41 @@ func (l *launcher) reloadMaps(after func()) {
 	})
 }
 
+// applySyncedMaps replaces beatmap list with the one synced by database's watcher, called outside of main thread
+func (l *launcher) applySyncedMaps(beatmaps []*beatmap.BeatMap) {
+	slices.SortFunc(beatmaps, func(a, b *beatmap.BeatMap) int {
+		return cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+
+	mainthread.CallNonBlock(func() {
+		l.beatmaps = beatmaps
+
+		l.refreshBeatmaps()
+	})
+}
+
+// refreshBeatmaps points current map and song select to the reloaded beatmaps
+func (l *launcher) refreshBeatmaps() {
+	if l.bld.currentMap != nil {
+		for _, b := range l.beatmaps {
+			if b.MD5 == l.bld.currentMap.MD5 {
+				l.bld.currentMap = b
+				break
+			}
+		}
+	}
+
+	if l.selectWindow != nil {
+		l.selectWindow.setBeatmaps(l.beatmaps)
+	}
+}
+
 func (l *launcher) setupWatcher() {
+	if settings.General.ChangeJournal && !launcherConfig.SkipMapUpdate {
+		database.WatchSongs(l.applySyncedMaps)
+		return
+	}
+
 	setupWatcher(settings.General.GetSongsDir(), func(event fsnotify.Event) {
 		l.showBeatmapAlert = qpc.GetMilliTimeF() + 3000 //Wait for the last map to load on osu side
 		l.beatmapDirUpdated = true
@@ -56,6 +56,8 @@ func (m *modPopup) drawModMenu() {
 			m.modCheckbox(difficulty.SpunOut, difficulty.Relax2)
 
 			m.modCheckbox(difficulty.ScoreV2, difficulty.None)
+
+			m.modCheckbox(difficulty.Target, difficulty.None)
 		})
 
 		imgui.EndTable()
@@ -125,6 +127,8 @@ func (m *modPopup) modCheckbox(mod, incompat difficulty.Modifier) (ret bool) {
 		sF := mod.StringFull()[0]
 		if sF == "Relax2" {
 			sF = "AutoPilot"
+		} else if sF == "Target" {
+			sF = "Target Practice"
 		}
 
 		imgui.SetTooltip(sF)
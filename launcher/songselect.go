@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"github.com/inkyblackness/imgui-go/v4"
 	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/beatmap/difficulty"
+	"github.com/wieku/danser-go/app/database"
 	"github.com/wieku/danser-go/app/settings"
+	"github.com/wieku/danser-go/app/utils"
 	"github.com/wieku/danser-go/framework/bass"
 	"github.com/wieku/danser-go/framework/graphics/texture"
 	"github.com/wieku/danser-go/framework/math/animation"
@@ -13,6 +16,7 @@ import (
 	"github.com/wieku/danser-go/framework/platform"
 	"github.com/wieku/danser-go/framework/qpc"
 	"github.com/wieku/danser-go/framework/util"
+	"log"
 	"math"
 	"math/rand"
 	"path/filepath"
@@ -58,7 +62,7 @@ type mapWithName struct {
 
 func newMapWithName(bMap *beatmap.BeatMap) *mapWithName {
 	return &mapWithName{
-		name: strings.ToLower(fmt.Sprintf("%s - %s [%s] by %s %d %d", bMap.Artist, bMap.Name, bMap.Difficulty, bMap.Creator, bMap.SetID, bMap.ID)),
+		name: beatmap.SearchString(bMap),
 		bMap: bMap,
 	}
 }
@@ -103,6 +107,14 @@ type songSelectPopup struct {
 	focusTheMap         bool
 
 	comboOpened bool
+
+	collections []*database.Collection
+	collection  *database.Collection
+
+	nameOpened bool
+	nameRename bool
+	nameStr    string
+	nameMap    *beatmap.BeatMap
 }
 
 func newSongSelectPopup(bld *builder, beatmaps []*beatmap.BeatMap) *songSelectPopup {
@@ -127,6 +139,7 @@ func (m *songSelectPopup) setBeatmaps(beatmaps []*beatmap.BeatMap) {
 	}
 
 	m.beatmaps = beatmaps2
+	m.loadCollections()
 	m.search()
 	m.focusTheMap = true
 }
@@ -211,6 +224,10 @@ func (m *songSelectPopup) drawSongSelect() {
 		ImIO.SetFontGlobalScale(1)
 		imgui.PopFont()
 
+		imgui.SameLine()
+
+		m.drawCollectionCombo()
+
 		imgui.TableNextColumn()
 
 		if imgui.Button("Random") {
@@ -422,6 +439,8 @@ func (m *songSelectPopup) drawSongSelect() {
 				if imgui.IsItemHovered() && ImIO.MousePosition().X <= sPos.X+tSiz.X {
 					m.showMapTooltip(bMap)
 				}
+
+				m.drawCollectionMenu(bMap, "##collctx"+rId+"s"+strconv.Itoa(j))
 			}
 
 			imgui.PopFont()
@@ -451,6 +470,250 @@ func (m *songSelectPopup) drawSongSelect() {
 	imgui.EndChild()
 
 	imgui.WindowDrawList().AddLine(csPos, csPos.Plus(vec2(imgui.ContentRegionAvail().X, 0)), imgui.PackedColorFromVec4(imgui.CurrentStyle().Color(imgui.StyleColorSeparator)))
+
+	m.drawCollectionNamePopup()
+}
+
+func (m *songSelectPopup) drawCollectionCombo() {
+	imgui.AlignTextToFramePadding()
+	imgui.Text("Collection:")
+
+	imgui.SameLine()
+
+	imgui.SetNextItemWidth(250)
+
+	cName := "All beatmaps"
+	if m.collection != nil {
+		cName = collectionLabel(m.collection)
+	}
+
+	if imgui.BeginComboV("##collectioncombo", cName, imgui.ComboFlagsHeightLarge) {
+		m.comboOpened = true
+
+		if imgui.SelectableV("All beatmaps", m.collection == nil, 0, vzero()) && m.collection != nil {
+			m.setCollection(nil)
+		}
+
+		for i, c := range m.collections {
+			if c.Stable && (i == 0 || !m.collections[i-1].Stable) {
+				imgui.Separator()
+			}
+
+			if imgui.SelectableV(fmt.Sprintf("%s (%d)##coll%d", collectionLabel(c), len(c.MD5s), c.ID), c == m.collection, 0, vzero()) && c != m.collection {
+				m.setCollection(c)
+			}
+		}
+
+		imgui.EndCombo()
+	}
+
+	imgui.SameLine()
+
+	if imgui.Button("New##collection") {
+		m.openNamePopup(false, nil)
+	}
+
+	e := m.collection == nil || m.collection.Stable
+
+	if e {
+		imgui.PushItemFlag(imgui.ItemFlagsDisabled, true)
+	}
+
+	imgui.SameLine()
+
+	if imgui.Button("Rename##collection") {
+		m.openNamePopup(true, nil)
+	}
+
+	imgui.SameLine()
+
+	if imgui.Button("Delete##collection") {
+		if showMessage(mQuestion, "Are you sure you want to delete \"%s\" collection?", m.collection.Name) {
+			if err := database.DeleteCollection(m.collection); err != nil {
+				showMessage(mError, "Failed to delete collection: %s", err)
+			} else {
+				m.setCollection(nil)
+				m.loadCollections()
+			}
+		}
+	}
+
+	if e {
+		imgui.PopItemFlag()
+	}
+}
+
+// drawCollectionMenu draws right click menu of a difficulty for adding it to or removing it from danser's collections
+func (m *songSelectPopup) drawCollectionMenu(bMap *beatmap.BeatMap, id string) {
+	if !imgui.BeginPopupContextItemV(id, imgui.PopupFlagsMouseButtonRight) {
+		return
+	}
+
+	m.comboOpened = true
+	openedAbove = true
+
+	for _, c := range m.collections {
+		if c.Stable {
+			continue
+		}
+
+		contains := c.Contains(bMap.MD5)
+
+		if imgui.MenuItemV(fmt.Sprintf("%s##collmenu%d", c.Name, c.ID), "", contains, true) {
+			var err error
+
+			if contains {
+				err = database.RemoveFromCollection(c, bMap.MD5)
+			} else {
+				err = database.AddToCollection(c, bMap.MD5)
+			}
+
+			if err != nil {
+				showMessage(mError, "Failed to update collection: %s", err)
+			}
+
+			if c == m.collection {
+				m.search()
+			}
+		}
+	}
+
+	if len(m.collections) > 0 && !m.collections[0].Stable {
+		imgui.Separator()
+	}
+
+	if imgui.MenuItem("New collection...") {
+		m.openNamePopup(false, bMap)
+	}
+
+	imgui.EndPopup()
+}
+
+func (m *songSelectPopup) openNamePopup(rename bool, bMap *beatmap.BeatMap) {
+	m.nameOpened = true
+	m.nameRename = rename
+	m.nameMap = bMap
+	m.nameStr = ""
+
+	if rename {
+		m.nameStr = m.collection.Name
+	}
+}
+
+func (m *songSelectPopup) drawCollectionNamePopup() {
+	if !m.nameOpened {
+		return
+	}
+
+	m.comboOpened = true
+
+	popupSmall("Collection name box", &m.nameOpened, true, func() {
+		if imgui.BeginTable("cnfa", 1) {
+			imgui.TableNextColumn()
+
+			imgui.Text("Name:")
+
+			imgui.SameLine()
+
+			imgui.SetNextItemWidth(imgui.TextLineHeight() * 10)
+
+			imgui.InputText("##collectionname", &m.nameStr)
+
+			if !imgui.IsAnyItemActive() && !imgui.IsMouseClicked(0) {
+				imgui.SetKeyboardFocusHereV(-1)
+			}
+
+			imgui.TableNextColumn()
+
+			cPos := imgui.CursorPos()
+
+			imgui.SetCursorPos(vec2(cPos.X+(imgui.ContentRegionAvail().X-imgui.CalcTextSize("Save", false, 0).X-imgui.CurrentStyle().FramePadding().X*2)/2, cPos.Y))
+
+			e := strings.TrimSpace(m.nameStr) == ""
+
+			if e {
+				imgui.PushItemFlag(imgui.ItemFlagsDisabled, true)
+			}
+
+			if imgui.Button("Save##collectionname") || (!e && (imgui.IsKeyPressed(imgui.KeyEnter) || imgui.IsKeyPressed(imgui.KeyKeypadEnter))) {
+				if err := m.saveCollectionName(); err != nil {
+					showMessage(mError, "%s", err)
+				} else {
+					m.nameOpened = false
+				}
+			}
+
+			if e {
+				imgui.PopItemFlag()
+			}
+
+			imgui.EndTable()
+		}
+	})
+}
+
+func (m *songSelectPopup) saveCollectionName() error {
+	if m.nameRename {
+		if err := database.RenameCollection(m.collection, m.nameStr); err != nil {
+			return err
+		}
+
+		m.loadCollections()
+
+		return nil
+	}
+
+	c, err := database.CreateCollection(m.nameStr)
+	if err != nil {
+		return err
+	}
+
+	if m.nameMap != nil {
+		if err = database.AddToCollection(c, m.nameMap.MD5); err != nil {
+			return err
+		}
+	}
+
+	m.loadCollections()
+
+	return nil
+}
+
+func (m *songSelectPopup) loadCollections() {
+	collections, err := database.GetCollections()
+	if err != nil {
+		log.Println("SongSelect: Failed to load collections:", err)
+	}
+
+	m.collections = collections
+
+	if m.collection == nil {
+		return
+	}
+
+	id := m.collection.ID
+	m.collection = nil
+
+	for _, c := range collections {
+		if c.ID == id {
+			m.collection = c
+			break
+		}
+	}
+}
+
+func (m *songSelectPopup) setCollection(c *database.Collection) {
+	m.collection = c
+	m.search()
+	m.focusTheMap = true
+}
+
+func collectionLabel(c *database.Collection) string {
+	if c.Stable {
+		return "osu!: " + c.Name
+	}
+
+	return c.Name
 }
 
 func (m *songSelectPopup) showMapTooltip(bMap *beatmap.BeatMap) {
@@ -503,6 +766,12 @@ func (m *songSelectPopup) showMapTooltip(bMap *beatmap.BeatMap) {
 	sR := "N/A"
 	if bMap.Stars >= 0 {
 		sR = mutils.FormatWOZeros(bMap.Stars, 2)
+
+		if masked := difficulty.GetDiffMaskedMods(m.bld.mods); masked != difficulty.None {
+			if mStars, ok := bMap.GetStarsFor(masked); ok {
+				sR += fmt.Sprintf(" (%s %s)", mutils.FormatWOZeros(mStars, 2), masked.String())
+			}
+		}
 	}
 
 	bpm := fmt.Sprintf("%.0f", bMap.MinBPM)
@@ -542,9 +811,22 @@ func (m *songSelectPopup) showMapTooltip(bMap *beatmap.BeatMap) {
 		tRow("Length: ", util.FormatSeconds(bMap.Length/1000))
 		tRow("", "")
 
+		tRow("Status: ", bMap.RankedStatus.String())
+		tRow("", "")
+
 		imgui.EndTable()
 	}
 
+	if best := database.GetLocalBest(bMap.MD5); best != nil {
+		mods := ""
+		if best.Mods != difficulty.None {
+			mods = " +" + best.Mods.String()
+		}
+
+		imgui.Text(fmt.Sprintf("Local best: %s%s", utils.Humanize(best.Score), mods))
+		imgui.Text(fmt.Sprintf("%.2f%% %dx by %s", best.Accuracy, best.MaxCombo, best.Player))
+	}
+
 	imgui.PopFont()
 	imgui.EndTooltip()
 }
@@ -631,19 +913,35 @@ func (m *songSelectPopup) search() {
 	m.sizeCalculated = 0
 	m.searchResults = m.searchResults[:0]
 
-	sString := strings.ToLower(m.searchStr)
+	query := beatmap.ParseQuery(m.searchStr)
+
+	var inCollection map[string]struct{}
+
+	if m.collection != nil {
+		inCollection = make(map[string]struct{}, len(m.collection.MD5s))
+
+		for _, md5 := range m.collection.MD5s {
+			inCollection[strings.ToLower(md5)] = struct{}{}
+		}
+	}
 
 	foundMaps := make([]*beatmap.BeatMap, 0, len(m.beatmaps))
 
 	for _, b := range m.beatmaps {
-		if sString != "" && !strings.Contains(b.name, sString) {
+		if inCollection != nil {
+			if _, ok := inCollection[strings.ToLower(b.bMap.MD5)]; !ok {
+				continue
+			}
+		}
+
+		if !query.MatchesWith(b.bMap, b.name) {
 			continue
 		}
 
 		foundMaps = append(foundMaps, b.bMap)
 	}
 
-	sortMaps(foundMaps, launcherConfig.SortMapsBy)
+	sortMaps(foundMaps, launcherConfig.SortMapsBy, m.bld.mods)
 
 	for _, b := range foundMaps {
 		if len(m.searchResults) == 0 || m.searchResults[len(m.searchResults)-1].bMaps[0].Dir != b.Dir {
@@ -658,6 +956,10 @@ func (m *songSelectPopup) search() {
 }
 
 func (m *songSelectPopup) open() {
+	if launcherConfig.SortMapsBy == Difficulty { // Selected mods or cached star ratings may have changed
+		m.search()
+	}
+
 	m.focusTheMap = true
 
 	m.popup.open()
@@ -687,7 +989,8 @@ func compareStrings(l, r string) int {
 	return -1
 }
 
-func sortMaps(bMaps []*beatmap.BeatMap, sortBy SortBy) {
+// sortMaps sorts beatmaps, difficulty uses star rating for given mods if it's already calculated
+func sortMaps(bMaps []*beatmap.BeatMap, sortBy SortBy, mods difficulty.Modifier) {
 	slices.SortStableFunc(bMaps, func(b1, b2 *beatmap.BeatMap) int {
 		var res int
 
@@ -705,7 +1008,10 @@ func sortMaps(bMaps []*beatmap.BeatMap, sortBy SortBy) {
 				res = 0
 			}
 		case Difficulty:
-			res = cmp.Compare(b1.Stars, b2.Stars)
+			s1, _ := b1.GetStarsFor(mods)
+			s2, _ := b2.GetStarsFor(mods)
+
+			res = cmp.Compare(s1, s2)
 		}
 
 		if !launcherConfig.SortAscending {
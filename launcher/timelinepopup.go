@@ -0,0 +1,510 @@
+package launcher
+
+import (
+	"fmt"
+	"github.com/inkyblackness/imgui-go/v4"
+	"github.com/wieku/danser-go/app/beatmap"
+	"github.com/wieku/danser-go/app/beatmap/objects"
+	"github.com/wieku/danser-go/app/rulesets/osu/performance/pp220930"
+	"github.com/wieku/danser-go/framework/goroutines"
+	"github.com/wieku/danser-go/framework/math/math32"
+	"github.com/wieku/danser-go/framework/math/mutils"
+	"github.com/wieku/danser-go/framework/util"
+	"math"
+	"sync"
+)
+
+const (
+	timelineStrainHeight = 90
+	timelineTimingHeight = 22
+	timelineObjHeight    = 36
+	timelineRulerHeight  = 20
+
+	timelineMinZoom = 1000.0 // Shortest visible range in ms
+)
+
+// timelinePopup shows map's timeline like osu!'s editor, start, end and screenshot times can be picked on it
+type timelinePopup struct {
+	*popup
+
+	bld *builder
+
+	status string
+
+	loadedMap *beatmap.BeatMap // bld.currentMap the timeline was loaded for
+	tMap      *beatmap.BeatMap // parsed copy
+	peaks     pp220930.StrainPeaks
+	mapEnd    float64
+
+	zoom   float64 // visible range in ms
+	scroll float64 // time at the left edge
+	cursor float64
+
+	scrubbing bool
+
+	// Guards fields below, timeline is loaded in a separate goroutine
+	mutex   sync.Mutex
+	loading *beatmap.BeatMap // map that's being loaded
+	result  *timelineResult
+}
+
+// timelineResult is published by the loader goroutine and picked up by the draw loop
+type timelineResult struct {
+	tMap   *beatmap.BeatMap
+	peaks  pp220930.StrainPeaks
+	mapEnd float64
+	failed bool
+}
+
+func newTimelinePopup(bld *builder) *timelinePopup {
+	mP := &timelinePopup{
+		popup:  newPopup("Timeline", popBig),
+		bld:    bld,
+		status: "No map selected",
+	}
+
+	mP.internalDraw = mP.drawTimeline
+
+	return mP
+}
+
+func (m *timelinePopup) load() {
+	m.collectResult()
+
+	if m.loadedMap == m.bld.currentMap {
+		return
+	}
+
+	m.loadedMap = m.bld.currentMap
+	m.tMap = nil
+
+	m.mutex.Lock()
+	m.loading = m.loadedMap
+	m.result = nil
+	m.mutex.Unlock()
+
+	if m.loadedMap == nil {
+		m.status = "No map selected"
+		return
+	}
+
+	m.status = "Loading timeline..."
+
+	source := m.loadedMap
+	bMap := source.Clone()
+
+	goroutines.Run(func() {
+		result := &timelineResult{}
+
+		defer func() {
+			if err := recover(); err != nil {
+				result = &timelineResult{failed: true}
+			}
+
+			m.mutex.Lock()
+
+			// Don't publish results for a map that's not selected anymore
+			if m.loading == source {
+				m.result = result
+			}
+
+			m.mutex.Unlock()
+		}()
+
+		beatmap.ParseTimingPointsAndPauses(bMap)
+		beatmap.ParseObjects(bMap, true, false)
+
+		result.peaks = pp220930.CalculateStrainPeaks(bMap.HitObjects, bMap.Diff)
+
+		mapEnd := float64(bMap.Length)
+		if len(bMap.HitObjects) > 0 {
+			mapEnd = max(mapEnd, bMap.HitObjects[len(bMap.HitObjects)-1].GetEndTime())
+		}
+
+		result.mapEnd = max(mapEnd+2000, 1000)
+		result.tMap = bMap
+	})
+}
+
+// collectResult applies timeline loaded by the loader goroutine, if it finished
+func (m *timelinePopup) collectResult() {
+	m.mutex.Lock()
+
+	result := m.result
+	m.result = nil
+
+	m.mutex.Unlock()
+
+	if result == nil {
+		return
+	}
+
+	if result.failed {
+		m.status = "Failed to load"
+		return
+	}
+
+	m.peaks = result.peaks
+	m.mapEnd = result.mapEnd
+
+	m.zoom = m.mapEnd
+	m.scroll = 0
+	m.cursor = float64(m.bld.start.value) * 1000
+
+	m.tMap = result.tMap
+
+	m.status = ""
+}
+
+func (m *timelinePopup) drawTimeline() {
+	m.load()
+
+	if m.tMap == nil {
+		centerTable("timelinestatus", -1, func() {
+			imgui.Text(m.status)
+		})
+
+		return
+	}
+
+	m.drawControls()
+
+	imgui.Separator()
+
+	m.drawCanvas()
+
+	m.drawScrollbar()
+}
+
+func (m *timelinePopup) drawControls() {
+	start := &m.bld.start
+	end := &m.bld.end
+
+	if imgui.BeginTableV("timelinectrl", 2, imgui.TableFlagsSizingStretchProp, vec2(-1, 0), -1) {
+		imgui.TableNextColumn()
+
+		imgui.AlignTextToFramePadding()
+		imgui.Text(fmt.Sprintf("Cursor: %s", formatEditorTime(m.cursor)))
+
+		imgui.SameLine()
+
+		if imgui.Button("Set start") {
+			start.value = float32(min(m.cursor, float64(end.value)*1000-1000) / 1000)
+			start.value = max(start.value, 0)
+			start.changed = start.value != start.ogValue
+		}
+
+		imgui.SameLine()
+
+		if imgui.Button("Set end") {
+			end.value = float32(max(m.cursor, float64(start.value)*1000+1000) / 1000)
+			end.value = min(end.value, end.ogValue)
+			end.changed = end.value != end.ogValue
+		}
+
+		imgui.SameLine()
+
+		if imgui.Button("Set screenshot") {
+			m.bld.ssTime = float32(mutils.Clamp(m.cursor/1000, 0, float64(end.ogValue)))
+		}
+
+		imgui.SameLine()
+
+		if imgui.Button("Reset times") {
+			start.value, start.changed = start.ogValue, false
+			end.value, end.changed = end.ogValue, false
+		}
+
+		imgui.TableNextColumn()
+
+		imgui.AlignTextToFramePadding()
+		imgui.Text("Zoom:")
+
+		imgui.SameLine()
+
+		zoom := float32(m.mapEnd / m.zoom)
+
+		imgui.SetNextItemWidth(200)
+		if sliderFloatSlide("##timelinezoom", &zoom, 1, float32(max(m.mapEnd/timelineMinZoom, 1)), "%.1fx", imgui.SliderFlagsLogarithmic|imgui.SliderFlagsNoInput) {
+			m.setZoom(m.mapEnd/float64(zoom), m.cursor)
+		}
+
+		imgui.EndTable()
+	}
+
+	imgui.PushFont(Font16)
+	imgui.Text(fmt.Sprintf("Start: %s    End: %s    Screenshot: %s (used in Screenshot mode)", formatEditorTime(float64(start.value)*1000), formatEditorTime(float64(end.value)*1000), formatEditorTime(float64(m.bld.ssTime)*1000)))
+	imgui.Text("Left click/drag: move cursor, mouse wheel: zoom, right drag: scroll, double click: set screenshot time")
+	imgui.PopFont()
+}
+
+func (m *timelinePopup) drawCanvas() {
+	width := imgui.ContentRegionAvail().X
+	height := float32(timelineStrainHeight + timelineTimingHeight + timelineObjHeight + timelineRulerHeight)
+
+	pos := imgui.CursorScreenPos()
+
+	imgui.InvisibleButtonV("##timelinecanvas", vec2(width, height), imgui.ButtonFlagsMouseButtonLeft|imgui.ButtonFlagsMouseButtonRight)
+
+	hovered := imgui.IsItemHovered()
+	active := imgui.IsItemActive()
+
+	mouseTime := m.scroll + float64((imgui.MousePos().X-pos.X)/width)*m.zoom
+
+	if hovered {
+		if _, wheel := ImIO.MouseWheel(); wheel != 0 {
+			m.setZoom(m.zoom*math.Pow(0.8, float64(wheel)), mouseTime)
+		}
+
+		if imgui.IsMouseDoubleClicked(0) {
+			m.bld.ssTime = float32(mutils.Clamp(mouseTime/1000, 0, float64(m.bld.end.ogValue)))
+		}
+	}
+
+	if active && imgui.IsMouseDown(0) {
+		m.scrubbing = true
+	} else if !imgui.IsMouseDown(0) {
+		m.scrubbing = false
+	}
+
+	if m.scrubbing {
+		m.cursor = mutils.Clamp(mouseTime, 0, m.mapEnd)
+
+		// Follow the cursor when it's dragged outside
+		if m.cursor < m.scroll {
+			m.scroll = m.cursor
+		} else if m.cursor > m.scroll+m.zoom {
+			m.scroll = m.cursor - m.zoom
+		}
+	}
+
+	if active && imgui.IsMouseDragging(1, 0) {
+		delta := imgui.MouseDragDelta(1, 0)
+		imgui.ResetMouseDragDelta(1)
+
+		m.setScroll(m.scroll - float64(delta.X/width)*m.zoom)
+	}
+
+	dl := imgui.WindowDrawList()
+	dl.PushClipRect(pos, pos.Plus(vec2(width, height)))
+
+	toX := func(time float64) float32 {
+		return pos.X + float32((time-m.scroll)/m.zoom)*width
+	}
+
+	col := func(r, g, b, a float32) imgui.PackedColor {
+		return imgui.PackedColorFromVec4(vec4(r, g, b, a))
+	}
+
+	dl.AddRectFilled(pos, pos.Plus(vec2(width, height)), col(0, 0, 0, 0.4))
+
+	y := pos.Y
+
+	m.drawStrains(dl, toX, y, col)
+
+	y += timelineStrainHeight
+
+	m.drawTimingTrack(dl, toX, y, width, col)
+
+	y += timelineTimingHeight
+
+	m.drawObjects(dl, toX, y, col)
+
+	y += timelineObjHeight
+
+	m.drawRuler(dl, toX, y, width, col)
+
+	// Dim parts outside of selected range
+	startX := toX(float64(m.bld.start.value) * 1000)
+	endX := toX(float64(m.bld.end.value) * 1000)
+
+	dl.AddRectFilled(pos, vec2(startX, pos.Y+height), col(0, 0, 0, 0.5))
+	dl.AddRectFilled(vec2(endX, pos.Y), pos.Plus(vec2(width, height)), col(0, 0, 0, 0.5))
+
+	marker := func(x float32, c imgui.PackedColor) {
+		dl.AddLineV(vec2(x, pos.Y), vec2(x, pos.Y+height), c, 2)
+	}
+
+	marker(startX, col(0.3, 1, 0.3, 1))
+	marker(endX, col(1, 0.3, 0.3, 1))
+	marker(toX(float64(m.bld.ssTime)*1000), col(0.3, 0.6, 1, 1))
+	marker(toX(m.cursor), col(1, 1, 1, 1))
+
+	dl.PopClipRect()
+
+	if hovered && !m.scrubbing {
+		imgui.BeginTooltip()
+		imgui.Text(formatEditorTime(max(mouseTime, 0)))
+		imgui.EndTooltip()
+	}
+}
+
+func (m *timelinePopup) drawStrains(dl imgui.DrawList, toX func(float64) float32, y float32, col func(r, g, b, a float32) imgui.PackedColor) {
+	strains := m.peaks.Total
+	if len(strains) < 2 || len(m.tMap.HitObjects) < 2 {
+		return
+	}
+
+	// Same time mapping as in play.StrainGraph
+	strainStart := m.tMap.HitObjects[1].GetStartTime()
+	strainLength := m.tMap.HitObjects[len(m.tMap.HitObjects)-1].GetStartTime() - strainStart
+
+	maxStrain := 0.0
+	for _, s := range strains {
+		maxStrain = max(maxStrain, s)
+	}
+
+	if maxStrain <= 0 {
+		return
+	}
+
+	sectionLength := strainLength / float64(len(strains)-1)
+
+	bottom := y + timelineStrainHeight - 2
+
+	for i, s := range strains {
+		t := strainStart + float64(i)*sectionLength
+
+		x1 := toX(t)
+		x2 := max(toX(t+sectionLength), x1+1)
+
+		h := float32(s/maxStrain) * (timelineStrainHeight - 8)
+
+		dl.AddRectFilled(vec2(x1, bottom-h), vec2(x2, bottom), col(0.9, 0.9, 0.9, 0.6))
+	}
+}
+
+func (m *timelinePopup) drawTimingTrack(dl imgui.DrawList, toX func(float64) float32, y, width float32, col func(r, g, b, a float32) imgui.PackedColor) {
+	points := m.tMap.Timings.GetPoints()
+
+	// Kiai sections
+	for i, p := range points {
+		if !p.Kiai {
+			continue
+		}
+
+		end := m.mapEnd
+		for _, p2 := range points[i+1:] {
+			if !p2.Kiai {
+				end = p2.Time
+				break
+			}
+		}
+
+		dl.AddRectFilled(vec2(toX(p.Time), y+2), vec2(toX(end), y+timelineTimingHeight-2), col(1, 0.6, 0.1, 0.35))
+	}
+
+	for _, p := range m.tMap.Pauses {
+		dl.AddRectFilled(vec2(toX(p.StartTime), y+2), vec2(toX(p.EndTime), y+timelineTimingHeight-2), col(0.5, 0.5, 0.5, 0.5))
+	}
+
+	for _, p := range points {
+		x := toX(p.Time)
+		if x < toX(m.scroll)-1 || x > toX(m.scroll)+width+1 {
+			continue
+		}
+
+		c := col(1, 0.2, 0.2, 1)
+		if p.Inherited {
+			c = col(0.2, 0.9, 0.2, 0.8)
+		}
+
+		dl.AddLine(vec2(x, y+2), vec2(x, y+timelineTimingHeight-2), c)
+	}
+}
+
+func (m *timelinePopup) drawObjects(dl imgui.DrawList, toX func(float64) float32, y float32, col func(r, g, b, a float32) imgui.PackedColor) {
+	center := y + timelineObjHeight/2
+	radius := float32(timelineObjHeight) / 4
+
+	minTime := m.scroll - 1000
+	maxTime := m.scroll + m.zoom + 1000
+
+	for _, o := range m.tMap.HitObjects {
+		if o.GetEndTime() < minTime {
+			continue
+		}
+
+		if o.GetStartTime() > maxTime {
+			break
+		}
+
+		x1 := toX(o.GetStartTime())
+		x2 := toX(o.GetEndTime())
+
+		switch o.GetType() {
+		case objects.SLIDER:
+			dl.AddRectFilledV(vec2(x1, center-radius), vec2(max(x2, x1+1), center+radius), col(0.3, 0.6, 1, 0.8), radius, 0)
+		case objects.SPINNER:
+			dl.AddRectFilledV(vec2(x1, center-radius*1.5), vec2(max(x2, x1+1), center+radius*1.5), col(0.8, 0.8, 0.8, 0.5), 2, 0)
+		default:
+			dl.AddCircleFilled(vec2(x1, center), radius, col(1, 0.4, 0.7, 0.9))
+		}
+	}
+}
+
+func (m *timelinePopup) drawRuler(dl imgui.DrawList, toX func(float64) float32, y, width float32, col func(r, g, b, a float32) imgui.PackedColor) {
+	// Pick a tick step that gives roughly 100px between labels
+	steps := []float64{100, 250, 500, 1000, 2000, 5000, 10000, 15000, 30000, 60000, 120000, 300000}
+
+	step := steps[len(steps)-1]
+	for _, s := range steps {
+		if float32(s/m.zoom)*width >= 100 {
+			step = s
+			break
+		}
+	}
+
+	dl.AddLine(vec2(toX(m.scroll), y), vec2(toX(m.scroll)+width, y), col(1, 1, 1, 0.3))
+
+	for t := math.Floor(m.scroll/step) * step; t <= m.scroll+m.zoom; t += step {
+		x := toX(t)
+
+		dl.AddLine(vec2(x, y), vec2(x, y+5), col(1, 1, 1, 0.6))
+		dl.AddText(vec2(x+3, y+2), col(1, 1, 1, 0.8), formatEditorTime(max(t, 0)))
+	}
+}
+
+func (m *timelinePopup) drawScrollbar() {
+	if m.zoom >= m.mapEnd {
+		return
+	}
+
+	scroll := float32(m.scroll / 1000)
+
+	imgui.SetNextItemWidth(-1)
+	if sliderFloatSlide("##timelinescroll", &scroll, 0, float32((m.mapEnd-m.zoom)/1000), formatEditorTime(m.scroll), imgui.SliderFlagsNoInput) {
+		m.setScroll(float64(scroll) * 1000)
+	}
+}
+
+// setZoom changes visible range keeping anchor time at the same position
+func (m *timelinePopup) setZoom(zoom, anchor float64) {
+	zoom = mutils.Clamp(zoom, timelineMinZoom, m.mapEnd)
+
+	progress := (anchor - m.scroll) / m.zoom
+
+	m.zoom = zoom
+
+	m.setScroll(anchor - progress*zoom)
+}
+
+func (m *timelinePopup) setScroll(scroll float64) {
+	m.scroll = mutils.Clamp(scroll, 0, max(m.mapEnd-m.zoom, 0))
+}
+
+// formatEditorTime formats time in ms like osu!'s editor does: mm:ss:ms
+func formatEditorTime(time float64) string {
+	t := int64(math.Round(time))
+
+	return fmt.Sprintf("%02d:%02d:%03d", t/60000, (t/1000)%60, t%1000)
+}
+
+// formatTime formats time in seconds, fractional times are shown with milliseconds
+func formatTime(seconds float32) string {
+	if seconds != math32.Floor(seconds) {
+		return formatEditorTime(float64(seconds) * 1000)
+	}
+
+	return util.FormatSeconds(int(seconds))
+}
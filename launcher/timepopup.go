@@ -12,7 +12,6 @@ import (
 	"github.com/wieku/danser-go/framework/graphics/buffer"
 	"github.com/wieku/danser-go/framework/graphics/viewport"
 	"github.com/wieku/danser-go/framework/math/math32"
-	"github.com/wieku/danser-go/framework/util"
 )
 
 type timePopup struct {
@@ -32,8 +31,10 @@ type timePopup struct {
 
 	fBatch *batch.QuadBatch
 
-	lastStart int32
-	lastEnd   int32
+	lastStart float32
+	lastEnd   float32
+
+	openTimeline func()
 }
 
 func newTimePopup(bld *builder) *timePopup {
@@ -70,7 +71,10 @@ func (m *timePopup) drawTimeMenu() {
 	imgui.Text("Start time:")
 	imgui.PushFont(Font16)
 	imgui.SetNextItemWidth(-1)
-	if sliderIntSlide("##Start time", &start.value, 0, end.ogValue-1, util.FormatSeconds(int(start.value)), imgui.SliderFlagsNoInput) {
+
+	startS := int32(start.value)
+	if sliderIntSlide("##Start time", &startS, 0, int32(end.ogValue)-1, formatTime(start.value), imgui.SliderFlagsNoInput) {
+		start.value = float32(startS)
 		start.changed = start.value != start.ogValue
 	}
 	imgui.PopFont()
@@ -82,7 +86,10 @@ func (m *timePopup) drawTimeMenu() {
 	imgui.Text("End time:")
 	imgui.PushFont(Font16)
 	imgui.SetNextItemWidth(-1)
-	if sliderIntSlide("##End time", &end.value, 1, end.ogValue, util.FormatSeconds(int(end.value)), imgui.SliderFlagsNoInput) {
+
+	endS := int32(math32.Ceil(end.value))
+	if sliderIntSlide("##End time", &endS, 1, int32(end.ogValue), formatTime(end.value), imgui.SliderFlagsNoInput) {
+		end.value = float32(endS)
 		end.changed = end.value != end.ogValue
 	}
 	imgui.PopFont()
@@ -93,6 +100,11 @@ func (m *timePopup) drawTimeMenu() {
 
 	m.drawStrainGraph()
 
+	if m.openTimeline != nil && imgui.ButtonV("Pick times on timeline...", vec2(-1, 0)) {
+		m.opened = false
+		m.openTimeline()
+	}
+
 	sliderIntReset("Audio offset", &m.bld.offset, -300, 300, "%dms")
 }
 
@@ -153,7 +165,7 @@ func (m *timePopup) drawStrainGraph() {
 			m.lastStart = m.bld.start.value
 			m.lastEnd = m.bld.end.value
 
-			m.sGraph.SetTimes(float64(m.lastStart*1000), float64(m.lastEnd*1000))
+			m.sGraph.SetTimes(float64(m.lastStart)*1000, float64(m.lastEnd)*1000)
 
 			// keep viewport on screen scaling
 			viewport.Push(int(settings.Graphics.GetWidth()), int(settings.Graphics.GetHeight()))